@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/plan"
+	"github.com/castisdev/cdn-simul/simul"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func main() {
+	cfgFile := flag.String("cfg", "cdn-simul.json", "base config file, first vod is template of planned vods (session limit, cache policy), parents are used as is")
+	dbFile := flag.String("db", "chunk.db", "event db")
+	outFile := flag.String("out", "", "planned config file to write. if empty, not written")
+	lbType := flag.String("lb", "hash", "hash | weight-storage | weight-storage-bps | dup2 | high-low | bounded-hash | hrw | choices | least-conn | least-bps | legacy")
+	start := flag.String("start", "", "simulation start time, (ex)2017-01-01 00:00:00.000")
+	readEventCount := flag.Int("event-count", 0, "event count to process. if 0, process all event")
+	maxOrigin := flag.Int64("max-origin-peak-bps", 0, "target max origin peak bps. if 0, not checked")
+	minHit := flag.Float64("min-hit-ratio", 0, "target min cache hit ratio, (ex)0.9. if 0, not checked")
+	maxRejected := flag.Int64("max-rejected", 0, "target max rejected session count. if negative, not checked")
+	minVODs := flag.Int("min-vods", 1, "min vod count")
+	maxVODs := flag.Int("max-vods", 10, "max vod count")
+	minStorage := flag.Int64("min-storage", 100000000000, "min storage size (byte) of vod, must be larger than chunk size")
+	maxStorage := flag.Int64("max-storage", 10000000000000, "max storage size (byte) of vod")
+	storageStep := flag.Int64("storage-step", 100000000000, "storage size resolution (byte) of search")
+	minBps := flag.Int64("min-bps", 0, "min limit bps of vod")
+	maxBps := flag.Int64("max-bps", 20000000000, "max limit bps of vod")
+	bpsStep := flag.Int64("bps-step", 100000000, "limit bps resolution of search")
+	vodCost := flag.Float64("vod-cost", 1, "cost of a vod")
+	storageCost := flag.Float64("storage-cost", 0.1, "cost of 1TB storage")
+	bpsCost := flag.Float64("bps-cost", 0.1, "cost of 1Gbps")
+	flag.Parse()
+
+	b, err := ioutil.ReadFile(*cfgFile)
+	if err != nil {
+		log.Fatalf("failed to read cfg, %v", err)
+	}
+	cfg := data.Config{}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		log.Fatalf("failed to unmarsharl cfg json, %v", err)
+	}
+	meta, err := data.LoadLogMeta(*dbFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := simul.CheckLogMeta(cfg, meta); err != nil {
+		log.Printf("warning, %v\n", err)
+	}
+	switch *lbType {
+	case "filebase", "replication":
+		log.Fatalf("lb %v is not supported, it needs file infos", *lbType)
+	}
+
+	// 같은 trace 를 여러 번 실행하므로 memory 에 한 번만 읽음
+	db, err := leveldb.OpenFile(*dbFile, nil)
+	if err != nil {
+		log.Fatalf("failed to open db, %v", err)
+	}
+	events := simul.LoadEvents(simul.NewDBEventReader(db))
+	db.Close()
+	log.Printf("%v sessions loaded from %v\n", len(events), *dbFile)
+
+	opt := simul.Options{
+		MaxReadEventCount: *readEventCount,
+		StatusWritePeriod: 24 * time.Hour,
+	}
+	if *start != "" {
+		opt.StartTime = simul.StrToTime(*start)
+	}
+	eval := &plan.SimEvaluator{
+		Events: events,
+		Opt:    opt,
+		LBOpt:  simul.LBOption{LBType: *lbType},
+	}
+	target := plan.Target{MaxOriginPeakBps: *maxOrigin, MinHitRatio: *minHit, MaxRejected: *maxRejected}
+	space := plan.Space{
+		MinVODs:     *minVODs,
+		MaxVODs:     *maxVODs,
+		MinStorage:  *minStorage,
+		MaxStorage:  *maxStorage,
+		StorageStep: *storageStep,
+		MinBps:      *minBps,
+		MaxBps:      *maxBps,
+		BpsStep:     *bpsStep,
+	}
+	cost := plan.Cost{VOD: *vodCost, StorageTB: *storageCost, Gbps: *bpsCost}
+
+	p, err := plan.Search(cfg, target, space, cost, eval)
+	if err != nil {
+		log.Fatalf("failed to plan, %v", err)
+	}
+	fmt.Printf("plan: vods(%v) storage(%v) limit-bps(%v) cost(%.2f) runs(%v)\n", p.VODCount, p.StorageSize, p.LimitBps, p.Cost, p.Runs)
+	fmt.Printf("result: %v\n", p.Result)
+
+	if *outFile != "" {
+		b, err := json.MarshalIndent(p.Cfg, "", "    ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(*outFile, b, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
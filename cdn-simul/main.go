@@ -11,50 +11,129 @@ import (
 	"time"
 
 	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/lb/cache"
 	"github.com/castisdev/cdn-simul/simul"
 	"github.com/castisdev/gcommon/profile"
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
+// simulation : 명령행 option 으로 만든 simulator
+// LB, FileInfos, selector 는 simulation 마다 따로 생성하므로 여러 simulation 을 동시에 실행할 수 있음
+type simulation struct {
+	si         *simul.Simulator
+	db         *leveldb.DB // event 를 memory 에서 읽으면 nil
+	cpuprofile string
+	memprofile string
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "sweep" {
+		sweep(os.Args[2:])
+		return
+	}
+
+	sim := newSimulation(os.Args[0], os.Args[1:], nil, false)
+	defer sim.db.Close()
+
+	if sim.cpuprofile != "" {
+		if err := profile.StartCPUProfile(sim.cpuprofile); err != nil {
+			log.Fatalf("failed to start cpu profile, %v", err)
+		}
+		defer profile.StopCPUProfile()
+	}
+
+	now := time.Now()
+	sim.si.Run()
+	log.Printf("completed. elapsed:%v\n", time.Since(now))
+
+	if sim.memprofile != "" {
+		f, err := os.Create(sim.memprofile)
+		if err != nil {
+			log.Fatalf("failed to start memory profile, %v", err)
+		}
+		pprof.WriteHeapProfile(f)
+		defer f.Close()
+	}
+}
+
+// newSimulation : events 가 nil 이면 -db 의 event db 를 열어서 읽음
+// sweep 이면 variant 결과만 비교하므로 status 를 출력하지 않음
+func newSimulation(name string, argv []string, events []*glblog.SessionInfo, sweep bool) *simulation {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
 	var cfgFile, dbFile, cpuprofile, memprofile, lp, dbAddr, dbName, lbType, hotListUpdatePeriod, bypass, fbPeriod, simulID, start string
-	var statDu, statDuDel, shiftP, pushP, fiFilepath, lbHistory, adsFile, purgeFile string
-	var readEventCount, hotRankLimit, pushDelayN, dawnPushN int
-	var firstBypass, useSessionDu, useDeleteLru, useFileSize, useTimeWeight, useIdeal bool
-
-	flag.StringVar(&cfgFile, "cfg", "cdn-simul.json", "config file")
-	flag.StringVar(&dbFile, "db", "chunk.db", "event db")
-	flag.IntVar(&readEventCount, "event-count", 0, "event count to process. if 0, process all event")
-	flag.StringVar(&cpuprofile, "cpuprofile", "", "write cpu profile")
-	flag.StringVar(&memprofile, "memprofile", "", "write memory profile")
-	flag.StringVar(&lp, "log-period", "0s", "status logging period (second). if 0, print log after every event")
-	flag.StringVar(&dbAddr, "db-addr", "", "DB address. if empty, not use DB. ex: localhost:8086")
-	flag.StringVar(&dbName, "db-name", "cdn-simul", "database name")
-	flag.StringVar(&lbType, "lb", "hash", "hash | weight-storage | weight-storage-bps | dup2 | high-low | legacy | filebase")
-	flag.StringVar(&hotListUpdatePeriod, "hot-period", "24h", "hot list update period (high-low)")
-	flag.IntVar(&hotRankLimit, "hot-rank", 100, "rank limit of hot list, that contents will be served in high group (high-low)")
-	flag.StringVar(&statDu, "stat-range", "24h", "data collect window size (filebase)")
-	flag.StringVar(&statDuDel, "stat-range-del", "24h", "data collect window size for delete (filebase)")
-	flag.StringVar(&shiftP, "shift-period", "1h", "data collect window shift period (filebase)")
-	flag.StringVar(&pushP, "push-period", "5m", "file push period (filebase)")
-	flag.IntVar(&pushDelayN, "push-delay", 2, "file push delay number, (push time = push-period * push-delay) (filebase)")
-	flag.IntVar(&dawnPushN, "dawn-push", 1, "push contents count in the dawn of day (filebase)")
-	flag.StringVar(&fiFilepath, "file-info", "fileinfo.csv", "csv file path contains id,name,size,bps,register-time (filebase)")
-	flag.StringVar(&lbHistory, "lb-history", "", "LB hitcount history file for initial contents (filebase)")
-	flag.StringVar(&adsFile, "ads-csv", "", "ADSAdapter csv file (filebase)")
-	flag.StringVar(&purgeFile, "purge-csv", "", "purge csv file (filebase)")
-	flag.BoolVar(&useSessionDu, "session-duration", false, "add session duration into hit weight (filebase)")
-	flag.BoolVar(&useDeleteLru, "delete-lru", false, "delete file using with LRU (filebase)")
-	flag.BoolVar(&useFileSize, "file-size", false, "add file size and session duration into hit weight (filebase)")
-	flag.BoolVar(&useTimeWeight, "time-weight", false, "add time weight into hit weight (filebase)")
-	flag.BoolVar(&useIdeal, "ideal-storage", false, "ideal storage mode (filebase)")
-	flag.StringVar(&bypass, "bypass", "", "text file that has contents list to bypass")
-	flag.BoolVar(&firstBypass, "first-bypass", false, "if true, chunks of first hit session for 24h will be bypassed")
-	flag.StringVar(&fbPeriod, "fb-period", "24h", "first bypass list update period (only used with first-bypass option)")
-	flag.StringVar(&simulID, "id", "cdn-simul", "simulation id, that used with tag values in influx DB")
-	flag.StringVar(&start, "start", "", "simulation start point, before that point events will be ignored, (ex)2017-01-01 00:00:00.000")
-
-	flag.Parse()
+	var statDu, statDuDel, shiftP, pushP, fiFilepath, lbHistory, adsFile, purgeFile, cachePolicy, cacheAdmission, faultFile, topologyFile, ladderFile string
+	var readEventCount, hotRankLimit, pushDelayN, dawnPushN, choices, replicas, replicaRank, maxReplicas int
+	var admissionMaxSize, originLimit, qoeOriginRate, chunkSize int64
+	var loadFactor, abrSafety float64
+	var loadMetric, hrwWeight, qoeOriginLatency, qoeBuffer, checkpointFile, checkpointP, resumeFile string
+	var snapshotFile, snapshotT, warmStartFile, warmUp, summaryFile string
+	var firstBypass, overflow, faultMigrate, useSessionDu, useDeleteLru, useFileSize, useTimeWeight, useIdeal, warmRemap bool
+
+	fs.StringVar(&cfgFile, "cfg", "cdn-simul.json", "config file")
+	fs.StringVar(&dbFile, "db", "chunk.db", "event db")
+	fs.IntVar(&readEventCount, "event-count", 0, "event count to process. if 0, process all event")
+	fs.StringVar(&cpuprofile, "cpuprofile", "", "write cpu profile")
+	fs.StringVar(&memprofile, "memprofile", "", "write memory profile")
+	fs.StringVar(&lp, "log-period", "0s", "status logging period (second). if 0, print log after every event")
+	fs.StringVar(&dbAddr, "db-addr", "", "DB address. if empty, not use DB. ex: localhost:8086")
+	fs.StringVar(&dbName, "db-name", "cdn-simul", "database name")
+	fs.StringVar(&lbType, "lb", "hash", "hash | weight-storage | weight-storage-bps | dup2 | high-low | bounded-hash | hrw | choices | least-conn | least-bps | replication | legacy | filebase")
+	fs.StringVar(&cachePolicy, "cache-policy", "", "lru | lfu | arc | 2q | s3fifo | opt | opt-size, cache policy of VODs/parents that has no cachePolicy in cfg (default lru). opt, opt-size require overflow and no origin-limit, fault-csv, topology-csv, ladder-csv")
+	fs.StringVar(&cacheAdmission, "cache-admission", "", "tinylfu | second-hit | size, cache admission of VODs/parents that has no cacheAdmission in cfg (default: admit all)")
+	fs.Int64Var(&admissionMaxSize, "admission-max-size", 0, "max chunk size to be cached (size admission)")
+	fs.Float64Var(&loadFactor, "load-factor", 0.25, "allowed load over average load, (load bound = (1+load-factor) * average load) (bounded-hash)")
+	fs.StringVar(&loadMetric, "load-metric", "session", "session | bps, load metric (bounded-hash, choices, replication)")
+	fs.IntVar(&choices, "choices", 2, "number of consistent hash candidates to compare load. if 0, compare all VODs (choices)")
+	fs.StringVar(&hrwWeight, "hrw-weight", "same", "same | storage | bps | weight, weight of VODs (hrw), weight uses hashWeight in cfg")
+	fs.IntVar(&maxReplicas, "max-replicas", 3, "replica count of the most popular file, others have replicas proportional to hit (replication)")
+	fs.StringVar(&hotListUpdatePeriod, "hot-period", "24h", "hot list update period (high-low, replication)")
+	fs.IntVar(&hotRankLimit, "hot-rank", 100, "rank limit of hot list, that contents will be served in high group (high-low)")
+	fs.StringVar(&statDu, "stat-range", "24h", "data collect window size (filebase, replication)")
+	fs.StringVar(&statDuDel, "stat-range-del", "24h", "data collect window size for delete (filebase)")
+	fs.StringVar(&shiftP, "shift-period", "1h", "data collect window shift period (filebase, replication)")
+	fs.StringVar(&pushP, "push-period", "5m", "file push period (filebase)")
+	fs.IntVar(&pushDelayN, "push-delay", 2, "file push delay number, (push time = push-period * push-delay) (filebase)")
+	fs.IntVar(&dawnPushN, "dawn-push", 1, "push contents count in the dawn of day (filebase)")
+	fs.IntVar(&replicas, "replicas", 1, "number of VODs to store popular file (filebase)")
+	fs.IntVar(&replicaRank, "replica-rank", 100, "files within this rank are stored in replicas VODs (filebase)")
+	fs.StringVar(&fiFilepath, "file-info", "fileinfo.csv", "csv file path contains id,name,size,bps,register-time (filebase, replication)")
+	fs.StringVar(&lbHistory, "lb-history", "", "LB hitcount history file for initial contents (filebase)")
+	fs.StringVar(&adsFile, "ads-csv", "", "ADSAdapter csv file (filebase)")
+	fs.StringVar(&purgeFile, "purge-csv", "", "purge csv file (filebase)")
+	fs.BoolVar(&useSessionDu, "session-duration", false, "add session duration into hit weight (filebase)")
+	fs.BoolVar(&useDeleteLru, "delete-lru", false, "delete file using with LRU (filebase)")
+	fs.BoolVar(&useFileSize, "file-size", false, "add file size and session duration into hit weight (filebase)")
+	fs.BoolVar(&useTimeWeight, "time-weight", false, "add time weight into hit weight (filebase)")
+	fs.BoolVar(&useIdeal, "ideal-storage", false, "ideal storage mode (filebase)")
+	fs.StringVar(&bypass, "bypass", "", "text file that has contents list to bypass")
+	fs.BoolVar(&firstBypass, "first-bypass", false, "if true, chunks of first hit session for 24h will be bypassed")
+	fs.BoolVar(&overflow, "overflow", false, "if true, sessions that no VOD can serve will be served from origin directly, otherwise rejected")
+	fs.Int64Var(&chunkSize, "chunk-size", 0, "chunk size (byte), overrides chunkSize in cfg. if both are 0, 2000000")
+	fs.Int64Var(&originLimit, "origin-limit", 0, "origin bandwidth (bps), if exceeded, chunks from origin are delayed. overrides originLimitBps in cfg")
+	fs.Int64Var(&qoeOriginRate, "qoe-origin-rate", 20000000, "max bps of a session fetching chunks from origin (qoe). if 0, no limit")
+	fs.StringVar(&qoeOriginLatency, "qoe-origin-latency", "100ms", "latency of origin fetch (qoe)")
+	fs.StringVar(&qoeBuffer, "qoe-buffer", "0s", "playback buffer, rebuffering occurs when a chunk fetch takes longer (qoe). if 0, playback duration of a chunk")
+	fs.StringVar(&ladderFile, "ladder-csv", "", "bitrate ladder csv file [file-name,bps(,bps...)], file-name * is default ladder. if set, sessions switch renditions by throughput (abr)")
+	fs.Float64Var(&abrSafety, "abr-safety", simul.DefaultABRSafety, "ratio of measured throughput used to select next rendition (abr)")
+	fs.StringVar(&faultFile, "fault-csv", "", "vod fault schedule csv file [vod-id,down-time,up-time(,wipe-cache)]")
+	fs.BoolVar(&faultMigrate, "fault-migrate", false, "if true, sessions of failed(removed) VOD will be migrated to other VOD, otherwise terminated")
+	fs.StringVar(&topologyFile, "topology-csv", "", "vod topology change schedule csv file [time,add|remove|change,vod-id(,storage-size,limit-session,limit-bps(,parent))]")
+	fs.StringVar(&fbPeriod, "fb-period", "24h", "first bypass list update period (only used with first-bypass option)")
+	fs.StringVar(&simulID, "id", "cdn-simul", "simulation id, that used with tag values in influx DB")
+	fs.StringVar(&start, "start", "", "simulation start point, before that point events will be ignored, (ex)2017-01-01 00:00:00.000")
+	fs.StringVar(&checkpointFile, "checkpoint", "", "checkpoint file to write simulation state periodically. if empty, not written")
+	fs.StringVar(&checkpointP, "checkpoint-period", "24h", "checkpoint period of simulation time")
+	fs.StringVar(&resumeFile, "resume", "", "checkpoint file to resume from, other flags must be same as the checkpointed simulation")
+	fs.StringVar(&snapshotFile, "snapshot", "", "file to write cache/storage contents at snapshot-time, that can be used with warm-start. if empty, not written")
+	fs.StringVar(&snapshotT, "snapshot-time", "", "simulation time to write snapshot, (ex)2017-01-08 00:00:00.000. if empty, written at the end of simulation")
+	fs.StringVar(&warmStartFile, "warm-start", "", "snapshot file to fill caches/storages before simulation. if start is empty, events before snapshot time will be ignored")
+	fs.BoolVar(&warmRemap, "warm-remap", false, "if true, snapshot contents are filled into VODs selected by current lb (hash, hrw, filebase), otherwise VODs of same id")
+	fs.StringVar(&warmUp, "warm-up", "0s", "warm-up period from the first event, statistics of that period are excluded from results")
+	fs.StringVar(&summaryFile, "summary", "", "json file to write end-of-run summary (hit ratio, origin/vod percentiles, hourly/daily breakdown). if empty, not written")
+
+	fs.Parse(argv)
 
 	logPeriod, err := time.ParseDuration(lp)
 	if err != nil {
@@ -64,6 +143,22 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	qoeLatency, err := time.ParseDuration(qoeOriginLatency)
+	if err != nil {
+		log.Fatal(err)
+	}
+	qoeBuf, err := time.ParseDuration(qoeBuffer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cpp, err := time.ParseDuration(checkpointP)
+	if err != nil {
+		log.Fatal(err)
+	}
+	wu, err := time.ParseDuration(warmUp)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	opt := simul.Options{
 		MaxReadEventCount: readEventCount,
@@ -74,17 +169,29 @@ func main() {
 		FirstBypass:       firstBypass,
 		FBPeriod:          fbp,
 		SimulID:           simulID,
+		FaultMigrate:      faultMigrate,
+		QoE: simul.QoEOption{
+			OriginFetchBps: qoeOriginRate,
+			OriginLatency:  qoeLatency,
+			Buffer:         qoeBuf,
+		},
+		ABRSafety:        abrSafety,
+		CheckpointFile:   checkpointFile,
+		CheckpointPeriod: cpp,
+		SnapshotFile:     snapshotFile,
+		WarmUp:           wu,
+		SummaryFile:      summaryFile,
 	}
 	if start != "" {
 		t := simul.StrToTime(start)
 		opt.StartTime = t
 	}
+	if snapshotT != "" {
+		opt.SnapshotTime = simul.StrToTime(snapshotT)
+	}
 
-	if cpuprofile != "" {
-		if err := profile.StartCPUProfile(cpuprofile); err != nil {
-			log.Fatalf("failed to start cpu profile, %v", err)
-		}
-		defer profile.StopCPUProfile()
+	if sweep && opt.StatusWritePeriod == 0 {
+		opt.StatusWritePeriod = 24 * time.Hour
 	}
 
 	f, err := os.OpenFile(cfgFile, os.O_RDONLY, 0755)
@@ -99,19 +206,71 @@ func main() {
 	if err := json.Unmarshal(b, &cfg); err != nil {
 		log.Fatalf("failed to unmarsharl cfg json, %v", err)
 	}
+	for i := range cfg.VODs {
+		if cfg.VODs[i].CachePolicy == "" {
+			cfg.VODs[i].CachePolicy = cachePolicy
+		}
+		if cfg.VODs[i].CacheAdmission == "" {
+			cfg.VODs[i].CacheAdmission = cacheAdmission
+		}
+		if cfg.VODs[i].AdmissionMaxSize == 0 {
+			cfg.VODs[i].AdmissionMaxSize = admissionMaxSize
+		}
+	}
+	if overflow {
+		cfg.Overflow = true
+	}
+	if originLimit != 0 {
+		cfg.OriginLimitBps = originLimit
+	}
+	if chunkSize != 0 {
+		cfg.ChunkSize = chunkSize
+	}
+	sizer, err := simul.NewChunkSizer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := range cfg.Parents {
+		if cfg.Parents[i].CachePolicy == "" {
+			cfg.Parents[i].CachePolicy = cachePolicy
+		}
+		if cfg.Parents[i].CacheAdmission == "" {
+			cfg.Parents[i].CacheAdmission = cacheAdmission
+		}
+		if cfg.Parents[i].AdmissionMaxSize == 0 {
+			cfg.Parents[i].AdmissionMaxSize = admissionMaxSize
+		}
+	}
 
 	var writer simul.StatusWriter
-	if opt.InfluxDBAddr != "" {
+	if sweep {
+		writer = nil
+	} else if opt.InfluxDBAddr != "" {
 		writer = simul.NewMultiStatusWriter([]simul.StatusWriter{&simul.DBStatusWriter{}, &simul.StdStatusWriter{}})
 	} else {
 		writer = &simul.StdStatusWriter{}
 	}
 
-	db, err := leveldb.OpenFile(dbFile, nil)
+	meta, err := data.LoadLogMeta(dbFile)
 	if err != nil {
-		log.Fatalf("failed to open db, %v", err)
+		log.Fatal(err)
+	}
+	if err := simul.CheckLogMeta(cfg, meta); err != nil {
+		log.Printf("warning, %v\n", err)
+	}
+	sim := &simulation{cpuprofile: cpuprofile, memprofile: memprofile}
+	newReader := func() simul.EventReader {
+		return simul.NewMemEventReader(events)
+	}
+	if events == nil {
+		sim.db, err = leveldb.OpenFile(dbFile, nil)
+		if err != nil {
+			log.Fatalf("failed to open db, %v", err)
+		}
+		newReader = func() simul.EventReader {
+			return simul.NewDBEventReader(sim.db)
+		}
 	}
-	defer db.Close()
 
 	var bypassList []string
 	if opt.BypassFile != "" {
@@ -143,7 +302,7 @@ func main() {
 		log.Fatal(err)
 	}
 	var fi *data.FileInfos
-	if lbType == "filebase" {
+	if lbType == "filebase" || lbType == "replication" {
 		fiFile, err := os.Open(fiFilepath)
 		if err != nil {
 			log.Fatal(err)
@@ -177,6 +336,13 @@ func main() {
 		UseFileSize:         useFileSize,
 		UseTimeWeight:       useTimeWeight,
 		UseIdeal:            useIdeal,
+		LoadFactor:          loadFactor,
+		LoadMetric:          loadMetric,
+		HRWWeight:           hrwWeight,
+		Choices:             choices,
+		Replicas:            replicas,
+		ReplicaRank:         replicaRank,
+		MaxReplicas:         maxReplicas,
 	}
 	if lbHistory != "" {
 		initList, err := data.LoadFromLBHistory(lbHistory)
@@ -199,22 +365,88 @@ func main() {
 		}
 		lbOpt.PurgeEvent = purgeEv
 	}
+	policies := make([]string, 0, len(cfg.VODs)+len(cfg.Parents))
+	for _, v := range cfg.VODs {
+		policies = append(policies, v.CachePolicy)
+	}
+	for _, v := range cfg.Parents {
+		policies = append(policies, v.CachePolicy)
+	}
+	for _, v := range policies {
+		if v == cache.PolicyOPT || v == cache.PolicyOPTSize {
+			if err := simul.CheckOracleConfig(cfg); err != nil {
+				log.Fatalf("opt, opt-size cache policy cannot be used, %v", err)
+			}
+			// fault, topology 변경으로 session 이 끝나거나 옮겨지면 oracle 의 chunk 요청과 달라짐
+			if faultFile != "" || topologyFile != "" {
+				log.Fatalf("fault-csv, topology-csv cannot be used with opt, opt-size cache policy")
+			}
+			lbOpt.Oracle = simul.NewOracle(newReader(), sizer, opt.StartTime, opt.MaxReadEventCount)
+			break
+		}
+	}
+	var ladders *data.Ladders
+	if ladderFile != "" {
+		// oracle 은 session 의 rendition 을 알 수 없음
+		if lbOpt.Oracle != nil {
+			log.Fatalf("ladder-csv cannot be used with opt, opt-size cache policy")
+		}
+		ladders, err = data.LoadFromLadderCsv(ladderFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	alb, err := simul.NewLoadBalancer(lbOpt)
 	if err != nil {
 		log.Fatalf("failed to create loadbalancer instance: %v", err)
 	}
-	si := simul.NewSimulator(cfg, opt, alb, simul.NewDBEventReader(db), writer, fi, bypassList)
-
-	now := time.Now()
-	si.Run()
-	log.Printf("completed. elapsed:%v\n", time.Since(now))
-
-	if memprofile != "" {
-		f, err := os.Create(memprofile)
+	if _, ok := alb.(lb.Snapshotter); snapshotFile != "" && !ok {
+		log.Fatalf("snapshot is not supported with lb %v", lbType)
+	}
+	si := simul.NewSimulator(cfg, opt, alb, newReader(), writer, fi, bypassList)
+	if ladders != nil {
+		si.SetLadders(ladders)
+	}
+	if faultFile != "" {
+		faults, err := data.LoadFromFaultCsv(faultFile)
 		if err != nil {
-			log.Fatalf("failed to start memory profile, %v", err)
+			log.Fatal(err)
+		}
+		if err := si.SetFaults(faults); err != nil {
+			log.Fatalf("failed to set faults, %v", err)
+		}
+	}
+	if topologyFile != "" {
+		events, err := data.LoadFromTopologyCsv(topologyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, v := range events {
+			v.VOD.CachePolicy = cachePolicy
+			v.VOD.CacheAdmission = cacheAdmission
+			v.VOD.AdmissionMaxSize = admissionMaxSize
+		}
+		if err := si.SetTopology(events); err != nil {
+			log.Fatalf("failed to set topology, %v", err)
+		}
+	}
+	// resume 하면 checkpoint 의 cache, storage 상태가 warm start 내용을 대체함
+	if warmStartFile != "" && resumeFile == "" {
+		if err := si.WarmStart(warmStartFile, warmRemap); err != nil {
+			log.Fatalf("failed to warm start, %v", err)
+		}
+	}
+	if resumeFile != "" {
+		if err := si.Resume(resumeFile); err != nil {
+			log.Fatalf("failed to resume, %v", err)
+		}
+	}
+	// 실행 전에 기록해 보고 checkpoint 를 지원하지 않는 설정이면 바로 종료
+	if checkpointFile != "" {
+		if err := si.WriteCheckpoint(checkpointFile); err != nil {
+			log.Fatalf("failed to write checkpoint, %v", err)
 		}
-		pprof.WriteHeapProfile(f)
-		defer f.Close()
 	}
+	sim.si = si
+	return sim
 }
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/simul"
+	"github.com/castisdev/cdn-simul/status"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// sweepFile : sweep 설정, key 는 cdn-simul 의 flag 이름 (ex) "lb", "cache-policy"
+// 각 variant 는 base 에 variant args 를 덮어쓰고, grid 의 모든 조합과 곱해짐
+// (ex) {"base": {"cfg": "cdn-simul.json", "db": "session.db"}, "grid": {"cache-policy": ["lru", "lfu"]},
+// "variants": [{"name": "hash", "args": {"lb": "hash"}}, {"name": "hrw", "args": {"lb": "hrw"}}]}
+type sweepFile struct {
+	Base     map[string]interface{}
+	Grid     map[string][]interface{}
+	Variants []sweepVariant
+}
+
+type sweepVariant struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// sweepUnsupportedFlags : 동시에 실행되는 simulation 이 같은 file, DB series 에 기록하거나 상태를 공유하게 되는 flag
+var sweepUnsupportedFlags = []string{"checkpoint", "resume", "snapshot", "summary", "db-addr", "cpuprofile", "memprofile"}
+
+// sweepRun : 실행할 simulation 하나의 이름과 flag 값
+type sweepRun struct {
+	name string
+	args map[string]string
+}
+
+// sweepResult : variant 비교 항목
+type sweepResult struct {
+	name          string
+	hit           int64
+	miss          int64
+	peakOriginBps int64
+	overLimit     time.Duration
+	rejected      int64
+	startupP90    time.Duration
+	totalStall    time.Duration
+	elapsed       time.Duration
+}
+
+func (r sweepResult) hitRatio() float64 {
+	if r.hit+r.miss == 0 {
+		return 0
+	}
+	return float64(r.hit) / float64(r.hit+r.miss)
+}
+
+func sweep(argv []string) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	sweepCfg := fs.String("sweep", "sweep.json", "sweep file, base flags, grid of flag values and variants")
+	outFile := fs.String("out", "sweep.csv", "comparison csv file to write. if empty, not written")
+	parallel := fs.Int("parallel", runtime.NumCPU(), "number of simulations running concurrently")
+	fs.Parse(argv)
+
+	runs, err := loadSweep(*sweepCfg)
+	if err != nil {
+		log.Fatalf("failed to load sweep file, %v", err)
+	}
+	if *parallel < 1 {
+		*parallel = 1
+	}
+
+	// 모든 variant 가 같은 event 를 사용하므로 한 번만 읽어서 공유
+	dbFile := runs[0].args["db"]
+	for _, v := range runs {
+		if v.args["db"] != dbFile {
+			log.Fatalf("all variants must use same db, %v(%v) != %v", v.name, v.args["db"], dbFile)
+		}
+	}
+	if dbFile == "" {
+		dbFile = "chunk.db"
+	}
+	db, err := leveldb.OpenFile(dbFile, nil)
+	if err != nil {
+		log.Fatalf("failed to open db, %v", err)
+	}
+	events := simul.LoadEvents(simul.NewDBEventReader(db))
+	db.Close()
+	if events == nil {
+		// nil 이면 newSimulation 이 db 를 다시 열게 됨
+		events = []*glblog.SessionInfo{}
+	}
+	log.Printf("%v sessions loaded from %v, %v simulations\n", len(events), dbFile, len(runs))
+
+	sims := make([]*simulation, len(runs))
+	for i, v := range runs {
+		log.Printf("setup %v: %v\n", v.name, strings.Join(sweepArgv(v.args), " "))
+		sims[i] = newSimulation(v.name, sweepArgv(v.args), events, true)
+	}
+
+	results := make([]sweepResult, len(runs))
+	sem := make(chan struct{}, *parallel)
+	var wg sync.WaitGroup
+	for i := range sims {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			now := time.Now()
+			sims[i].si.Run()
+			results[i] = newSweepResult(runs[i].name, sims[i].si.Status(), sims[i].si.QoE())
+			results[i].elapsed = time.Since(now)
+			log.Printf("completed %v. elapsed:%v\n", runs[i].name, results[i].elapsed)
+		}(i)
+	}
+	wg.Wait()
+
+	printSweepResults(results)
+	if *outFile != "" {
+		if err := writeSweepResults(*outFile, results); err != nil {
+			log.Fatalf("failed to write sweep result, %v", err)
+		}
+	}
+}
+
+func loadSweep(fpath string) ([]sweepRun, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var sf sweepFile
+	dec := json.NewDecoder(f)
+	// 큰 정수가 1e+07 처럼 바뀌지 않도록 숫자는 원래 문자열 그대로 사용
+	dec.UseNumber()
+	if err := dec.Decode(&sf); err != nil {
+		return nil, err
+	}
+
+	variants := sf.Variants
+	if len(variants) == 0 {
+		variants = []sweepVariant{sweepVariant{}}
+	}
+	gridKeys := make([]string, 0, len(sf.Grid))
+	for k, v := range sf.Grid {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("empty grid values of %v", k)
+		}
+		gridKeys = append(gridKeys, k)
+	}
+	sort.Strings(gridKeys)
+
+	var runs []sweepRun
+	names := make(map[string]struct{})
+	for i, v := range variants {
+		// grid 의 모든 조합, 마지막 key 가 가장 빨리 바뀜
+		idx := make([]int, len(gridKeys))
+		for {
+			args := make(map[string]string)
+			for k, a := range sf.Base {
+				args[k] = fmt.Sprint(a)
+			}
+			for k, a := range v.Args {
+				args[k] = fmt.Sprint(a)
+			}
+			var name []string
+			if v.Name != "" {
+				name = append(name, v.Name)
+			} else if len(sf.Variants) > 0 {
+				name = append(name, fmt.Sprintf("variant%d", i+1))
+			}
+			for j, k := range gridKeys {
+				args[k] = fmt.Sprint(sf.Grid[k][idx[j]])
+				name = append(name, k+"="+args[k])
+			}
+			if len(name) == 0 {
+				name = append(name, "base")
+			}
+			run := sweepRun{name: strings.Join(name, " "), args: args}
+			for _, k := range sweepUnsupportedFlags {
+				if a, ok := args[k]; ok && a != "" {
+					return nil, fmt.Errorf("%v is not supported in sweep, %v", k, run.name)
+				}
+			}
+			if _, ok := names[run.name]; ok {
+				return nil, fmt.Errorf("duplicated variant %v", run.name)
+			}
+			names[run.name] = struct{}{}
+			runs = append(runs, run)
+
+			j := len(idx) - 1
+			for ; j >= 0; j-- {
+				idx[j]++
+				if idx[j] < len(sf.Grid[gridKeys[j]]) {
+					break
+				}
+				idx[j] = 0
+			}
+			if j < 0 {
+				break
+			}
+		}
+	}
+	return runs, nil
+}
+
+func sweepArgv(args map[string]string) []string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ret := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, fmt.Sprintf("-%s=%s", k, args[k]))
+	}
+	return ret
+}
+
+func newSweepResult(name string, st status.Status, q *status.QoEStatus) sweepResult {
+	r := sweepResult{name: name, startupP90: q.StartupP90, totalStall: q.TotalStall}
+	for _, v := range st.Caches {
+		r.hit += v.CacheHitCount
+		r.miss += v.CacheMissCount
+	}
+	if st.Origin != nil {
+		r.peakOriginBps = st.Origin.PeakBps
+		r.overLimit = st.Origin.OverLimitDuration
+	}
+	if st.Reject != nil {
+		r.rejected = st.Reject.RefusedCount()
+	}
+	return r
+}
+
+var sweepHeader = []string{"name", "hit", "miss", "hit-ratio", "peak-origin-bps", "origin-over-limit", "rejected", "startup-p90", "total-stall", "elapsed"}
+
+func (r sweepResult) record() []string {
+	return []string{
+		r.name,
+		fmt.Sprint(r.hit),
+		fmt.Sprint(r.miss),
+		fmt.Sprintf("%.4f", r.hitRatio()),
+		fmt.Sprint(r.peakOriginBps),
+		fmt.Sprint(r.overLimit),
+		fmt.Sprint(r.rejected),
+		fmt.Sprint(r.startupP90),
+		fmt.Sprint(r.totalStall),
+		fmt.Sprint(r.elapsed.Truncate(time.Millisecond)),
+	}
+}
+
+func printSweepResults(results []sweepResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(sweepHeader, "\t"))
+	for _, v := range results {
+		fmt.Fprintln(w, strings.Join(v.record(), "\t"))
+	}
+	w.Flush()
+}
+
+func writeSweepResults(fpath string, results []sweepResult) error {
+	f, err := os.Create(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write(sweepHeader)
+	for _, v := range results {
+		w.Write(v.record())
+	}
+	w.Flush()
+	return w.Error()
+}
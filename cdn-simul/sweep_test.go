@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSweep(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sweep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name      string
+		sweep     string
+		wantNames []string
+		wantArgs  map[string]string // 첫 run 의 args
+		wantErr   bool
+	}{
+		{
+			name:      "base",
+			sweep:     `{"base": {"cfg": "cdn-simul.json", "db": "session.db"}}`,
+			wantNames: []string{"base"},
+			wantArgs:  map[string]string{"cfg": "cdn-simul.json", "db": "session.db"},
+		},
+		{
+			name:  "grid",
+			sweep: `{"base": {"lb": "hash"}, "grid": {"cache-policy": ["lru", "lfu"], "choices": [1, 2]}}`,
+			wantNames: []string{
+				"cache-policy=lru choices=1", "cache-policy=lru choices=2",
+				"cache-policy=lfu choices=1", "cache-policy=lfu choices=2",
+			},
+			wantArgs: map[string]string{"lb": "hash", "cache-policy": "lru", "choices": "1"},
+		},
+		{
+			name: "variants",
+			sweep: `{"base": {"lb": "hash", "cache-policy": "lru"}, "grid": {"choices": [2]},
+				"variants": [{"name": "hrw", "args": {"lb": "hrw"}}, {"args": {"cache-policy": "lfu"}}]}`,
+			wantNames: []string{"hrw choices=2", "variant2 choices=2"},
+			wantArgs:  map[string]string{"lb": "hrw", "cache-policy": "lru", "choices": "2"},
+		},
+		{
+			name:      "large number",
+			sweep:     `{"base": {"origin-limit": 10000000000, "load-factor": 1.25}}`,
+			wantNames: []string{"base"},
+			wantArgs:  map[string]string{"origin-limit": "10000000000", "load-factor": "1.25"},
+		},
+		{
+			name:    "duplicated variant",
+			sweep:   `{"variants": [{"name": "a", "args": {"lb": "hash"}}, {"name": "a", "args": {"lb": "hrw"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty grid",
+			sweep:   `{"grid": {"choices": []}}`,
+			wantErr: true,
+		},
+		{
+			name:    "summary in base",
+			sweep:   `{"base": {"summary": "summary.json"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "db-addr in variant",
+			sweep:   `{"variants": [{"name": "a", "args": {"db-addr": "localhost:8086"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "profile in grid",
+			sweep:   `{"grid": {"cpuprofile": ["a.prof", "b.prof"]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "checkpoint",
+			sweep:   `{"base": {"checkpoint": "simul.checkpoint"}}`,
+			wantErr: true,
+		},
+	}
+	for i, tt := range tests {
+		fpath := filepath.Join(dir, "sweep.json")
+		if err := ioutil.WriteFile(fpath, []byte(tt.sweep), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runs, err := loadSweep(fpath)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("[%v] %v: error must be returned", i, tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%v] %v: %v", i, tt.name, err)
+			continue
+		}
+		var names []string
+		for _, v := range runs {
+			names = append(names, v.name)
+		}
+		if !reflect.DeepEqual(names, tt.wantNames) {
+			t.Errorf("[%v] %v: names %v, expected %v", i, tt.name, names, tt.wantNames)
+		}
+		if !reflect.DeepEqual(runs[0].args, tt.wantArgs) {
+			t.Errorf("[%v] %v: args %v, expected %v", i, tt.name, runs[0].args, tt.wantArgs)
+		}
+	}
+}
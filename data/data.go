@@ -15,17 +15,54 @@ type Session struct {
 	Bps       int64
 }
 
+// DefaultChunkSize : cfg 에 chunkSize 가 없을 때 사용하는 chunk 크기, 로그 가공 도구의 기본값과 같아야 함
+const DefaultChunkSize int64 = 2000000
+
 // Config :
 type Config struct {
-	VODs []VODConfig `json:"vods"`
+	VODs     []VODConfig    `json:"vods"`
+	Parents  []ParentConfig `json:"parents,omitempty"`
+	Overflow bool           `json:"overflow,omitempty"` // true 이면 VOD 가 처리하지 못한 session 을 origin 에서 직접 처리
+	// origin(center) 대역폭, 0 이면 제한 없음
+	// 초과하면 origin 에서 받는 chunk 의 전송 시간이 요구량/limit 비율로 늘어남
+	OriginLimitBps int64 `json:"originLimitBps,omitempty"`
+	// chunk 크기(byte), 0 이면 DefaultChunkSize
+	ChunkSize int64 `json:"chunkSize,omitempty"`
+	// file 이름이 pattern 과 match 되는 file 은 class 의 chunk 크기 사용, 처음 match 되는 class 적용
+	ChunkClasses []ChunkClassConfig `json:"chunkClasses,omitempty"`
+}
+
+// ChunkClassConfig : pattern 은 path.Match 형식, (ex) *HD.mpg
+type ChunkClassConfig struct {
+	Pattern   string `json:"pattern"`
+	ChunkSize int64  `json:"chunkSize"`
+}
+
+// LogMeta : 로그 가공 도구가 event db 와 함께 기록하는 정보, simulator 설정과 맞는지 확인할 때 사용
+type LogMeta struct {
+	ChunkSize int64 `json:"chunkSize"`
 }
 
 // VODConfig :
 type VODConfig struct {
-	VodID        string `json:"vodid"`
-	StorageSize  int64  `json:"storageSize"`
-	LimitSession int64  `json:"limitSession"`
-	LimitBps     int64  `json:"limitBps"`
+	VodID            string  `json:"vodid"`
+	StorageSize      int64   `json:"storageSize"`
+	LimitSession     int64   `json:"limitSession"`
+	LimitBps         int64   `json:"limitBps"`
+	CachePolicy      string  `json:"cachePolicy,omitempty"`      // lru | lfu | arc | 2q | s3fifo | opt | opt-size, 없으면 lru
+	CacheAdmission   string  `json:"cacheAdmission,omitempty"`   // tinylfu | second-hit | size, 없으면 miss 된 모든 chunk 기록
+	AdmissionMaxSize int64   `json:"admissionMaxSize,omitempty"` // size admission 에서 기록할 최대 chunk 크기
+	Parent           string  `json:"parent,omitempty"`           // cache miss 시 요청할 parent id, 없으면 origin 으로 요청
+	HashWeight       float64 `json:"hashWeight,omitempty"`       // rendezvous hashing 의 weight (hrw-weight 가 weight 인 경우)
+}
+
+// ParentConfig : 여러 VOD 가 공유하는 mid-tier cache, cache miss 는 origin 으로 요청
+type ParentConfig struct {
+	ParentID         string `json:"parentid"`
+	StorageSize      int64  `json:"storageSize"`
+	CachePolicy      string `json:"cachePolicy,omitempty"`
+	CacheAdmission   string `json:"cacheAdmission,omitempty"`
+	AdmissionMaxSize int64  `json:"admissionMaxSize,omitempty"`
 }
 
 // SessionEvent :
@@ -55,14 +92,32 @@ type ChunkEvent struct {
 	Bps         int64
 	Index       int64
 	ChunkSize   int64
+	Rendition   int // abr 의 rendition(ladder 의 index), abr 이 아니면 0
 	Bypass      bool
 	IsCenter    bool
 }
 
 func (s ChunkEvent) String() string {
 	layout := "2006-01-02 15:04:05.000"
-	return fmt.Sprintf("Chunk %s %s %s size:%d bps:%d idx:%d chunksize:%d bypass:%v center:%v",
-		s.Time.Format(layout), s.SessionID, s.FileName, s.FileSize, s.Bps, s.Index, s.ChunkSize, s.Bypass, s.IsCenter)
+	return fmt.Sprintf("Chunk %s %s %s size:%d bps:%d idx:%d chunksize:%d rendition:%d bypass:%v center:%v",
+		s.Time.Format(layout), s.SessionID, s.FileName, s.FileSize, s.Bps, s.Index, s.ChunkSize, s.Rendition, s.Bypass, s.IsCenter)
+}
+
+// Ladder : abr rendition 별 bps, 오름차순
+type Ladder []int64
+
+// Ladders : file 별 bitrate ladder, ladder 가 없는 file 은 Default 사용
+type Ladders struct {
+	Default Ladder
+	Files   map[string]Ladder
+}
+
+// Get : file 의 ladder, 없으면 nil
+func (l *Ladders) Get(file string) Ladder {
+	if v, ok := l.Files[file]; ok {
+		return v
+	}
+	return l.Default
 }
 
 // DeliverEvent :
@@ -77,3 +132,26 @@ type PurgeEvent struct {
 	Time     time.Time
 	FileName string
 }
+
+// topology actions
+const (
+	TopologyAdd    = "add"
+	TopologyRemove = "remove"
+	TopologyChange = "change"
+)
+
+// TopologyEvent : Time 에 VOD 추가/삭제/변경
+// remove 는 VOD.VodID 만 사용, change 는 0 이 아닌 StorageSize, LimitSession, LimitBps 만 변경
+type TopologyEvent struct {
+	Time   time.Time
+	Action string
+	VOD    VODConfig
+}
+
+// FaultEvent : DownTime ~ UpTime 동안 VOD 장애(점검)
+type FaultEvent struct {
+	VodID     string
+	DownTime  time.Time
+	UpTime    time.Time
+	WipeCache bool // true 이면 복구 시 cache 를 비움
+}
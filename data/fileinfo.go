@@ -2,10 +2,13 @@ package data
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -212,3 +215,170 @@ func LoadFromPurgeCsv(filepath string) ([]*PurgeEvent, error) {
 	fmt.Printf("loaded from %v, count(%v)\n", filepath, len(events))
 	return events, nil
 }
+
+// LoadFromFaultCsv :
+// fault.csv format : [vod-id,down-time,up-time(,wipe-cache)], time format : 2006-01-02 15:04:05.000
+// wipe-cache 는 true/false, 없으면 false
+func LoadFromFaultCsv(filepath string) ([]*FaultEvent, error) {
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v, %v", filepath, err)
+	}
+	r := csv.NewReader(strings.NewReader(string(b)))
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fault csv file, %v", err)
+	}
+
+	var events []*FaultEvent
+	for _, v := range records {
+		if len(v) < 3 {
+			return nil, fmt.Errorf("invalid fault record %v", v)
+		}
+		f := &FaultEvent{
+			VodID:    strings.Trim(v[0], " "),
+			DownTime: strToTime(strings.Trim(v[1], " ")),
+			UpTime:   strToTime(strings.Trim(v[2], " ")),
+		}
+		if len(v) > 3 {
+			f.WipeCache, err = strconv.ParseBool(strings.Trim(v[3], " "))
+			if err != nil {
+				return nil, fmt.Errorf("invalid wipe-cache %v, %v", v, err)
+			}
+		}
+		if f.DownTime.IsZero() || f.UpTime.Before(f.DownTime) {
+			return nil, fmt.Errorf("invalid fault time %v", v)
+		}
+		events = append(events, f)
+	}
+	fmt.Printf("loaded from %v, count(%v)\n", filepath, len(events))
+	return events, nil
+}
+
+// LoadFromTopologyCsv :
+// topology.csv format : [time,action,vod-id(,storage-size,limit-session,limit-bps(,parent))], time format : 2006-01-02 15:04:05.000
+// action 은 add | remove | change, add 는 storage-size, limit-session, limit-bps 필수
+// change 는 0 이 아닌 값만 변경
+func LoadFromTopologyCsv(filepath string) ([]*TopologyEvent, error) {
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v, %v", filepath, err)
+	}
+	r := csv.NewReader(strings.NewReader(string(b)))
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topology csv file, %v", err)
+	}
+
+	var events []*TopologyEvent
+	for _, v := range records {
+		if len(v) < 3 {
+			return nil, fmt.Errorf("invalid topology record %v", v)
+		}
+		e := &TopologyEvent{
+			Time:   strToTime(strings.Trim(v[0], " ")),
+			Action: strings.Trim(v[1], " "),
+			VOD:    VODConfig{VodID: strings.Trim(v[2], " ")},
+		}
+		if e.Time.IsZero() {
+			return nil, fmt.Errorf("invalid topology time %v", v)
+		}
+		if len(v) >= 6 {
+			var nums [3]int64
+			for i := range nums {
+				nums[i], err = strconv.ParseInt(strings.Trim(v[3+i], " "), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid topology record %v, %v", v, err)
+				}
+			}
+			e.VOD.StorageSize, e.VOD.LimitSession, e.VOD.LimitBps = nums[0], nums[1], nums[2]
+		}
+		if len(v) > 6 {
+			e.VOD.Parent = strings.Trim(v[6], " ")
+		}
+		switch e.Action {
+		case TopologyAdd:
+			if e.VOD.StorageSize <= 0 || e.VOD.LimitSession <= 0 || e.VOD.LimitBps <= 0 {
+				return nil, fmt.Errorf("invalid vod limits to add %v", v)
+			}
+		case TopologyRemove, TopologyChange:
+		default:
+			return nil, fmt.Errorf("invalid topology action %v", v)
+		}
+		events = append(events, e)
+	}
+	fmt.Printf("loaded from %v, count(%v)\n", filepath, len(events))
+	return events, nil
+}
+
+// LoadFromLadderCsv :
+// ladder.csv format : [file-name,bps(,bps...)], file-name 이 * 이면 ladder 가 없는 file 에 사용하는 기본 ladder
+func LoadFromLadderCsv(filepath string) (*Ladders, error) {
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v, %v", filepath, err)
+	}
+	r := csv.NewReader(strings.NewReader(string(b)))
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ladder csv file, %v", err)
+	}
+
+	ladders := &Ladders{Files: make(map[string]Ladder)}
+	for _, v := range records {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("invalid ladder record %v", v)
+		}
+		var l Ladder
+		for _, str := range v[1:] {
+			bps, err := strconv.ParseInt(strings.Trim(str, " "), 10, 64)
+			if err != nil || bps <= 0 {
+				return nil, fmt.Errorf("invalid ladder bps %v", v)
+			}
+			l = append(l, bps)
+		}
+		sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+		if file := strings.Trim(v[0], " "); file == "*" {
+			ladders.Default = l
+		} else {
+			ladders.Files[file] = l
+		}
+	}
+	fmt.Printf("loaded from %v, count(%v) default(%v)\n", filepath, len(ladders.Files), ladders.Default)
+	return ladders, nil
+}
+
+// LogMetaPath : event db 의 LogMeta 파일 경로
+func LogMetaPath(dbPath string) string {
+	return strings.TrimRight(dbPath, "/") + ".meta.json"
+}
+
+// WriteLogMeta :
+func WriteLogMeta(dbPath string, m LogMeta) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(LogMetaPath(dbPath), b, 0644)
+}
+
+// LoadLogMeta : LogMeta 파일이 없으면 nil
+func LoadLogMeta(dbPath string) (*LogMeta, error) {
+	b, err := ioutil.ReadFile(LogMetaPath(dbPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %v, %v", LogMetaPath(dbPath), err)
+	}
+	m := &LogMeta{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %v, %v", LogMetaPath(dbPath), err)
+	}
+	return m, nil
+}
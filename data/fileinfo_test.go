@@ -2,6 +2,7 @@ package data
 
 import (
 	"io/ioutil"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -63,3 +64,122 @@ func TestLoadFromLBHistory(t *testing.T) {
 	}
 
 }
+
+func TestLoadFromFaultCsv(t *testing.T) {
+	fpath := "test.fault.csv"
+	defer os.Remove(fpath)
+
+	data := `vod1,2017-01-01 01:00:00.000,2017-01-01 02:00:00.000
+vod2,2017-01-01 03:00:00.000,2017-01-01 03:30:00.000,true`
+	if err := ioutil.WriteFile(fpath, []byte(data), 0777); err != nil {
+		t.Fatal(err)
+	}
+	v, err := LoadFromFaultCsv(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 2 {
+		t.Fatalf("%v != %v", 2, len(v))
+	}
+	if v[0].VodID != "vod1" || v[0].WipeCache || v[0].UpTime.Sub(v[0].DownTime) != time.Hour {
+		t.Errorf("invalid fault %+v", *v[0])
+	}
+	if v[1].VodID != "vod2" || !v[1].WipeCache {
+		t.Errorf("invalid fault %+v", *v[1])
+	}
+
+	if err := ioutil.WriteFile(fpath, []byte("vod1,2017-01-01 02:00:00.000,2017-01-01 01:00:00.000"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromFaultCsv(fpath); err == nil {
+		t.Errorf("up-time before down-time must be failed")
+	}
+}
+
+func TestLoadFromTopologyCsv(t *testing.T) {
+	fpath := "test.topology.csv"
+	defer os.Remove(fpath)
+
+	data := `2017-01-01 01:00:00.000,add,vod3,1000000000,100,1000000000,parent1
+2017-01-01 02:00:00.000,change,vod1,0,50,0
+2017-01-01 03:00:00.000,remove,vod2`
+	if err := ioutil.WriteFile(fpath, []byte(data), 0777); err != nil {
+		t.Fatal(err)
+	}
+	v, err := LoadFromTopologyCsv(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 3 {
+		t.Fatalf("%v != %v", 3, len(v))
+	}
+	exp := VODConfig{VodID: "vod3", StorageSize: 1000000000, LimitSession: 100, LimitBps: 1000000000, Parent: "parent1"}
+	if v[0].Action != TopologyAdd || v[0].VOD != exp {
+		t.Errorf("invalid topology %+v", *v[0])
+	}
+	if v[1].Action != TopologyChange || v[1].VOD != (VODConfig{VodID: "vod1", LimitSession: 50}) {
+		t.Errorf("invalid topology %+v", *v[1])
+	}
+	if v[2].Action != TopologyRemove || v[2].VOD.VodID != "vod2" {
+		t.Errorf("invalid topology %+v", *v[2])
+	}
+
+	for _, s := range []string{"2017-01-01 01:00:00.000,add,vod3", "2017-01-01 01:00:00.000,move,vod3"} {
+		if err := ioutil.WriteFile(fpath, []byte(s), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFromTopologyCsv(fpath); err == nil {
+			t.Errorf("%v must be failed", s)
+		}
+	}
+}
+
+func TestLoadFromLadderCsv(t *testing.T) {
+	fpath := "test.ladder.csv"
+	defer os.Remove(fpath)
+
+	data := `*,3000000,1000000
+a.mpg,500000,1000000,4000000`
+	if err := ioutil.WriteFile(fpath, []byte(data), 0777); err != nil {
+		t.Fatal(err)
+	}
+	v, err := LoadFromLadderCsv(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := v.Get("a.mpg"); len(l) != 3 || l[0] != 500000 || l[2] != 4000000 {
+		t.Errorf("invalid ladder of a.mpg %v", l)
+	}
+	if l := v.Get("b.mpg"); len(l) != 2 || l[0] != 1000000 || l[1] != 3000000 {
+		t.Errorf("invalid default ladder %v", l)
+	}
+
+	for _, s := range []string{"a.mpg", "a.mpg,0", "a.mpg,1000k"} {
+		if err := ioutil.WriteFile(fpath, []byte(s), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFromLadderCsv(fpath); err == nil {
+			t.Errorf("%v must be failed", s)
+		}
+	}
+}
+
+func TestLogMeta(t *testing.T) {
+	dbPath := "test.db"
+	defer os.Remove(LogMetaPath(dbPath))
+
+	m, err := LoadLogMeta(dbPath)
+	if err != nil || m != nil {
+		t.Fatalf("not exists meta must be nil, %v %v", m, err)
+	}
+	if err := WriteLogMeta(dbPath, LogMeta{ChunkSize: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	m, err = LoadLogMeta(dbPath + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ChunkSize != 1000 {
+		t.Errorf("%v != %v", m.ChunkSize, 1000)
+	}
+}
@@ -15,13 +15,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/castisdev/cdn-simul/data"
 	"github.com/castisdev/cdn-simul/glblog"
 	"github.com/castisdev/cdn-simul/loginfo"
 	"github.com/castisdev/cdn-simul/vodlog"
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
-var chunkSize float64 = 2 * 1024 * 1024
 var avgBitrate int
 
 type fileInfo struct {
@@ -33,6 +33,7 @@ func main() {
 	sdir := flag.String("sdir", "", "source directory")
 	sdbfn := flag.String("sdb", "sid.db", "session db")
 	assetOnly := flag.Bool("asset-only", false, "make only asset data")
+	chunkSize := flag.Int64("chunk-size", data.DefaultChunkSize, "chunk size (byte) of simulation, recorded in session.db.meta.json, simulator warns if it differs")
 	flag.Parse()
 
 	var db *leveldb.DB
@@ -43,6 +44,9 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
+		if err := data.WriteLogMeta("session.db", data.LogMeta{ChunkSize: *chunkSize}); err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	sdb, err := leveldb.OpenFile(*sdbfn, nil)
@@ -232,6 +236,7 @@ func doOneFile(fpath string, smap map[string]*glblog.SessionInfo, fmap map[strin
 						}
 					}
 					sort.Sort(vodlog.Sorter(logs))
+					logs, si.Tricks = splitTricks(logs, si.Started, si.Ended)
 					if len(logs) == 1 {
 						si.Offset = logs[0].StartOffset
 					} else if len(logs) > 1 {
@@ -301,6 +306,31 @@ func doOneFile(fpath string, smap map[string]*glblog.SessionInfo, fmap map[strin
 	}
 }
 
+var trickTypes = map[int64]glblog.TrickType{
+	vodlog.Seek:  glblog.TrickSeek,
+	vodlog.Pause: glblog.TrickPause,
+	vodlog.Play:  glblog.TrickPlay,
+	vodlog.FF:    glblog.TrickFF,
+	vodlog.RW:    glblog.TrickRW,
+}
+
+// splitTricks : 시간순 event 에서 usage event 와 session 시간 안의 trick-play event 를 분리
+func splitTricks(logs []vodlog.EventLog, started, ended time.Time) ([]vodlog.EventLog, []glblog.Trick) {
+	var usages []vodlog.EventLog
+	var tricks []glblog.Trick
+	for _, l := range logs {
+		if l.IsTrick() == false {
+			usages = append(usages, l)
+			continue
+		}
+		if l.EventTime.Before(started) || l.EventTime.After(ended) {
+			continue
+		}
+		tricks = append(tricks, glblog.Trick{Time: l.EventTime, Type: trickTypes[l.Type], Offset: l.StartOffset})
+	}
+	return usages, tricks
+}
+
 func writeEvent(batch *leveldb.Batch, sid string, tm time.Time, etype glblog.EventType, fname string, idx int) *glblog.Event {
 	c := glblog.Event{
 		SID:       sid,
@@ -5,6 +5,46 @@ import (
 	"time"
 )
 
+// TrickType :
+type TrickType int
+
+const (
+	// TrickSeek : Offset 으로 이동하여 재생
+	TrickSeek TrickType = iota
+	// TrickPause : 다음 Play, Seek 까지 재생 중지
+	TrickPause
+	// TrickPlay : 재생 재개, Offset 이 -1 이 아니면 Offset 에서 재개
+	TrickPlay
+	// TrickFF : 다음 Play, Seek 까지 일반 chunk 요청 없음
+	TrickFF
+	// TrickRW : 다음 Play, Seek 까지 일반 chunk 요청 없음
+	TrickRW
+)
+
+func (t TrickType) String() string {
+	switch t {
+	case TrickSeek:
+		return "seek"
+	case TrickPause:
+		return "pause"
+	case TrickPlay:
+		return "play"
+	case TrickFF:
+		return "ff"
+	case TrickRW:
+		return "rw"
+	default:
+		return "unknown"
+	}
+}
+
+// Trick : session 중의 trick-play event
+type Trick struct {
+	Time   time.Time
+	Type   TrickType
+	Offset int64 // byte, -1 이면 위치 정보 없음
+}
+
 // SessionInfo :
 type SessionInfo struct {
 	SID       string
@@ -15,6 +55,7 @@ type SessionInfo struct {
 	Offset    int64
 	Filesize  int64
 	IsCenter  bool
+	Tricks    []Trick // 시간순
 }
 
 func (s SessionInfo) String() string {
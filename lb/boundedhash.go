@@ -0,0 +1,62 @@
+package lb
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+// BoundedHash : consistent hashing with bounded loads
+// hash 순서대로 VOD 를 선택하되, 부하가 (1+loadFactor) * 평균 부하(limit 비례) 를 넘는 VOD 는 건너뜀
+type BoundedHash struct {
+	SameHashingWeight
+	loadFactor float64
+	metric     string
+}
+
+// NewBoundedHash : metric 은 LoadSession, LoadBps 중 하나
+func NewBoundedHash(loadFactor float64, metric string) VODSelector {
+	return &BoundedHash{loadFactor: loadFactor, metric: metric}
+}
+
+// Init :
+func (s *BoundedHash) Init(cfg data.Config) error {
+	if s.loadFactor < 0 {
+		return fmt.Errorf("invalid load factor %v", s.loadFactor)
+	}
+	if err := validLoadMetric(s.metric); err != nil {
+		return err
+	}
+	fmt.Printf("bounded-hash: load-factor:%v metric:%v\n", s.loadFactor, s.metric)
+	return s.SameHashingWeight.Init(cfg)
+}
+
+// VODSelect : bound 를 만족하는 VOD 가 없으면 SelectAvailableFirst 로 선택
+func (s *BoundedHash) VODSelect(evt *data.SessionEvent, lb LoadBalancer) (vod.Key, error) {
+	vodKeys := s.hash.GetItems(evt.FileName)
+	vods := lb.GetVODs()
+	var total, totalLimit int64
+	for _, v := range vods {
+		if v.Down {
+			continue
+		}
+		cur, limit := vodLoad(v, s.metric)
+		total += cur
+		totalLimit += limit
+	}
+	load := sessionLoad(evt, s.metric)
+	for _, k := range vodKeys {
+		v := vods[vod.Key(k)]
+		if v.Available(evt.Bps) != nil {
+			continue
+		}
+		cur, limit := vodLoad(v, s.metric)
+		bound := math.Ceil((1 + s.loadFactor) * float64(total+load) * float64(limit) / float64(totalLimit))
+		if float64(cur+load) <= bound {
+			return vod.Key(k), nil
+		}
+	}
+	return SelectAvailableFirst(evt, lb, vodKeys)
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// cache admission names
+const (
+	AdmissionTinyLFU   = "tinylfu"
+	AdmissionSecondHit = "second-hit"
+	AdmissionSize      = "size"
+)
+
+// sketch, bloom filter 크기 산정용 평균 chunk 크기
+const admissionObjectSize int64 = 2000000
+
+// Admission : cache miss 된 chunk 를 cache 에 기록할지 결정
+type Admission interface {
+	// Record : 모든 chunk 요청(hit/miss)마다 호출
+	Record(key lruKey)
+	// Admit : victim 은 chunk 를 기록하기 위해 삭제될 chunk, 삭제가 필요 없으면 nil
+	Admit(key lruKey, size int64, victim lruKey) bool
+}
+
+// NewAdmission : name 이 빈 문자열이면 nil (모든 chunk 기록)
+func NewAdmission(name string, limitSize, maxSize int64) (Admission, error) {
+	entries := int(limitSize / admissionObjectSize)
+	if entries < 1024 {
+		entries = 1024
+	}
+	switch name {
+	case "":
+		return nil, nil
+	case AdmissionTinyLFU:
+		return newTinyLfuAdmission(entries), nil
+	case AdmissionSecondHit:
+		return newSecondHitAdmission(entries), nil
+	case AdmissionSize:
+		if maxSize <= 0 {
+			return nil, fmt.Errorf("invalid max size(%d) of size admission", maxSize)
+		}
+		return &sizeAdmission{maxSize: maxSize}, nil
+	}
+	return nil, fmt.Errorf("invalid cache admission %v", name)
+}
+
+func keyHash(key lruKey) uint64 {
+	h := fnv.New64a()
+	switch k := key.(type) {
+	case int:
+		var b [8]byte
+		for i := 0; i < 8; i++ {
+			b[i] = byte(k >> (8 * uint(i)))
+		}
+		h.Write(b[:])
+	case ChunkKey:
+		var b [24]byte
+		for i := 0; i < 8; i++ {
+			b[i] = byte(k.File >> (8 * uint(i)))
+			b[8+i] = byte(k.Index >> (8 * uint(i)))
+			b[16+i] = byte(k.Rendition >> (8 * uint(i)))
+		}
+		h.Write(b[:])
+	case string:
+		h.Write([]byte(k))
+	default:
+		fmt.Fprint(h, k)
+	}
+	return h.Sum64()
+}
+
+// tinyLfuAdmission : count-min sketch 로 추정한 요청 빈도가 victim 보다 높을 때만 기록 (Einziger et al.)
+//
+// 기록된 요청 수가 sampleSize 에 도달하면 모든 counter 를 절반으로 줄여(reset) 최근 빈도를 반영
+type tinyLfuAdmission struct {
+	rows       [4][]uint8
+	mask       uint64
+	added      int
+	sampleSize int
+}
+
+const tinyLfuMaxCount = 15
+
+func newTinyLfuAdmission(entries int) *tinyLfuAdmission {
+	width := 1
+	for width < entries {
+		width <<= 1
+	}
+	a := &tinyLfuAdmission{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * entries,
+	}
+	for i := range a.rows {
+		a.rows[i] = make([]uint8, width)
+	}
+	return a
+}
+
+func (a *tinyLfuAdmission) index(h uint64, row int) uint64 {
+	// row 별 hash 는 double hashing 으로 생성
+	return (h + uint64(row)*((h>>32)|1)) & a.mask
+}
+
+func (a *tinyLfuAdmission) Record(key lruKey) {
+	h := keyHash(key)
+	for i := range a.rows {
+		idx := a.index(h, i)
+		if a.rows[i][idx] < tinyLfuMaxCount {
+			a.rows[i][idx]++
+		}
+	}
+	a.added++
+	if a.added >= a.sampleSize {
+		a.reset()
+	}
+}
+
+func (a *tinyLfuAdmission) estimate(key lruKey) uint8 {
+	h := keyHash(key)
+	est := uint8(tinyLfuMaxCount)
+	for i := range a.rows {
+		if v := a.rows[i][a.index(h, i)]; v < est {
+			est = v
+		}
+	}
+	return est
+}
+
+func (a *tinyLfuAdmission) reset() {
+	for i := range a.rows {
+		for j := range a.rows[i] {
+			a.rows[i][j] >>= 1
+		}
+	}
+	a.added /= 2
+}
+
+func (a *tinyLfuAdmission) Admit(key lruKey, size int64, victim lruKey) bool {
+	if victim == nil {
+		return true
+	}
+	return a.estimate(key) > a.estimate(victim)
+}
+
+// secondHitAdmission : bloom filter 에 기록된 적 있는(두번째 miss 인) chunk 만 기록
+//
+// bloom filter 에 추가된 수가 entries 에 도달하면 filter 를 비움
+type secondHitAdmission struct {
+	bits    []uint64
+	nbits   uint64
+	added   int
+	entries int
+}
+
+const secondHitHashN = 4
+
+func newSecondHitAdmission(entries int) *secondHitAdmission {
+	// false positive 약 2% (bit 수 = entries * 8)
+	nbits := uint64(entries * 8)
+	return &secondHitAdmission{
+		bits:    make([]uint64, (nbits+63)/64),
+		nbits:   nbits,
+		entries: entries,
+	}
+}
+
+func (a *secondHitAdmission) Record(key lruKey) {
+}
+
+func (a *secondHitAdmission) Admit(key lruKey, size int64, victim lruKey) bool {
+	h := keyHash(key)
+	exists := true
+	for i := uint64(0); i < secondHitHashN; i++ {
+		idx := (h + i*((h>>32)|1)) % a.nbits
+		if a.bits[idx/64]&(1<<(idx%64)) == 0 {
+			exists = false
+			a.bits[idx/64] |= 1 << (idx % 64)
+		}
+	}
+	if exists {
+		return true
+	}
+	a.added++
+	if a.added >= a.entries {
+		for i := range a.bits {
+			a.bits[i] = 0
+		}
+		a.added = 0
+	}
+	return false
+}
+
+// sizeAdmission : maxSize 이하의 chunk 만 기록
+type sizeAdmission struct {
+	maxSize int64
+}
+
+func (a *sizeAdmission) Record(key lruKey) {
+}
+
+func (a *sizeAdmission) Admit(key lruKey, size int64, victim lruKey) bool {
+	return size <= a.maxSize
+}
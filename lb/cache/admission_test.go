@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+func TestAdmission(t *testing.T) {
+	if a, err := NewAdmission("", 100, 0); a != nil || err != nil {
+		t.Errorf("empty admission must be nil, %v %v", a, err)
+	}
+	if _, err := NewAdmission(AdmissionSize, 100, 0); err == nil {
+		t.Errorf("size admission without max size must be failed")
+	}
+
+	second, _ := NewAdmission(AdmissionSecondHit, 100, 0)
+	if second.Admit(1, 10, nil) {
+		t.Errorf("first miss must be rejected (second-hit)")
+	}
+	if !second.Admit(1, 10, nil) {
+		t.Errorf("second miss must be admitted (second-hit)")
+	}
+
+	size, _ := NewAdmission(AdmissionSize, 100, 10)
+	if !size.Admit(1, 10, nil) || size.Admit(2, 11, nil) {
+		t.Errorf("only chunks (size <= 10) must be admitted (size)")
+	}
+
+	tiny, _ := NewAdmission(AdmissionTinyLFU, 100, 0)
+	for i := 0; i < 3; i++ {
+		tiny.Record(1)
+	}
+	tiny.Record(2)
+	if !tiny.Admit(2, 10, nil) {
+		t.Errorf("chunk must be admitted without victim (tinylfu)")
+	}
+	if tiny.Admit(2, 10, 1) {
+		t.Errorf("less frequent chunk must be rejected (tinylfu)")
+	}
+	if !tiny.Admit(1, 10, 2) {
+		t.Errorf("more frequent chunk must be admitted (tinylfu)")
+	}
+}
+
+func TestCache_Admission(t *testing.T) {
+	adm, _ := NewAdmission(AdmissionSecondHit, 100, 0)
+	c, err := NewCache(100, PolicyLRU, adm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt := &data.ChunkEvent{IntFileName: 1, ChunkSize: 10, Bps: 1}
+	for i := 0; i < 3; i++ {
+		useOrigin, err := c.StartChunk(evt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.EndChunk(evt, useOrigin)
+	}
+	if c.MissCount != 2 || c.HitCount != 1 {
+		t.Errorf("hit(%v) miss(%v)", c.HitCount, c.MissCount)
+	}
+	if c.RejectCount != 1 || c.AdmitCount != 1 {
+		t.Errorf("admit(%v) reject(%v)", c.AdmitCount, c.RejectCount)
+	}
+}
@@ -0,0 +1,121 @@
+package cache
+
+// arcPolicy : Adaptive Replacement Cache (Megiddo & Modha), 크기 단위를 chunk 개수 대신 byte 로 사용
+//
+// t1: 최근 1회 사용된 chunk, t2: 2회 이상 사용된 chunk, b1/b2: t1/t2 에서 삭제된 chunk 의 ghost
+type arcPolicy struct {
+	limitSize int64
+	p         int64 // t1 의 목표 크기
+	t1        *sizedList
+	t2        *sizedList
+	b1        *sizedList
+	b2        *sizedList
+}
+
+func newArcPolicy(limitSize int64) *arcPolicy {
+	return &arcPolicy{
+		limitSize: limitSize,
+		t1:        newSizedList(),
+		t2:        newSizedList(),
+		b1:        newSizedList(),
+		b2:        newSizedList(),
+	}
+}
+
+func (p *arcPolicy) Add(key lruKey, size int64) {
+	p.Remove(key)
+	if ele, ok := p.b1.get(key); ok {
+		delta := size
+		if p.b1.size > 0 && p.b2.size > p.b1.size {
+			delta = size * (p.b2.size / p.b1.size)
+		}
+		p.p = min64(p.limitSize, p.p+delta)
+		p.b1.remove(ele)
+		p.t2.pushFront(&policyEntry{key: key, size: size})
+	} else if ele, ok := p.b2.get(key); ok {
+		delta := size
+		if p.b2.size > 0 && p.b1.size > p.b2.size {
+			delta = size * (p.b1.size / p.b2.size)
+		}
+		p.p = max64(0, p.p-delta)
+		p.b2.remove(ele)
+		p.t2.pushFront(&policyEntry{key: key, size: size})
+	} else {
+		p.t1.pushFront(&policyEntry{key: key, size: size})
+	}
+
+	for p.b1.len() > 0 && p.t1.size+p.b1.size > p.limitSize {
+		p.b1.removeBack()
+	}
+	for p.b2.len() > 0 && p.t1.size+p.t2.size+p.b1.size+p.b2.size > 2*p.limitSize {
+		p.b2.removeBack()
+	}
+}
+
+func (p *arcPolicy) Get(key lruKey) (size int64, ok bool) {
+	if ele, ok := p.t1.get(key); ok {
+		e := p.t1.remove(ele)
+		p.t2.pushFront(e)
+		return e.size, true
+	}
+	if ele, ok := p.t2.get(key); ok {
+		p.t2.ll.MoveToFront(ele)
+		return ele.Value.(*policyEntry).size, true
+	}
+	return 0, false
+}
+
+func (p *arcPolicy) Remove(key lruKey) (size int64, ok bool) {
+	if ele, ok := p.t1.get(key); ok {
+		return p.t1.remove(ele).size, true
+	}
+	if ele, ok := p.t2.get(key); ok {
+		return p.t2.remove(ele).size, true
+	}
+	return 0, false
+}
+
+func (p *arcPolicy) Evict() (key lruKey, size int64, ok bool) {
+	var e *policyEntry
+	if p.replaceT1() {
+		e = p.t1.removeBack()
+		p.b1.pushFront(e)
+	} else if p.t2.len() > 0 {
+		e = p.t2.removeBack()
+		p.b2.pushFront(e)
+	} else {
+		return nil, 0, false
+	}
+	return e.key, e.size, true
+}
+
+func (p *arcPolicy) Victim() (key lruKey, ok bool) {
+	if p.replaceT1() {
+		return p.t1.back().key, true
+	} else if p.t2.len() > 0 {
+		return p.t2.back().key, true
+	}
+	return nil, false
+}
+
+func (p *arcPolicy) replaceT1() bool {
+	return p.t1.len() > 0 && (p.t1.size > p.p || p.t2.len() == 0)
+}
+
+func (p *arcPolicy) Len() int {
+	return p.t1.len() + p.t2.len()
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
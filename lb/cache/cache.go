@@ -8,34 +8,53 @@ import (
 
 // Cache :
 type Cache struct {
-	Lru         *Lru
+	Policy      Policy
+	Admission   Admission
 	LimitSize   int64
 	CurSize     int64
 	HitCount    int64
 	MissCount   int64
+	AdmitCount  int64
+	RejectCount int64
 	OriginBps   int64
 	IsCacheFull bool
 }
 
-// NewCache :
-func NewCache(limitSize int64) (*Cache, error) {
+// NewCache : policy 가 빈 문자열이면 LRU 사용, admission 이 nil 이면 miss 된 모든 chunk 기록
+// oracle 은 OPT policy 에서만 사용
+func NewCache(limitSize int64, policy string, admission Admission, oracle Oracle) (*Cache, error) {
 	m := &Cache{
 		LimitSize: limitSize,
+		Admission: admission,
 	}
 
-	if err := m.init(); err != nil {
+	if err := m.init(policy, oracle); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-func filepath(evt *data.ChunkEvent) int {
-	return evt.IntFileName*10000 + int(evt.Index)
+// ChunkKey : cache 에 기록되는 chunk
+type ChunkKey struct {
+	File      int
+	Index     int64
+	Rendition int
+}
+
+// NewChunkKey :
+func NewChunkKey(evt *data.ChunkEvent) ChunkKey {
+	return ChunkKey{File: evt.IntFileName, Index: evt.Index, Rendition: evt.Rendition}
 }
 
 // StartChunk :
 func (c *Cache) StartChunk(evt *data.ChunkEvent) (useOrigin bool, err error) {
-	key := filepath(evt)
+	key := NewChunkKey(evt)
+	if p, ok := c.Policy.(*optPolicy); ok {
+		p.observe(key, evt)
+	}
+	if c.Admission != nil {
+		c.Admission.Record(key)
+	}
 	n, ok := c.Get(key)
 	if ok {
 		if n != evt.ChunkSize {
@@ -43,7 +62,7 @@ func (c *Cache) StartChunk(evt *data.ChunkEvent) (useOrigin bool, err error) {
 		}
 		c.HitCount++
 	} else {
-		if evt.Bypass == false {
+		if evt.Bypass == false && c.admit(key, evt.ChunkSize) {
 			err = c.Add(key, evt.ChunkSize)
 			if err != nil {
 				return false, err
@@ -64,21 +83,33 @@ func (c *Cache) EndChunk(evt *data.ChunkEvent, useOrigin bool) error {
 	return nil
 }
 
-func (c *Cache) init() error {
-	c.Lru = &Lru{
-		OnEvicted: func(key lruKey, value interface{}) {
-			c.IsCacheFull = true
-			v := value.(int64)
-			c.CurSize -= v
-		},
+func (c *Cache) admit(key lruKey, size int64) bool {
+	if c.Admission == nil {
+		return true
+	}
+	var victim lruKey
+	if c.CurSize+size > c.LimitSize {
+		victim, _ = c.Policy.Victim()
+	}
+	if c.Admission.Admit(key, size, victim) {
+		c.AdmitCount++
+		return true
 	}
+	c.RejectCount++
+	return false
+}
 
+func (c *Cache) init(policy string, oracle Oracle) error {
+	p, err := NewPolicy(policy, c.LimitSize, oracle)
+	if err != nil {
+		return err
+	}
+	c.Policy = p
 	return nil
 }
 
 // Add :
-func (c *Cache) Add(relFilePath int, size int64) error {
-	key := relFilePath
+func (c *Cache) Add(key ChunkKey, size int64) error {
 
 	if c.LimitSize <= 0 || c.LimitSize < size {
 		return fmt.Errorf("data size(%d) > cache limit size(%d)", size, c.LimitSize)
@@ -88,26 +119,56 @@ func (c *Cache) Add(relFilePath int, size int64) error {
 		if c.CurSize+size <= c.LimitSize {
 			break
 		}
-		c.Lru.RemoveOldest()
+		_, n, ok := c.Policy.Evict()
+		if !ok {
+			return fmt.Errorf("failed to evict, cur size(%d) limit size(%d)", c.CurSize, c.LimitSize)
+		}
+		c.IsCacheFull = true
+		c.CurSize -= n
 	}
-	c.Lru.Add(key, size)
+	c.Policy.Add(key, size)
 	c.CurSize += size
 	return nil
 }
 
 // Get :
-func (c *Cache) Get(filepath int) (size int64, ok bool) {
-	if c.Lru == nil {
+func (c *Cache) Get(key ChunkKey) (size int64, ok bool) {
+	if c.Policy == nil {
 		return
 	}
-	v, ok := c.Lru.Get(filepath)
-	if !ok {
-		return
+	return c.Policy.Get(key)
+}
+
+// Clear : cache 된 모든 chunk 삭제
+func (c *Cache) Clear() {
+	for {
+		_, n, ok := c.Policy.Evict()
+		if !ok {
+			break
+		}
+		c.CurSize -= n
+	}
+	c.IsCacheFull = false
+}
+
+// Resize : limit 이 현재 크기보다 작으면 limit 이하가 될 때까지 evict
+func (c *Cache) Resize(limitSize int64) {
+	c.LimitSize = limitSize
+	for c.CurSize > c.LimitSize {
+		_, n, ok := c.Policy.Evict()
+		if !ok {
+			break
+		}
+		c.IsCacheFull = true
+		c.CurSize -= n
 	}
-	return v.(int64), true
 }
 
 // Remove :
-func (c *Cache) Remove(filepath string) bool {
-	return c.Lru.Remove(filepath)
+func (c *Cache) Remove(key ChunkKey) bool {
+	n, ok := c.Policy.Remove(key)
+	if ok {
+		c.CurSize -= n
+	}
+	return ok
 }
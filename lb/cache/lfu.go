@@ -0,0 +1,107 @@
+package cache
+
+// lfuPolicy : 사용 빈도가 가장 낮은 chunk 를 삭제, 빈도가 같으면 가장 오래 사용되지 않은 chunk 삭제
+type lfuPolicy struct {
+	freqs   map[int]*sizedList // freq 별 LRU list
+	keys    map[lruKey]int     // key-freq
+	minFreq int
+}
+
+func newLfuPolicy() *lfuPolicy {
+	return &lfuPolicy{
+		freqs: make(map[int]*sizedList),
+		keys:  make(map[lruKey]int),
+	}
+}
+
+func (p *lfuPolicy) list(freq int) *sizedList {
+	l, ok := p.freqs[freq]
+	if !ok {
+		l = newSizedList()
+		p.freqs[freq] = l
+	}
+	return l
+}
+
+func (p *lfuPolicy) Add(key lruKey, size int64) {
+	if _, ok := p.keys[key]; ok {
+		p.Remove(key)
+	}
+	p.list(1).pushFront(&policyEntry{key: key, size: size, freq: 1})
+	p.keys[key] = 1
+	p.minFreq = 1
+}
+
+func (p *lfuPolicy) Get(key lruKey) (size int64, ok bool) {
+	freq, ok := p.keys[key]
+	if !ok {
+		return 0, false
+	}
+	l := p.freqs[freq]
+	ele, _ := l.get(key)
+	e := l.remove(ele)
+	if l.len() == 0 {
+		delete(p.freqs, freq)
+		if p.minFreq == freq {
+			p.minFreq = freq + 1
+		}
+	}
+	e.freq++
+	p.list(e.freq).pushFront(e)
+	p.keys[key] = e.freq
+	return e.size, true
+}
+
+func (p *lfuPolicy) Remove(key lruKey) (size int64, ok bool) {
+	freq, ok := p.keys[key]
+	if !ok {
+		return 0, false
+	}
+	l := p.freqs[freq]
+	ele, _ := l.get(key)
+	e := l.remove(ele)
+	if l.len() == 0 {
+		delete(p.freqs, freq)
+	}
+	delete(p.keys, key)
+	return e.size, true
+}
+
+func (p *lfuPolicy) Evict() (key lruKey, size int64, ok bool) {
+	if len(p.keys) == 0 {
+		return nil, 0, false
+	}
+	l := p.minFreqList()
+	e := l.removeBack()
+	if l.len() == 0 {
+		delete(p.freqs, p.minFreq)
+	}
+	delete(p.keys, e.key)
+	return e.key, e.size, true
+}
+
+func (p *lfuPolicy) Victim() (key lruKey, ok bool) {
+	if len(p.keys) == 0 {
+		return nil, false
+	}
+	return p.minFreqList().back().key, true
+}
+
+func (p *lfuPolicy) minFreqList() *sizedList {
+	l, ok := p.freqs[p.minFreq]
+	if !ok {
+		// Remove 로 minFreq list 가 비워진 경우
+		p.minFreq = 0
+		for f := range p.freqs {
+			if p.minFreq == 0 || f < p.minFreq {
+				p.minFreq = f
+			}
+		}
+		l = p.freqs[p.minFreq]
+	}
+	return l
+}
+
+func (p *lfuPolicy) Len() int {
+	return len(p.keys)
+}
@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+// OPT 의 size-aware 삭제 시 비교하는 sample 수
+const optSampleN = 64
+
+// Oracle : trace 를 미리 읽어 chunk 의 다음 요청 시각을 알려줌 (OPT policy 용)
+type Oracle interface {
+	// NextRef : t 이후 fileName 의 index 번째 chunk 가 다시 요청되는 시각, 없으면 zero time
+	NextRef(fileName string, index int64, t time.Time) time.Time
+}
+
+type optEntry struct {
+	key      lruKey
+	fileName string
+	index    int64
+	size     int64
+	next     int64 // 다음 요청 시각 (UnixNano), 다시 요청되지 않으면 math.MaxInt64
+	farIdx   int
+	nearIdx  int
+}
+
+// optFarHeap : 다음 요청이 가장 늦은 entry 가 root
+type optFarHeap []*optEntry
+
+func (h optFarHeap) Len() int           { return len(h) }
+func (h optFarHeap) Less(i, j int) bool { return h[i].next > h[j].next }
+func (h optFarHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].farIdx = i
+	h[j].farIdx = j
+}
+
+func (h *optFarHeap) Push(x interface{}) {
+	e := x.(*optEntry)
+	e.farIdx = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *optFarHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// optNearHeap : 다음 요청이 가장 빠른 entry 가 root
+type optNearHeap []*optEntry
+
+func (h optNearHeap) Len() int           { return len(h) }
+func (h optNearHeap) Less(i, j int) bool { return h[i].next < h[j].next }
+func (h optNearHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].nearIdx = i
+	h[j].nearIdx = j
+}
+
+func (h *optNearHeap) Push(x interface{}) {
+	e := x.(*optEntry)
+	e.nearIdx = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *optNearHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// optPolicy : Belady OPT, 다음 요청이 가장 늦은 chunk 를 삭제
+//
+// sizeAware 이면 sample 중 (다음 요청까지 남은 시간 * 크기)가 가장 큰 chunk 를 삭제한다.
+// chunk 가 어느 VOD 로 요청될지는 미리 알 수 없으므로 전체 trace 기준의 다음 요청 시각을 사용하며,
+// 다른 VOD 로 요청되어 다음 요청 시각이 지나버린 chunk 는 그 다음 요청 시각으로 갱신한다.
+type optPolicy struct {
+	oracle      Oracle
+	sizeAware   bool
+	entries     map[lruKey]*optEntry
+	far         optFarHeap
+	near        optNearHeap
+	now         int64
+	pending     *data.ChunkEvent
+	pendingKey  lruKey
+	pendingNext int64
+	rnd         *rand.Rand
+	sampled     *optEntry // Victim 이 고른 chunk, 다음 요청 전까지 Evict 에서 삭제
+}
+
+func newOptPolicy(oracle Oracle, sizeAware bool) *optPolicy {
+	return &optPolicy{
+		oracle:    oracle,
+		sizeAware: sizeAware,
+		entries:   make(map[lruKey]*optEntry),
+		rnd:       rand.New(rand.NewSource(1)),
+	}
+}
+
+func (p *optPolicy) nextRef(fileName string, index int64, t time.Time) int64 {
+	if next := p.oracle.NextRef(fileName, index, t); !next.IsZero() {
+		return next.UnixNano()
+	}
+	return math.MaxInt64
+}
+
+// observe : Get/Add 전에 현재 요청된 chunk 의 다음 요청 시각을 기록
+func (p *optPolicy) observe(key lruKey, evt *data.ChunkEvent) {
+	p.now = evt.Time.UnixNano()
+	p.sampled = nil
+	p.pending = evt
+	p.pendingKey = key
+	p.pendingNext = p.nextRef(evt.FileName, evt.Index, evt.Time)
+
+	for len(p.near) > 0 && p.near[0].next <= p.now && p.near[0].key != key {
+		e := p.near[0]
+		e.next = p.nextRef(e.fileName, e.index, evt.Time)
+		heap.Fix(&p.near, 0)
+		heap.Fix(&p.far, e.farIdx)
+	}
+}
+
+func (p *optPolicy) Add(key lruKey, size int64) {
+	p.Remove(key)
+	e := &optEntry{key: key, size: size, next: math.MaxInt64}
+	if key == p.pendingKey && p.pending != nil {
+		e.fileName = p.pending.FileName
+		e.index = p.pending.Index
+		e.next = p.pendingNext
+	}
+	heap.Push(&p.far, e)
+	heap.Push(&p.near, e)
+	p.entries[key] = e
+}
+
+func (p *optPolicy) Get(key lruKey) (size int64, ok bool) {
+	e, ok := p.entries[key]
+	if !ok {
+		return 0, false
+	}
+	if key == p.pendingKey {
+		e.next = p.pendingNext
+		heap.Fix(&p.far, e.farIdx)
+		heap.Fix(&p.near, e.nearIdx)
+	}
+	return e.size, true
+}
+
+func (p *optPolicy) Remove(key lruKey) (size int64, ok bool) {
+	e, ok := p.entries[key]
+	if !ok {
+		return 0, false
+	}
+	p.remove(e)
+	return e.size, true
+}
+
+func (p *optPolicy) remove(e *optEntry) {
+	heap.Remove(&p.far, e.farIdx)
+	heap.Remove(&p.near, e.nearIdx)
+	delete(p.entries, e.key)
+	if p.sampled == e {
+		p.sampled = nil
+	}
+}
+
+func (p *optPolicy) victim() *optEntry {
+	if len(p.far) == 0 {
+		return nil
+	}
+	if !p.sizeAware {
+		return p.far[0]
+	}
+	cost := func(e *optEntry) float64 {
+		return float64(e.next-p.now) * float64(e.size)
+	}
+	// 다음 요청이 가장 늦은 chunk(root) 는 항상 후보에 포함
+	v := p.far[0]
+	if len(p.far) <= optSampleN {
+		for _, e := range p.far {
+			if cost(e) > cost(v) {
+				v = e
+			}
+		}
+		return v
+	}
+	for i := 0; i < optSampleN; i++ {
+		e := p.far[p.rnd.Intn(len(p.far))]
+		if cost(e) > cost(v) {
+			v = e
+		}
+	}
+	return v
+}
+
+func (p *optPolicy) Evict() (key lruKey, size int64, ok bool) {
+	e := p.sampled
+	if e == nil {
+		e = p.victim()
+	}
+	if e == nil {
+		return nil, 0, false
+	}
+	p.remove(e)
+	return e.key, e.size, true
+}
+
+// Victim : size-aware 이면 sample 로 고른 chunk 를 기억해 Evict 에서 같은 chunk 를 삭제
+func (p *optPolicy) Victim() (key lruKey, ok bool) {
+	e := p.victim()
+	if e == nil {
+		return nil, false
+	}
+	if p.sizeAware {
+		p.sampled = e
+	}
+	return e.key, true
+}
+
+func (p *optPolicy) Len() int {
+	return len(p.far)
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// cache policy names
+const (
+	PolicyLRU     = "lru"
+	PolicyLFU     = "lfu"
+	PolicyARC     = "arc"
+	Policy2Q      = "2q"
+	PolicyS3FIFO  = "s3fifo"
+	PolicyOPT     = "opt"
+	PolicyOPTSize = "opt-size"
+)
+
+// Policy : cache 교체(eviction) 정책
+//
+// Add 는 cache miss 인 key 에 대해서만 호출되며, 공간 확보는 Cache 가 Evict 를 반복 호출하여 처리한다.
+type Policy interface {
+	Add(key lruKey, size int64)
+	Get(key lruKey) (size int64, ok bool)
+	Remove(key lruKey) (size int64, ok bool)
+	Evict() (key lruKey, size int64, ok bool)
+	Victim() (key lruKey, ok bool)
+	Len() int
+}
+
+// NewPolicy : oracle 은 OPT policy 에서만 사용
+func NewPolicy(name string, limitSize int64, oracle Oracle) (Policy, error) {
+	switch name {
+	case "", PolicyLRU:
+		return newLruPolicy(), nil
+	case PolicyLFU:
+		return newLfuPolicy(), nil
+	case PolicyARC:
+		return newArcPolicy(limitSize), nil
+	case Policy2Q:
+		return newTwoQPolicy(limitSize), nil
+	case PolicyS3FIFO:
+		return newS3FifoPolicy(limitSize), nil
+	case PolicyOPT, PolicyOPTSize:
+		if oracle == nil {
+			return nil, fmt.Errorf("%v cache policy needs oracle", name)
+		}
+		return newOptPolicy(oracle, name == PolicyOPTSize), nil
+	}
+	return nil, fmt.Errorf("invalid cache policy %v", name)
+}
+
+// lruPolicy : Lru 를 Policy 로 사용
+type lruPolicy struct {
+	lru *Lru
+}
+
+func newLruPolicy() *lruPolicy {
+	return &lruPolicy{lru: NewLru(0)}
+}
+
+func (p *lruPolicy) Add(key lruKey, size int64) {
+	p.lru.Add(key, size)
+}
+
+func (p *lruPolicy) Get(key lruKey) (size int64, ok bool) {
+	v, ok := p.lru.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return v.(int64), true
+}
+
+func (p *lruPolicy) Remove(key lruKey) (size int64, ok bool) {
+	ele, ok := p.lru.cache[key]
+	if !ok {
+		return 0, false
+	}
+	size = ele.Value.(*lruEntry).value.(int64)
+	p.lru.removeElement(ele)
+	return size, true
+}
+
+func (p *lruPolicy) Evict() (key lruKey, size int64, ok bool) {
+	ele := p.lru.ll.Back()
+	if ele == nil {
+		return nil, 0, false
+	}
+	kv := ele.Value.(*lruEntry)
+	p.lru.removeElement(ele)
+	return kv.key, kv.value.(int64), true
+}
+
+func (p *lruPolicy) Victim() (key lruKey, ok bool) {
+	ele := p.lru.ll.Back()
+	if ele == nil {
+		return nil, false
+	}
+	return ele.Value.(*lruEntry).key, true
+}
+
+func (p *lruPolicy) Len() int {
+	return p.lru.Len()
+}
+
+// policyEntry : LFU/ARC/2Q/S3-FIFO 의 list element 값
+type policyEntry struct {
+	key  lruKey
+	size int64
+	freq int
+}
+
+// sizedList : 포함된 entry 들의 크기 합을 관리하는 list
+type sizedList struct {
+	ll    *list.List
+	items map[lruKey]*list.Element
+	size  int64
+}
+
+func newSizedList() *sizedList {
+	return &sizedList{ll: list.New(), items: make(map[lruKey]*list.Element)}
+}
+
+func (l *sizedList) pushFront(e *policyEntry) {
+	l.items[e.key] = l.ll.PushFront(e)
+	l.size += e.size
+}
+
+func (l *sizedList) get(key lruKey) (*list.Element, bool) {
+	ele, ok := l.items[key]
+	return ele, ok
+}
+
+func (l *sizedList) remove(ele *list.Element) *policyEntry {
+	e := l.ll.Remove(ele).(*policyEntry)
+	delete(l.items, e.key)
+	l.size -= e.size
+	return e
+}
+
+func (l *sizedList) removeBack() *policyEntry {
+	ele := l.ll.Back()
+	if ele == nil {
+		return nil
+	}
+	return l.remove(ele)
+}
+
+func (l *sizedList) back() *policyEntry {
+	ele := l.ll.Back()
+	if ele == nil {
+		return nil
+	}
+	return ele.Value.(*policyEntry)
+}
+
+func (l *sizedList) len() int {
+	return l.ll.Len()
+}
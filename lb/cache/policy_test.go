@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+func TestNewPolicy(t *testing.T) {
+	for _, name := range []string{"", PolicyLRU, PolicyLFU, PolicyARC, Policy2Q, PolicyS3FIFO} {
+		if _, err := NewPolicy(name, 100, nil); err != nil {
+			t.Errorf("[%v] %v", name, err)
+		}
+	}
+	if _, err := NewPolicy("mru", 100, nil); err == nil {
+		t.Errorf("invalid policy must be failed")
+	}
+}
+
+func TestCache_Policies(t *testing.T) {
+	chunk := func(file int) *data.ChunkEvent {
+		return &data.ChunkEvent{IntFileName: file, ChunkSize: 10, Bps: 1}
+	}
+	// 1 을 여러번 사용한 뒤 다른 chunk 들로 cache 를 채웠을 때 1 이 남아있는지 확인
+	tests := []struct {
+		policy   string
+		expected bool
+	}{
+		{PolicyLRU, false},
+		{PolicyLFU, true},
+		{PolicyARC, true},
+		{Policy2Q, false}, // a1in 에 있는 동안의 재사용은 am 으로 옮겨지지 않음
+		{PolicyS3FIFO, true},
+	}
+	for _, tt := range tests {
+		c, err := NewCache(40, tt.policy, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := c.StartChunk(chunk(1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for f := 2; f < 10; f++ {
+			if _, err := c.StartChunk(chunk(f)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if c.CurSize > c.LimitSize {
+			t.Errorf("[%v] cur size(%v) > limit size(%v)", tt.policy, c.CurSize, c.LimitSize)
+		}
+		if int64(c.Policy.Len())*10 != c.CurSize {
+			t.Errorf("[%v] %v != %v", tt.policy, int64(c.Policy.Len())*10, c.CurSize)
+		}
+		if _, ok := c.Get(NewChunkKey(chunk(1))); ok != tt.expected {
+			t.Errorf("[%v] %v != %v", tt.policy, tt.expected, ok)
+		}
+		if c.HitCount != 2 || c.MissCount != 9 {
+			t.Errorf("[%v] hit(%v) miss(%v)", tt.policy, c.HitCount, c.MissCount)
+		}
+	}
+}
+
+func TestChunkKey(t *testing.T) {
+	c, err := NewCache(100, PolicyLRU, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// file 당 chunk 가 10000 개를 넘어도 다른 file 의 chunk 와 겹치지 않음
+	a := &data.ChunkEvent{IntFileName: 1, Index: 10000, ChunkSize: 10}
+	b := &data.ChunkEvent{IntFileName: 2, Index: 0, ChunkSize: 10}
+	if _, err := c.StartChunk(a); err != nil {
+		t.Fatal(err)
+	}
+	if useOrigin, err := c.StartChunk(b); err != nil || !useOrigin {
+		t.Errorf("chunk of other file must be missed, %v %v", useOrigin, err)
+	}
+	if _, ok := c.Get(ChunkKey{File: 1, Index: 10000}); !ok {
+		t.Errorf("not exists chunk")
+	}
+	// 같은 구간이라도 rendition 이 다르면 다른 chunk
+	r := &data.ChunkEvent{IntFileName: 1, Index: 10000, ChunkSize: 5, Rendition: 1}
+	if useOrigin, err := c.StartChunk(r); err != nil || !useOrigin {
+		t.Errorf("chunk of other rendition must be missed, %v %v", useOrigin, err)
+	}
+}
+
+func TestOptPolicy_Victim(t *testing.T) {
+	// 다시 요청되지 않는 chunk 들 중 크기가 큰 chunk 가 삭제 대상
+	p := newOptPolicy(nil, true)
+	for i := 0; i < 3; i++ {
+		p.Add(i, int64(10+i))
+	}
+	if victim, _ := p.Victim(); victim != 2 {
+		t.Errorf("largest chunk must be victim, %v", victim)
+	}
+
+	// sample 로 고른 victim 과 삭제되는 chunk 가 같아야 함
+	p = newOptPolicy(nil, true)
+	for i := 0; i < optSampleN*2; i++ {
+		p.Add(i, int64(10+i%7))
+	}
+	for i := 0; i < 10; i++ {
+		victim, ok := p.Victim()
+		if !ok {
+			t.Fatal("victim must be found")
+		}
+		if key, _, _ := p.Evict(); key != victim {
+			t.Errorf("evicted %v != victim %v", key, victim)
+		}
+	}
+}
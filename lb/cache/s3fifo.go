@@ -0,0 +1,132 @@
+package cache
+
+const s3FifoMaxFreq = 3
+
+// s3FifoPolicy : S3-FIFO (Yang et al., SOSP'23)
+//
+// small: 처음 사용된 chunk 의 FIFO (전체의 10%), main: 재사용된 chunk 의 FIFO, ghost: small 에서 삭제된 chunk
+type s3FifoPolicy struct {
+	smallLimit int64
+	ghostLimit int64
+	small      *sizedList
+	main       *sizedList
+	ghost      *sizedList
+}
+
+func newS3FifoPolicy(limitSize int64) *s3FifoPolicy {
+	return &s3FifoPolicy{
+		smallLimit: limitSize / 10,
+		ghostLimit: limitSize - limitSize/10,
+		small:      newSizedList(),
+		main:       newSizedList(),
+		ghost:      newSizedList(),
+	}
+}
+
+func (p *s3FifoPolicy) Add(key lruKey, size int64) {
+	p.Remove(key)
+	if ele, ok := p.ghost.get(key); ok {
+		p.ghost.remove(ele)
+		p.main.pushFront(&policyEntry{key: key, size: size})
+		return
+	}
+	p.small.pushFront(&policyEntry{key: key, size: size})
+}
+
+func (p *s3FifoPolicy) Get(key lruKey) (size int64, ok bool) {
+	ele, ok := p.small.get(key)
+	if !ok {
+		ele, ok = p.main.get(key)
+	}
+	if !ok {
+		return 0, false
+	}
+	e := ele.Value.(*policyEntry)
+	if e.freq < s3FifoMaxFreq {
+		e.freq++
+	}
+	return e.size, true
+}
+
+func (p *s3FifoPolicy) Remove(key lruKey) (size int64, ok bool) {
+	if ele, ok := p.small.get(key); ok {
+		return p.small.remove(ele).size, true
+	}
+	if ele, ok := p.main.get(key); ok {
+		return p.main.remove(ele).size, true
+	}
+	return 0, false
+}
+
+func (p *s3FifoPolicy) Evict() (key lruKey, size int64, ok bool) {
+	var e *policyEntry
+	if p.small.len() > 0 && (p.small.size >= p.smallLimit || p.main.len() == 0) {
+		e = p.evictSmall()
+	}
+	if e == nil {
+		e = p.evictMain()
+	}
+	if e == nil {
+		return nil, 0, false
+	}
+	return e.key, e.size, true
+}
+
+// evictSmall : small 에서 재사용된 chunk 는 main 으로 옮기고, 그렇지 않은 chunk 를 삭제 후 ghost 에 기록
+func (p *s3FifoPolicy) evictSmall() *policyEntry {
+	for p.small.len() > 0 {
+		e := p.small.removeBack()
+		if e.freq > 1 {
+			e.freq = 0
+			p.main.pushFront(e)
+			continue
+		}
+		p.ghost.pushFront(&policyEntry{key: e.key, size: e.size})
+		for p.ghost.len() > 0 && p.ghost.size > p.ghostLimit {
+			p.ghost.removeBack()
+		}
+		return e
+	}
+	return nil
+}
+
+// evictMain : main 에서 사용된 적이 있는 chunk 는 freq 를 줄여 다시 넣고(reinsertion), 그렇지 않은 chunk 삭제
+func (p *s3FifoPolicy) evictMain() *policyEntry {
+	for p.main.len() > 0 {
+		e := p.main.removeBack()
+		if e.freq > 0 {
+			e.freq--
+			p.main.pushFront(e)
+			continue
+		}
+		return e
+	}
+	return nil
+}
+
+// Victim : Evict 시 main 으로 옮겨지거나 reinsertion 되는 chunk 를 건너뛰어 삭제될 chunk 를 추정
+func (p *s3FifoPolicy) Victim() (key lruKey, ok bool) {
+	if p.small.len() > 0 && (p.small.size >= p.smallLimit || p.main.len() == 0) {
+		for ele := p.small.ll.Back(); ele != nil; ele = ele.Prev() {
+			if e := ele.Value.(*policyEntry); e.freq <= 1 {
+				return e.key, true
+			}
+		}
+	}
+	for ele := p.main.ll.Back(); ele != nil; ele = ele.Prev() {
+		if e := ele.Value.(*policyEntry); e.freq == 0 {
+			return e.key, true
+		}
+	}
+	if e := p.main.back(); e != nil {
+		return e.key, true
+	}
+	if e := p.small.back(); e != nil {
+		return e.key, true
+	}
+	return nil, false
+}
+
+func (p *s3FifoPolicy) Len() int {
+	return p.small.len() + p.main.len()
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Contents : cache 된 chunk 목록 (ghost list 제외), 먼저 evict 될 chunk 부터
+// Warm 에 순서대로 넣으면 나중에 넣은 chunk 가 더 오래 남음
+func (c *Cache) Contents() ([]StateEntry, error) {
+	var ls []*sizedList
+	switch v := c.Policy.(type) {
+	case *lruPolicy:
+		var ret []StateEntry
+		for ele := v.lru.ll.Back(); ele != nil; ele = ele.Prev() {
+			e := ele.Value.(*lruEntry)
+			k, err := stateKey(e.key)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, StateEntry{Key: k, Size: e.value.(int64)})
+		}
+		return ret, nil
+	case *lfuPolicy:
+		freqs := make([]int, 0, len(v.freqs))
+		for f := range v.freqs {
+			freqs = append(freqs, f)
+		}
+		sort.Ints(freqs)
+		for _, f := range freqs {
+			ls = append(ls, v.freqs[f])
+		}
+	case *arcPolicy:
+		ls = []*sizedList{v.t1, v.t2}
+	case *twoQPolicy:
+		ls = []*sizedList{v.a1in, v.am}
+	case *s3FifoPolicy:
+		ls = []*sizedList{v.small, v.main}
+	case *optPolicy:
+		entries := make([]*optEntry, 0, len(v.entries))
+		for _, e := range v.entries {
+			entries = append(entries, e)
+		}
+		// 다음 요청이 먼 chunk 부터, 같으면 key 순서로 결과를 고정
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].next != entries[j].next {
+				return entries[i].next > entries[j].next
+			}
+			return chunkKeyLess(entries[i].key, entries[j].key)
+		})
+		ret := make([]StateEntry, 0, len(entries))
+		for _, e := range entries {
+			k, err := stateKey(e.key)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, StateEntry{Key: k, Size: e.size})
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("cache policy %T does not support snapshot", c.Policy)
+	}
+
+	var ret []StateEntry
+	for _, l := range ls {
+		for ele := l.ll.Back(); ele != nil; ele = ele.Prev() {
+			e := ele.Value.(*policyEntry)
+			k, err := stateKey(e.key)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, StateEntry{Key: k, Size: e.size, Freq: e.freq})
+		}
+	}
+	return ret, nil
+}
+
+// Warm : entries 를 순서대로 cache 에 추가, hit/miss, admission 에는 반영하지 않음
+// 이미 있는 chunk, cache 보다 큰 chunk 는 건너뛰며 추가한 chunk 수를 반환
+// opt policy 는 추가한 chunk 의 다음 요청 시각을 알 수 없으므로 지원하지 않음
+func (c *Cache) Warm(entries []StateEntry) (int, error) {
+	if _, ok := c.Policy.(*optPolicy); ok {
+		return 0, fmt.Errorf("cache policy %T does not support warm start", c.Policy)
+	}
+	n := 0
+	for _, e := range entries {
+		if e.Size > c.LimitSize {
+			continue
+		}
+		if _, ok := c.Get(e.Key); ok {
+			continue
+		}
+		if err := c.Add(e.Key, e.Size); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ResetStats : hit/miss, admission 누적 값 초기화, 저장된 chunk 와 origin bps 는 유지
+func (c *Cache) ResetStats() {
+	c.HitCount, c.MissCount = 0, 0
+	c.AdmitCount, c.RejectCount = 0, 0
+}
+
+func chunkKeyLess(a, b lruKey) bool {
+	ka, _ := a.(ChunkKey)
+	kb, _ := b.(ChunkKey)
+	if ka.File != kb.File {
+		return ka.File < kb.File
+	}
+	if ka.Index != kb.Index {
+		return ka.Index < kb.Index
+	}
+	return ka.Rendition < kb.Rendition
+}
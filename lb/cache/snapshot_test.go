@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+func TestCache_Warm(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	src, err := NewCache(200, PolicyLRU, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		src.StartChunk(&data.ChunkEvent{IntFileName: r.Intn(30), Index: int64(r.Intn(3)), ChunkSize: 10, Bps: 1})
+	}
+	contents, err := src.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 20 {
+		t.Fatalf("invalid contents count %v", len(contents))
+	}
+
+	for _, policy := range []string{PolicyLRU, PolicyLFU, PolicyARC, Policy2Q, PolicyS3FIFO} {
+		c, err := NewCache(200, policy, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := c.Warm(contents)
+		if err != nil {
+			t.Fatalf("[%v] %v", policy, err)
+		}
+		if n != len(contents) || c.CurSize != 200 || c.HitCount != 0 || c.MissCount != 0 {
+			t.Errorf("[%v] invalid warm result %v chunks, size(%v) hit(%v) miss(%v)", policy, n, c.CurSize, c.HitCount, c.MissCount)
+		}
+		got, err := c.Contents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy == PolicyLRU && !reflect.DeepEqual(got, contents) {
+			t.Errorf("warm must keep eviction order %v %v", got, contents)
+		}
+	}
+
+	// 작은 cache 에는 나중에 evict 될 chunk 가 남음
+	c, _ := NewCache(50, PolicyLRU, nil, nil)
+	c.Warm(contents)
+	got, _ := c.Contents()
+	if !reflect.DeepEqual(got, contents[len(contents)-5:]) {
+		t.Errorf("small cache must keep last chunks %v", got)
+	}
+
+	opt := &Cache{LimitSize: 100, Policy: newOptPolicy(nil, false)}
+	if _, err := opt.Warm(contents); err == nil {
+		t.Errorf("opt policy must not support warm start")
+	}
+}
@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CacheState : checkpoint 에 기록하는 Cache 상태
+type CacheState struct {
+	LimitSize   int64
+	CurSize     int64
+	HitCount    int64
+	MissCount   int64
+	AdmitCount  int64
+	RejectCount int64
+	OriginBps   int64
+	IsCacheFull bool
+	Policy      PolicyState
+	Admission   AdmissionState
+}
+
+// StateEntry : policy list 의 entry
+type StateEntry struct {
+	Key  ChunkKey
+	Size int64
+	Freq int
+}
+
+// PolicyState : policy 의 list 들(각 list 는 front 부터)과 policy 별 parameter
+//
+// lfu: freq 순 list, [minFreq], arc: [t1 t2 b1 b2], [limitSize p], 2q: [a1in a1out am], [kin kout],
+// s3fifo: [small main ghost], [smallLimit ghostLimit]
+type PolicyState struct {
+	Lists  [][]StateEntry
+	Params []int64
+}
+
+// AdmissionState : tinylfu 는 Rows, Mask, SampleSize, second-hit 은 Bits, NBits, Entries 사용
+type AdmissionState struct {
+	Rows       [4][]uint8
+	Mask       uint64
+	SampleSize int
+	Bits       []uint64
+	NBits      uint64
+	Entries    int
+	Added      int
+}
+
+// State : opt policy 는 oracle 상태를 기록할 수 없으므로 지원하지 않음
+func (c *Cache) State() (*CacheState, error) {
+	ps, err := policyState(c.Policy)
+	if err != nil {
+		return nil, err
+	}
+	return &CacheState{
+		LimitSize:   c.LimitSize,
+		CurSize:     c.CurSize,
+		HitCount:    c.HitCount,
+		MissCount:   c.MissCount,
+		AdmitCount:  c.AdmitCount,
+		RejectCount: c.RejectCount,
+		OriginBps:   c.OriginBps,
+		IsCacheFull: c.IsCacheFull,
+		Policy:      ps,
+		Admission:   admissionState(c.Admission),
+	}, nil
+}
+
+// SetState : 같은 policy, admission 으로 생성한 Cache 를 st 상태로 복구
+func (c *Cache) SetState(st *CacheState) error {
+	if err := setPolicyState(c.Policy, st.Policy); err != nil {
+		return err
+	}
+	setAdmissionState(c.Admission, st.Admission)
+	c.LimitSize = st.LimitSize
+	c.CurSize = st.CurSize
+	c.HitCount = st.HitCount
+	c.MissCount = st.MissCount
+	c.AdmitCount = st.AdmitCount
+	c.RejectCount = st.RejectCount
+	c.OriginBps = st.OriginBps
+	c.IsCacheFull = st.IsCacheFull
+	return nil
+}
+
+func stateKey(key lruKey) (ChunkKey, error) {
+	k, ok := key.(ChunkKey)
+	if !ok {
+		return ChunkKey{}, fmt.Errorf("invalid cache key type %T", key)
+	}
+	return k, nil
+}
+
+func (l *sizedList) pushBack(e *policyEntry) {
+	l.items[e.key] = l.ll.PushBack(e)
+	l.size += e.size
+}
+
+func (l *sizedList) entries() ([]StateEntry, error) {
+	ret := make([]StateEntry, 0, l.len())
+	for ele := l.ll.Front(); ele != nil; ele = ele.Next() {
+		e := ele.Value.(*policyEntry)
+		k, err := stateKey(e.key)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, StateEntry{Key: k, Size: e.size, Freq: e.freq})
+	}
+	return ret, nil
+}
+
+func newSizedListFrom(entries []StateEntry) *sizedList {
+	l := newSizedList()
+	for _, v := range entries {
+		l.pushBack(&policyEntry{key: v.Key, size: v.Size, freq: v.Freq})
+	}
+	return l
+}
+
+func listsState(ls ...*sizedList) ([][]StateEntry, error) {
+	ret := make([][]StateEntry, len(ls))
+	for i, l := range ls {
+		var err error
+		if ret[i], err = l.entries(); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func policyState(p Policy) (PolicyState, error) {
+	var st PolicyState
+	var err error
+	switch v := p.(type) {
+	case *lruPolicy:
+		var entries []StateEntry
+		for ele := v.lru.ll.Front(); ele != nil; ele = ele.Next() {
+			e := ele.Value.(*lruEntry)
+			k, err := stateKey(e.key)
+			if err != nil {
+				return st, err
+			}
+			entries = append(entries, StateEntry{Key: k, Size: e.value.(int64)})
+		}
+		st.Lists = [][]StateEntry{entries}
+	case *lfuPolicy:
+		freqs := make([]int, 0, len(v.freqs))
+		for f := range v.freqs {
+			freqs = append(freqs, f)
+		}
+		sort.Ints(freqs)
+		ls := make([]*sizedList, len(freqs))
+		for i, f := range freqs {
+			ls[i] = v.freqs[f]
+		}
+		st.Lists, err = listsState(ls...)
+		st.Params = []int64{int64(v.minFreq)}
+	case *arcPolicy:
+		st.Lists, err = listsState(v.t1, v.t2, v.b1, v.b2)
+		st.Params = []int64{v.limitSize, v.p}
+	case *twoQPolicy:
+		st.Lists, err = listsState(v.a1in, v.a1out, v.am)
+		st.Params = []int64{v.kin, v.kout}
+	case *s3FifoPolicy:
+		st.Lists, err = listsState(v.small, v.main, v.ghost)
+		st.Params = []int64{v.smallLimit, v.ghostLimit}
+	default:
+		return st, fmt.Errorf("cache policy %T does not support checkpoint", p)
+	}
+	return st, err
+}
+
+func setPolicyState(p Policy, st PolicyState) error {
+	list := func(i int) []StateEntry {
+		if i < len(st.Lists) {
+			return st.Lists[i]
+		}
+		return nil
+	}
+	param := func(i int) int64 {
+		if i < len(st.Params) {
+			return st.Params[i]
+		}
+		return 0
+	}
+	switch v := p.(type) {
+	case *lruPolicy:
+		v.lru = NewLru(0)
+		for _, e := range list(0) {
+			v.lru.cache[e.Key] = v.lru.ll.PushBack(&lruEntry{key: e.Key, value: e.Size})
+		}
+	case *lfuPolicy:
+		v.freqs = make(map[int]*sizedList)
+		v.keys = make(map[lruKey]int)
+		for _, entries := range st.Lists {
+			if len(entries) == 0 {
+				continue
+			}
+			v.freqs[entries[0].Freq] = newSizedListFrom(entries)
+			for _, e := range entries {
+				v.keys[e.Key] = e.Freq
+			}
+		}
+		v.minFreq = int(param(0))
+	case *arcPolicy:
+		v.t1, v.t2, v.b1, v.b2 = newSizedListFrom(list(0)), newSizedListFrom(list(1)), newSizedListFrom(list(2)), newSizedListFrom(list(3))
+		v.limitSize, v.p = param(0), param(1)
+	case *twoQPolicy:
+		v.a1in, v.a1out, v.am = newSizedListFrom(list(0)), newSizedListFrom(list(1)), newSizedListFrom(list(2))
+		v.kin, v.kout = param(0), param(1)
+	case *s3FifoPolicy:
+		v.small, v.main, v.ghost = newSizedListFrom(list(0)), newSizedListFrom(list(1)), newSizedListFrom(list(2))
+		v.smallLimit, v.ghostLimit = param(0), param(1)
+	default:
+		return fmt.Errorf("cache policy %T does not support checkpoint", p)
+	}
+	return nil
+}
+
+func admissionState(a Admission) AdmissionState {
+	var st AdmissionState
+	switch v := a.(type) {
+	case *tinyLfuAdmission:
+		for i := range v.rows {
+			st.Rows[i] = append([]uint8(nil), v.rows[i]...)
+		}
+		st.Mask, st.SampleSize, st.Added = v.mask, v.sampleSize, v.added
+	case *secondHitAdmission:
+		st.Bits = append([]uint64(nil), v.bits...)
+		st.NBits, st.Entries, st.Added = v.nbits, v.entries, v.added
+	}
+	return st
+}
+
+func setAdmissionState(a Admission, st AdmissionState) {
+	switch v := a.(type) {
+	case *tinyLfuAdmission:
+		for i := range v.rows {
+			v.rows[i] = make([]uint8, st.Mask+1)
+			copy(v.rows[i], st.Rows[i])
+		}
+		v.mask, v.sampleSize, v.added = st.Mask, st.SampleSize, st.Added
+	case *secondHitAdmission:
+		v.bits = make([]uint64, (st.NBits+63)/64)
+		copy(v.bits, st.Bits)
+		v.nbits, v.entries, v.added = st.NBits, st.Entries, st.Added
+	}
+}
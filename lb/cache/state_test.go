@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+func TestCache_State(t *testing.T) {
+	tests := []struct {
+		policy    string
+		admission string
+	}{
+		{PolicyLRU, ""},
+		{PolicyLFU, AdmissionTinyLFU},
+		{PolicyARC, ""},
+		{Policy2Q, AdmissionSecondHit},
+		{PolicyS3FIFO, AdmissionSize},
+	}
+	newCache := func(policy, admission string) *Cache {
+		adm, err := NewAdmission(admission, 200, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := NewCache(200, policy, adm, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	for _, tt := range tests {
+		r := rand.New(rand.NewSource(1))
+		evts := make([]*data.ChunkEvent, 2000)
+		for i := range evts {
+			evts[i] = &data.ChunkEvent{IntFileName: r.Intn(20) * r.Intn(5), Index: int64(r.Intn(3)), ChunkSize: 10, Bps: 1}
+		}
+		a := newCache(tt.policy, tt.admission)
+		for _, v := range evts[:1000] {
+			a.StartChunk(v)
+		}
+		st, err := a.State()
+		if err != nil {
+			t.Fatalf("[%v] %v", tt.policy, err)
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(st); err != nil {
+			t.Fatal(err)
+		}
+		var decoded CacheState
+		if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+			t.Fatal(err)
+		}
+		b := newCache(tt.policy, tt.admission)
+		if err := b.SetState(&decoded); err != nil {
+			t.Fatalf("[%v] %v", tt.policy, err)
+		}
+		for _, v := range evts[1000:] {
+			ua, _ := a.StartChunk(v)
+			ub, _ := b.StartChunk(v)
+			if ua != ub {
+				t.Fatalf("[%v] restored cache must have same result", tt.policy)
+			}
+		}
+		sa, _ := a.State()
+		sb, _ := b.State()
+		if !reflect.DeepEqual(sa, sb) {
+			t.Errorf("[%v] different state %+v, %+v", tt.policy, sa.Policy, sb.Policy)
+		}
+	}
+
+	c := &Cache{LimitSize: 100, Policy: newOptPolicy(nil, false)}
+	if _, err := c.State(); err == nil {
+		t.Errorf("opt policy must not support checkpoint")
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+// twoQPolicy : 2Q (Johnson & Shasha, full version)
+//
+// a1in: 처음 사용된 chunk 의 FIFO, a1out: a1in 에서 삭제된 chunk 의 ghost, am: 재사용된 chunk 의 LRU
+type twoQPolicy struct {
+	kin   int64 // a1in 의 최대 크기 (전체의 25%)
+	kout  int64 // a1out 의 최대 크기 (전체의 50%)
+	a1in  *sizedList
+	a1out *sizedList
+	am    *sizedList
+}
+
+func newTwoQPolicy(limitSize int64) *twoQPolicy {
+	return &twoQPolicy{
+		kin:   limitSize / 4,
+		kout:  limitSize / 2,
+		a1in:  newSizedList(),
+		a1out: newSizedList(),
+		am:    newSizedList(),
+	}
+}
+
+func (p *twoQPolicy) Add(key lruKey, size int64) {
+	p.Remove(key)
+	if ele, ok := p.a1out.get(key); ok {
+		p.a1out.remove(ele)
+		p.am.pushFront(&policyEntry{key: key, size: size})
+		return
+	}
+	p.a1in.pushFront(&policyEntry{key: key, size: size})
+}
+
+func (p *twoQPolicy) Get(key lruKey) (size int64, ok bool) {
+	if ele, ok := p.am.get(key); ok {
+		p.am.ll.MoveToFront(ele)
+		return ele.Value.(*policyEntry).size, true
+	}
+	if ele, ok := p.a1in.get(key); ok {
+		// a1in 에서는 순서를 바꾸지 않음
+		return ele.Value.(*policyEntry).size, true
+	}
+	return 0, false
+}
+
+func (p *twoQPolicy) Remove(key lruKey) (size int64, ok bool) {
+	if ele, ok := p.am.get(key); ok {
+		return p.am.remove(ele).size, true
+	}
+	if ele, ok := p.a1in.get(key); ok {
+		return p.a1in.remove(ele).size, true
+	}
+	return 0, false
+}
+
+func (p *twoQPolicy) Evict() (key lruKey, size int64, ok bool) {
+	var e *policyEntry
+	if p.replaceA1in() {
+		e = p.a1in.removeBack()
+		p.a1out.pushFront(e)
+		for p.a1out.len() > 0 && p.a1out.size > p.kout {
+			p.a1out.removeBack()
+		}
+	} else if p.am.len() > 0 {
+		e = p.am.removeBack()
+	} else {
+		return nil, 0, false
+	}
+	return e.key, e.size, true
+}
+
+func (p *twoQPolicy) Victim() (key lruKey, ok bool) {
+	if p.replaceA1in() {
+		return p.a1in.back().key, true
+	} else if p.am.len() > 0 {
+		return p.am.back().key, true
+	}
+	return nil, false
+}
+
+func (p *twoQPolicy) replaceA1in() bool {
+	return p.a1in.len() > 0 && (p.a1in.size > p.kin || p.am.len() == 0)
+}
+
+func (p *twoQPolicy) Len() int {
+	return p.a1in.len() + p.am.len()
+}
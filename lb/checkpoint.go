@@ -0,0 +1,617 @@
+package lb
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/cache"
+	"github.com/castisdev/cdn-simul/lb/vod"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// Checkpointer : 상태를 저장하고 복구할 수 있는 LoadBalancer, VODSelector
+// LoadState 는 같은 설정으로 새로 생성한 객체에서 호출
+type Checkpointer interface {
+	SaveState() ([]byte, error)
+	LoadState(b []byte) error
+}
+
+func encodeState(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeState(b []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+func sortedInts(m map[int]struct{}) []int {
+	ret := make([]int, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Ints(ret)
+	return ret
+}
+
+func intSet(l []int) map[int]struct{} {
+	ret := make(map[int]struct{}, len(l))
+	for _, v := range l {
+		ret[v] = struct{}{}
+	}
+	return ret
+}
+
+func sameVODs(a, b []data.VODConfig) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type rejecterState struct {
+	Sessions []string
+	Status   status.RejectStatus
+}
+
+func (r *sessionRejecter) state() rejecterState {
+	st := rejecterState{Status: r.status}
+	for k := range r.sessions {
+		st.Sessions = append(st.Sessions, k)
+	}
+	sort.Strings(st.Sessions)
+	return st
+}
+
+func (r *sessionRejecter) setState(st rejecterState) {
+	r.status = st.Status
+	r.sessions = make(map[string]struct{})
+	for _, v := range st.Sessions {
+		r.sessions[v] = struct{}{}
+	}
+}
+
+func saveSelector(s VODSelector) ([]byte, error) {
+	c, ok := s.(Checkpointer)
+	if !ok {
+		return nil, fmt.Errorf("selector %T does not support checkpoint", s)
+	}
+	return c.SaveState()
+}
+
+func loadSelector(s VODSelector, b []byte) error {
+	c, ok := s.(Checkpointer)
+	if !ok {
+		return fmt.Errorf("selector %T does not support checkpoint", s)
+	}
+	return c.LoadState(b)
+}
+
+type parentState struct {
+	Cache *cache.CacheState
+	InBps int64
+}
+
+type lbState struct {
+	Cfg          data.Config
+	VODs         map[vod.Key]vod.VOD
+	Caches       map[vod.Key]*cache.CacheState
+	Parents      map[string]parentState
+	Sessions     map[string]vod.Key
+	ParentChunks map[string]bool
+	Rejecter     rejecterState
+	Files        []string
+	Topology     status.TopologyStatus
+	TopologyHit  int64
+	TopologyMiss int64
+	Selector     []byte
+}
+
+// SaveState :
+func (lb *LB) SaveState() ([]byte, error) {
+	st := lbState{
+		Cfg:          lb.cfg,
+		VODs:         make(map[vod.Key]vod.VOD),
+		Caches:       make(map[vod.Key]*cache.CacheState),
+		Parents:      make(map[string]parentState),
+		Sessions:     lb.vodSessionMap,
+		ParentChunks: lb.parentChunks,
+		Rejecter:     lb.rejecter.state(),
+		Topology:     lb.topology,
+		TopologyHit:  lb.topologyHit,
+		TopologyMiss: lb.topologyMiss,
+	}
+	for k, v := range lb.VODs {
+		st.VODs[k] = *v
+	}
+	for k, v := range lb.Caches {
+		cs, err := v.State()
+		if err != nil {
+			return nil, err
+		}
+		st.Caches[k] = cs
+	}
+	for k, v := range lb.Parents {
+		cs, err := v.Cache.State()
+		if err != nil {
+			return nil, err
+		}
+		st.Parents[k] = parentState{Cache: cs, InBps: v.InBps}
+	}
+	for k := range lb.files {
+		st.Files = append(st.Files, k)
+	}
+	sort.Strings(st.Files)
+	var err error
+	if st.Selector, err = saveSelector(lb.Selector); err != nil {
+		return nil, err
+	}
+	return encodeState(&st)
+}
+
+// LoadState : topology 변경으로 VOD 구성이 바뀌었으면 저장된 cfg 로 VOD, cache, selector 를 다시 생성
+func (lb *LB) LoadState(b []byte) error {
+	var st lbState
+	if err := decodeState(b, &st); err != nil {
+		return err
+	}
+	if !sameVODs(lb.cfg.VODs, st.Cfg.VODs) {
+		lb.Caches = make(map[vod.Key]*cache.Cache)
+		lb.VODs = make(map[vod.Key]*vod.VOD)
+		lb.vodParentMap = make(map[vod.Key]string)
+		for _, v := range st.Cfg.VODs {
+			if err := lb.addVOD(v); err != nil {
+				return err
+			}
+		}
+		if err := lb.Selector.Init(st.Cfg); err != nil {
+			return err
+		}
+	}
+	lb.cfg = st.Cfg
+	for k, v := range lb.VODs {
+		sv, ok := st.VODs[k]
+		if !ok {
+			return fmt.Errorf("not exists state of vod %v", k)
+		}
+		*v = sv
+		if err := lb.Caches[k].SetState(st.Caches[k]); err != nil {
+			return err
+		}
+	}
+	for k, v := range lb.Parents {
+		sp, ok := st.Parents[k]
+		if !ok {
+			return fmt.Errorf("not exists state of parent %v", k)
+		}
+		if err := v.Cache.SetState(sp.Cache); err != nil {
+			return err
+		}
+		v.InBps = sp.InBps
+	}
+	lb.vodSessionMap = make(map[string]vod.Key)
+	for k, v := range st.Sessions {
+		lb.vodSessionMap[k] = v
+	}
+	lb.parentChunks = make(map[string]bool)
+	for k, v := range st.ParentChunks {
+		lb.parentChunks[k] = v
+	}
+	lb.rejecter.setState(st.Rejecter)
+	lb.files = make(map[string]struct{})
+	for _, v := range st.Files {
+		lb.files[v] = struct{}{}
+	}
+	lb.topology = st.Topology
+	lb.topologyHit, lb.topologyMiss = st.TopologyHit, st.TopologyMiss
+	return loadSelector(lb.Selector, st.Selector)
+}
+
+type filebaseLBState struct {
+	VODs             map[vod.Key]vod.VOD
+	Counts           map[vod.Key]FilebaseCount
+	Sessions         map[string]vod.Key
+	MissSessions     map[string]vod.Key
+	HitCount         int64
+	MissCount        int64
+	MissSessionCount int64
+	OriginBps        int64
+	Rejecter         rejecterState
+	Selector         []byte
+}
+
+// SaveState :
+func (lb *FilebaseLB) SaveState() ([]byte, error) {
+	st := filebaseLBState{
+		VODs:             make(map[vod.Key]vod.VOD),
+		Counts:           make(map[vod.Key]FilebaseCount),
+		Sessions:         lb.vodSessionMap,
+		MissSessions:     lb.missSessionMap,
+		HitCount:         lb.HitCount,
+		MissCount:        lb.MissCount,
+		MissSessionCount: lb.MissSessionCount,
+		OriginBps:        lb.OriginBps,
+		Rejecter:         lb.rejecter.state(),
+	}
+	for k, v := range lb.VODs {
+		st.VODs[k] = *v
+		st.Counts[k] = *lb.Counts[k]
+	}
+	var err error
+	if st.Selector, err = saveSelector(lb.selector); err != nil {
+		return nil, err
+	}
+	return encodeState(&st)
+}
+
+// LoadState :
+func (lb *FilebaseLB) LoadState(b []byte) error {
+	var st filebaseLBState
+	if err := decodeState(b, &st); err != nil {
+		return err
+	}
+	for k, v := range lb.VODs {
+		sv, ok := st.VODs[k]
+		if !ok {
+			return fmt.Errorf("not exists state of vod %v", k)
+		}
+		*v = sv
+		*lb.Counts[k] = st.Counts[k]
+	}
+	lb.vodSessionMap = make(map[string]vod.Key)
+	for k, v := range st.Sessions {
+		lb.vodSessionMap[k] = v
+	}
+	lb.missSessionMap = make(map[string]vod.Key)
+	for k, v := range st.MissSessions {
+		lb.missSessionMap[k] = v
+	}
+	lb.HitCount, lb.MissCount, lb.OriginBps = st.HitCount, st.MissCount, st.OriginBps
+	lb.MissSessionCount = st.MissSessionCount
+	lb.rejecter.setState(st.Rejecter)
+	return loadSelector(lb.selector, st.Selector)
+}
+
+type legacyLBState struct {
+	VODs      map[vod.Key]vod.VOD
+	Sessions  map[string]vod.Key
+	HitCount  int64
+	MissCount int64
+	OriginBps int64
+	Rejecter  rejecterState
+}
+
+// SaveState :
+func (lb *LegacyLB) SaveState() ([]byte, error) {
+	st := legacyLBState{
+		VODs:      make(map[vod.Key]vod.VOD),
+		Sessions:  lb.vodSessionMap,
+		HitCount:  lb.HitCount,
+		MissCount: lb.MissCount,
+		OriginBps: lb.OriginBps,
+		Rejecter:  lb.rejecter.state(),
+	}
+	for k, v := range lb.VODs {
+		st.VODs[k] = *v
+	}
+	return encodeState(&st)
+}
+
+// LoadState :
+func (lb *LegacyLB) LoadState(b []byte) error {
+	var st legacyLBState
+	if err := decodeState(b, &st); err != nil {
+		return err
+	}
+	for k, v := range lb.VODs {
+		sv, ok := st.VODs[k]
+		if !ok {
+			return fmt.Errorf("not exists state of vod %v", k)
+		}
+		*v = sv
+	}
+	lb.vodSessionMap = make(map[string]vod.Key)
+	for k, v := range st.Sessions {
+		lb.vodSessionMap[k] = v
+	}
+	lb.HitCount, lb.MissCount, lb.OriginBps = st.HitCount, st.MissCount, st.OriginBps
+	lb.rejecter.setState(st.Rejecter)
+	return nil
+}
+
+// SaveState : hash 는 Init 에서 cfg 로 생성되므로 저장할 상태 없음
+func (s *SameHashingWeight) SaveState() ([]byte, error) {
+	return nil, nil
+}
+
+// LoadState :
+func (s *SameHashingWeight) LoadState(b []byte) error {
+	return nil
+}
+
+// SaveState : Init 에서 cfg 로 생성되므로 저장할 상태 없음
+func (s *Rendezvous) SaveState() ([]byte, error) {
+	return nil, nil
+}
+
+// LoadState :
+func (s *Rendezvous) LoadState(b []byte) error {
+	return nil
+}
+
+type hitInfoState struct {
+	Filename int
+	Hit      int64
+	Filesize int64
+	RegT     time.Time
+}
+
+type highLowState struct {
+	ContentHits     map[int]int64
+	UpdatedHotListT time.Time
+	HotList         []hitInfoState
+}
+
+// SaveState :
+func (s *HighLowGroup) SaveState() ([]byte, error) {
+	st := highLowState{ContentHits: s.contentHits, UpdatedHotListT: s.updatedHotListT}
+	for _, v := range s.hotList {
+		st.HotList = append(st.HotList, hitInfoState{Filename: v.filename, Hit: v.hit, Filesize: v.filesize, RegT: v.regT})
+	}
+	return encodeState(&st)
+}
+
+// LoadState :
+func (s *HighLowGroup) LoadState(b []byte) error {
+	var st highLowState
+	if err := decodeState(b, &st); err != nil {
+		return err
+	}
+	s.contentHits = make(map[int]int64)
+	for k, v := range st.ContentHits {
+		s.contentHits[k] = v
+	}
+	s.updatedHotListT = st.UpdatedHotListT
+	s.hotList = nil
+	for _, v := range st.HotList {
+		s.hotList = append(s.hotList, HitInfo{filename: v.Filename, hit: v.Hit, filesize: v.Filesize, regT: v.RegT})
+	}
+	return nil
+}
+
+type replicationState struct {
+	Ranker   *rankerState
+	UpdatedT time.Time
+	Replicas map[int]int
+}
+
+// SaveState :
+func (s *Replication) SaveState() ([]byte, error) {
+	rs, err := saveRanker(s.ranker)
+	if err != nil {
+		return nil, err
+	}
+	return encodeState(&replicationState{Ranker: rs, UpdatedT: s.updatedT, Replicas: s.replicas})
+}
+
+// LoadState :
+func (s *Replication) LoadState(b []byte) error {
+	var st replicationState
+	if err := decodeState(b, &st); err != nil {
+		return err
+	}
+	if err := loadRanker(s.ranker, st.Ranker); err != nil {
+		return err
+	}
+	s.updatedT = st.UpdatedT
+	s.replicas = make(map[int]int)
+	for k, v := range st.Replicas {
+		s.replicas[k] = v
+	}
+	return nil
+}
+
+type fileBaseState struct {
+	Storages  map[vod.Key]*storageState
+	Placement placementState
+}
+
+// SaveState :
+func (s *FileBase) SaveState() ([]byte, error) {
+	st := fileBaseState{Storages: make(map[vod.Key]*storageState)}
+	for k, v := range s.storages {
+		ss, err := saveStorage(v)
+		if err != nil {
+			return nil, err
+		}
+		st.Storages[k] = ss
+	}
+	var err error
+	if st.Placement, err = s.placement.state(); err != nil {
+		return nil, err
+	}
+	return encodeState(&st)
+}
+
+// LoadState :
+func (s *FileBase) LoadState(b []byte) error {
+	var st fileBaseState
+	if err := decodeState(b, &st); err != nil {
+		return err
+	}
+	for k, v := range s.storages {
+		ss, ok := st.Storages[k]
+		if !ok {
+			return fmt.Errorf("not exists state of storage %v", k)
+		}
+		if err := loadStorage(v, ss); err != nil {
+			return err
+		}
+	}
+	return s.placement.setState(st.Placement)
+}
+
+type placementState struct {
+	Ranker   *rankerState
+	UpdatedT time.Time
+	HotFiles []int
+}
+
+func (p *FilePlacement) state() (placementState, error) {
+	rs, err := saveRanker(p.hitRanker)
+	if err != nil {
+		return placementState{}, err
+	}
+	return placementState{Ranker: rs, UpdatedT: p.updatedT, HotFiles: sortedInts(p.hotFiles)}, nil
+}
+
+func (p *FilePlacement) setState(st placementState) error {
+	if err := loadRanker(p.hitRanker, st.Ranker); err != nil {
+		return err
+	}
+	p.updatedT = st.UpdatedT
+	p.hotFiles = intSet(st.HotFiles)
+	return nil
+}
+
+// storageState : FilebaseStorage, IdealStorage 상태
+type storageState struct {
+	Contents     []int
+	CurSize      int64
+	PushedT      time.Time
+	PushingQ     []int
+	Ranker       *rankerState
+	RankerForDel *rankerState
+	DeliverIdx   int
+	PurgeIdx     int
+	UpdatedT     time.Time
+}
+
+func saveStorage(s Storage) (*storageState, error) {
+	switch v := s.(type) {
+	case *FilebaseStorage:
+		st := &storageState{Contents: sortedInts(v.contents), CurSize: v.curSize, PushedT: v.pushedT, PushingQ: v.pushingQ}
+		var err error
+		if st.Ranker, err = saveRanker(v.hitRanker); err != nil {
+			return nil, err
+		}
+		if st.RankerForDel, err = saveRanker(v.hitRankerForDel); err != nil {
+			return nil, err
+		}
+		if v.deliverP != nil {
+			st.DeliverIdx = v.deliverP.curIdx
+		}
+		if v.purgeP != nil {
+			st.PurgeIdx = v.purgeP.curIdx
+		}
+		return st, nil
+	case *IdealStorage:
+		rs, err := saveRanker(v.hitRanker)
+		if err != nil {
+			return nil, err
+		}
+		return &storageState{Contents: sortedInts(v.contents), CurSize: v.curSize, Ranker: rs, UpdatedT: v.updatedT}, nil
+	}
+	return nil, fmt.Errorf("storage %T does not support checkpoint", s)
+}
+
+func loadStorage(s Storage, st *storageState) error {
+	switch v := s.(type) {
+	case *FilebaseStorage:
+		if err := loadRanker(v.hitRanker, st.Ranker); err != nil {
+			return err
+		}
+		if err := loadRanker(v.hitRankerForDel, st.RankerForDel); err != nil {
+			return err
+		}
+		v.contents = intSet(st.Contents)
+		v.curSize, v.pushedT, v.pushingQ = st.CurSize, st.PushedT, st.PushingQ
+		if v.deliverP != nil {
+			v.deliverP.curIdx = st.DeliverIdx
+		}
+		if v.purgeP != nil {
+			v.purgeP.curIdx = st.PurgeIdx
+		}
+		return nil
+	case *IdealStorage:
+		if err := loadRanker(v.hitRanker, st.Ranker); err != nil {
+			return err
+		}
+		v.contents = intSet(st.Contents)
+		v.curSize, v.updatedT = st.CurSize, st.UpdatedT
+		return nil
+	}
+	return fmt.Errorf("storage %T does not support checkpoint", s)
+}
+
+// rankerState : HitRanker, DeleteLruRanker 상태, ranker 가 없으면 nil
+type rankerState struct {
+	ShiftT           time.Time
+	CurT             time.Time
+	ContentHits      []map[int]int64
+	ContentHitCounts []map[int]int64
+	RecentSessionT   map[int]time.Time
+}
+
+func saveRanker(r Ranker) (*rankerState, error) {
+	switch v := r.(type) {
+	case nil:
+		return nil, nil
+	case *HitRanker:
+		return &rankerState{ShiftT: v.shiftT, CurT: v.curT, ContentHits: v.contentHits, ContentHitCounts: v.contentHitCounts}, nil
+	case *DeleteLruRanker:
+		st, err := saveRanker(v.hitRanker)
+		if err != nil {
+			return nil, err
+		}
+		st.RecentSessionT = v.recentSessionT
+		return st, nil
+	}
+	return nil, fmt.Errorf("ranker %T does not support checkpoint", r)
+}
+
+// loadRanker : gob 은 빈 map 을 nil 로 복구하므로 slot 별 map 을 새로 생성
+func loadRanker(r Ranker, st *rankerState) error {
+	switch v := r.(type) {
+	case nil:
+		return nil
+	case *HitRanker:
+		if st == nil || len(st.ContentHits) != len(v.contentHits) || len(st.ContentHitCounts) != len(v.contentHitCounts) {
+			return fmt.Errorf("invalid ranker state, slot size is different")
+		}
+		v.shiftT, v.curT = st.ShiftT, st.CurT
+		for i := range v.contentHits {
+			v.contentHits[i] = make(map[int]int64)
+			for k, n := range st.ContentHits[i] {
+				v.contentHits[i][k] = n
+			}
+			v.contentHitCounts[i] = make(map[int]int64)
+			for k, n := range st.ContentHitCounts[i] {
+				v.contentHitCounts[i][k] = n
+			}
+		}
+		return nil
+	case *DeleteLruRanker:
+		if err := loadRanker(v.hitRanker, st); err != nil {
+			return err
+		}
+		v.recentSessionT = make(map[int]time.Time)
+		for k, t := range st.RecentSessionT {
+			v.recentSessionT[k] = t
+		}
+		return nil
+	}
+	return fmt.Errorf("ranker %T does not support checkpoint", r)
+}
@@ -0,0 +1,83 @@
+package lb
+
+import (
+	"fmt"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+// ChoicesOfD : power of d choices, consistent hash 의 처음 d 개 VOD 중 부하가 가장 작은 VOD 선택
+// d 가 0 이면 모든 VOD 중 선택(least-loaded), 부하가 같으면 hash 순서
+// SameWeightDup2 를 일반화한 것으로 d 개 VOD 모두 처리할 수 없으면 나머지 VOD 중 SelectAvailableFirst 로 선택
+type ChoicesOfD struct {
+	SameHashingWeight
+	d           int
+	metric      string
+	utilization bool // true 이면 limit 대비 부하 비율, false 이면 부하 값으로 비교
+}
+
+// NewChoicesOfD : metric 은 LoadSession, LoadBps 중 하나, limit 대비 부하 비율로 비교
+func NewChoicesOfD(d int, metric string) VODSelector {
+	return &ChoicesOfD{d: d, metric: metric, utilization: true}
+}
+
+// NewLeastConn : session 수가 가장 작은 VOD 선택
+func NewLeastConn() VODSelector {
+	return &ChoicesOfD{metric: LoadSession}
+}
+
+// NewLeastBps : limit 대비 bps 비율이 가장 작은 VOD 선택
+func NewLeastBps() VODSelector {
+	return &ChoicesOfD{metric: LoadBps, utilization: true}
+}
+
+// Init :
+func (s *ChoicesOfD) Init(cfg data.Config) error {
+	if s.d < 0 {
+		return fmt.Errorf("invalid choices %v", s.d)
+	}
+	if err := validLoadMetric(s.metric); err != nil {
+		return err
+	}
+	fmt.Printf("choices: d:%v metric:%v utilization:%v\n", s.d, s.metric, s.utilization)
+	return s.SameHashingWeight.Init(cfg)
+}
+
+// VODSelect :
+func (s *ChoicesOfD) VODSelect(evt *data.SessionEvent, lb LoadBalancer) (vod.Key, error) {
+	vodKeys := s.hash.GetItems(evt.FileName)
+	n := len(vodKeys)
+	if s.d > 0 && s.d < n {
+		n = s.d
+	}
+	vods := lb.GetVODs()
+	var best vod.Key
+	var bestLoad float64
+	for _, k := range vodKeys[:n] {
+		v := vods[vod.Key(k)]
+		if v.Available(evt.Bps) != nil {
+			continue
+		}
+		l := s.load(v)
+		if best == "" || l < bestLoad {
+			best = vod.Key(k)
+			bestLoad = l
+		}
+	}
+	if best != "" {
+		return best, nil
+	}
+	return SelectAvailableFirst(evt, lb, vodKeys)
+}
+
+func (s *ChoicesOfD) load(v *vod.VOD) float64 {
+	cur, limit := vodLoad(v, s.metric)
+	if !s.utilization {
+		return float64(cur)
+	}
+	if limit <= 0 {
+		return 1
+	}
+	return float64(cur) / float64(limit)
+}
@@ -11,29 +11,42 @@ import (
 
 // FilebaseLB :
 type FilebaseLB struct {
-	VODs          map[vod.Key]*vod.VOD
-	vodSessionMap map[string]vod.Key
-	selector      VODSelector
-	HitCount      int64
-	MissCount     int64
-	OriginBps     int64
+	VODs             map[vod.Key]*vod.VOD
+	Counts           map[vod.Key]*FilebaseCount
+	vodSessionMap    map[string]vod.Key
+	missSessionMap   map[string]vod.Key // file 이 없어 origin 에서 처리하는 session, file 을 저장해야 할 VOD 로 집계
+	selector         VODSelector
+	HitCount         int64
+	MissCount        int64
+	MissSessionCount int64 // file 이 없어 origin 에서 처리한 session 수, 거부된 session 이 아님
+	OriginBps        int64
+	rejecter         sessionRejecter
+}
+
+// FilebaseCount : VOD 별 chunk hit/miss
+type FilebaseCount struct {
+	HitCount  int64
+	MissCount int64
+	OriginBps int64
 }
 
 // NewFilebaseLB :
 func NewFilebaseLB(cfg data.Config, selector VODSelector) (LoadBalancer, error) {
 	fmt.Println("FilebaseLB created")
 	l := &FilebaseLB{
-		VODs:          make(map[vod.Key]*vod.VOD),
-		vodSessionMap: make(map[string]vod.Key),
-		selector:      selector,
+		VODs:           make(map[vod.Key]*vod.VOD),
+		Counts:         make(map[vod.Key]*FilebaseCount),
+		vodSessionMap:  make(map[string]vod.Key),
+		missSessionMap: make(map[string]vod.Key),
+		selector:       selector,
+		rejecter:       newSessionRejecter(cfg.Overflow),
 	}
 
-	// 1개의 VOD만 있다고 가정
 	for _, v := range cfg.VODs {
 		l.VODs[vod.Key(v.VodID)] = &vod.VOD{LimitSessionCount: v.LimitSession, LimitBps: v.LimitBps}
-		break
+		l.Counts[vod.Key(v.VodID)] = &FilebaseCount{}
 	}
-	if len(l.VODs) != 1 {
+	if len(l.VODs) == 0 {
 		return nil, fmt.Errorf("invalid vod info")
 	}
 	err := l.selector.Init(cfg)
@@ -48,6 +61,12 @@ func (lb *FilebaseLB) GetVODs() map[vod.Key]*vod.VOD {
 	return lb.VODs
 }
 
+// SessionVOD : session 을 처리 중인 VOD
+func (lb *FilebaseLB) SessionVOD(sid string) (vod.Key, bool) {
+	k, ok := lb.vodSessionMap[sid]
+	return k, ok
+}
+
 // Status :
 func (lb *FilebaseLB) Status(t time.Time) *status.Status {
 	return lb.MakeStatus(t)
@@ -57,19 +76,23 @@ func (lb *FilebaseLB) Status(t time.Time) *status.Status {
 func (lb *FilebaseLB) StartSession(evt *data.SessionEvent) error {
 	k, err := lb.selector.VODSelect(evt, lb)
 	if err == ErrFileNotFound {
-		// file 없으면 StartChunk 시 cache miss 처리
-		for _, v := range lb.VODs {
-			// 하나의 VOD만 있다고 가정
+		// file 없으면 거부하지 않고 StartChunk 시 cache miss 처리
+		lb.MissSessionCount++
+		if v, ok := lb.VODs[k]; ok {
 			v.HitFail()
-			break
+			lb.missSessionMap[evt.SessionID] = k
 		}
 		return nil
+	} else if isRejectReason(err) {
+		return lb.rejecter.reject(evt, err)
 	} else if err != nil {
 		return fmt.Errorf("failed to select VOD, %v", err)
 	}
 
 	err = lb.VODs[k].StartSession(evt)
-	if err != nil {
+	if isRejectReason(err) {
+		return lb.rejecter.reject(evt, err)
+	} else if err != nil {
 		return fmt.Errorf("failed to start session in VOD, %v", err)
 	}
 	lb.vodSessionMap[evt.SessionID] = k
@@ -79,6 +102,11 @@ func (lb *FilebaseLB) StartSession(evt *data.SessionEvent) error {
 // EndSession :
 func (lb *FilebaseLB) EndSession(evt *data.SessionEvent) error {
 	lb.selector.EndSession(evt)
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.endSession(evt)
+		return nil
+	}
+	delete(lb.missSessionMap, evt.SessionID)
 	key, ok := lb.vodSessionMap[evt.SessionID]
 	if ok {
 		err := lb.VODs[key].EndSession(evt)
@@ -91,21 +119,37 @@ func (lb *FilebaseLB) EndSession(evt *data.SessionEvent) error {
 }
 
 // StartChunk :
-func (lb *FilebaseLB) StartChunk(evt *data.ChunkEvent) (useOrigin bool, err error) {
-	_, ok := lb.vodSessionMap[evt.SessionID]
+func (lb *FilebaseLB) StartChunk(evt *data.ChunkEvent) (ChunkSource, error) {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.startChunk(evt)
+		return SourceOrigin, nil
+	}
+	k, ok := lb.vodSessionMap[evt.SessionID]
 	if ok {
 		lb.HitCount++
-	} else {
-		lb.MissCount++
-		lb.OriginBps += evt.Bps
+		lb.Counts[k].HitCount++
+		return SourceVOD, nil
 	}
-	return !ok, nil
+	lb.MissCount++
+	lb.OriginBps += evt.Bps
+	if k, ok := lb.missSessionMap[evt.SessionID]; ok {
+		lb.Counts[k].MissCount++
+		lb.Counts[k].OriginBps += evt.Bps
+	}
+	return SourceOrigin, nil
 }
 
 // EndChunk :
-func (lb *FilebaseLB) EndChunk(evt *data.ChunkEvent, useOrigin bool) error {
-	if useOrigin {
+func (lb *FilebaseLB) EndChunk(evt *data.ChunkEvent, src ChunkSource) error {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.endChunk(evt)
+		return nil
+	}
+	if src == SourceOrigin {
 		lb.OriginBps -= evt.Bps
+		if k, ok := lb.missSessionMap[evt.SessionID]; ok {
+			lb.Counts[k].OriginBps -= evt.Bps
+		}
 	}
 	return nil
 }
@@ -117,9 +161,12 @@ func (lb *FilebaseLB) MakeStatus(t time.Time) *status.Status {
 		Origin: &status.OriginStatus{},
 		Vods:   make(map[vod.Key]*status.VODStatus),
 		Caches: make(map[vod.Key]*status.CacheStatus),
+		Reject: lb.rejecter.makeStatus(),
 	}
 	st.AllCacheFull = true
-	st.Origin.Bps = lb.OriginBps
+	st.Origin.Bps = lb.OriginBps + st.Reject.OverflowBps
+	st.Origin.MissSessionCount = lb.MissSessionCount
+	fb, _ := lb.selector.(*FileBase)
 	for k, v := range lb.VODs {
 		st.Vods[k] = &status.VODStatus{
 			VODKey:            string(k),
@@ -128,12 +175,15 @@ func (lb *FilebaseLB) MakeStatus(t time.Time) *status.Status {
 			TotalSessionCount: v.TotalSessionCount,
 			HitSessionCount:   v.HitSessionCount,
 		}
+		c := lb.Counts[k]
 		st.Caches[k] = &status.CacheStatus{
 			VODKey:         string(k),
-			CacheHitCount:  lb.HitCount,
-			CacheMissCount: lb.MissCount,
-			OriginBps:      lb.OriginBps,
-			CurSize:        0,
+			CacheHitCount:  c.HitCount,
+			CacheMissCount: c.MissCount,
+			OriginBps:      c.OriginBps,
+		}
+		if fb != nil {
+			st.Caches[k].CurSize = fb.storages[k].CurSize()
 		}
 	}
 	return st
@@ -2,6 +2,7 @@ package lb
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/castisdev/cdn-simul/data"
@@ -14,47 +15,141 @@ import (
 type LB struct {
 	Caches        map[vod.Key]*cache.Cache
 	VODs          map[vod.Key]*vod.VOD
+	Parents       map[string]*Parent
 	vodSessionMap map[string]vod.Key
+	vodParentMap  map[vod.Key]string
+	parentChunks  map[string]bool // session 별 진행 중인 parent chunk 의 origin 사용 여부
+	rejecter      sessionRejecter
 	Selector      VODSelector
+	cfg           data.Config
+	oracle        cache.Oracle
+	files         map[string]struct{} // selector 가 Owner 인 경우 topology 변경 시 owner 가 바뀐 file 수 측정에 사용
+	topology      status.TopologyStatus
+	topologyHit   int64 // 마지막 topology 변경 시점의 전체 cache hit 수
+	topologyMiss  int64
+}
+
+// FaultHandler : VOD 장애(점검)를 처리할 수 있는 LoadBalancer
+type FaultHandler interface {
+	DownVOD(key vod.Key) error
+	UpVOD(key vod.Key, wipeCache bool) error
+	Sessions(key vod.Key) []string
+}
+
+// OriginReporter : Status 를 만들지 않고 origin 요청 bps 를 알 수 있는 LoadBalancer
+type OriginReporter interface {
+	OriginBps() int64
+}
+
+// Parent : 여러 VOD 가 공유하는 mid-tier cache
+type Parent struct {
+	Cache *cache.Cache
+	InBps int64 // 하위 VOD 들의 cache miss 로 요청되는 bps
+}
+
+// ChunkSource : chunk 를 전달받은 곳
+type ChunkSource int
+
+const (
+	// SourceVOD : VOD cache hit
+	SourceVOD ChunkSource = iota
+	// SourceParent : VOD cache miss, parent cache hit
+	SourceParent
+	// SourceOrigin : origin 에서 가져옴 (parent 가 없거나 parent 도 miss, overflow session)
+	SourceOrigin
+)
+
+// VODMiss : VOD cache 에 없어 VOD 밖에서 가져온 chunk 인지
+func (s ChunkSource) VODMiss() bool {
+	return s != SourceVOD
 }
 
 // LoadBalancer :
 type LoadBalancer interface {
 	StartSession(evt *data.SessionEvent) error
 	EndSession(evt *data.SessionEvent) error
-	StartChunk(evt *data.ChunkEvent) (useOrigin bool, err error)
-	EndChunk(evt *data.ChunkEvent, useOrigin bool) error
+	StartChunk(evt *data.ChunkEvent) (ChunkSource, error)
+	EndChunk(evt *data.ChunkEvent, src ChunkSource) error
 	GetVODs() map[vod.Key]*vod.VOD
+	SessionVOD(sid string) (vod.Key, bool)
 	Status(t time.Time) *status.Status
 }
 
 // New :
 func New(cfg data.Config, selector VODSelector) (LoadBalancer, error) {
+	return NewWithOracle(cfg, selector, nil)
+}
+
+// NewWithOracle : oracle 은 cache policy 가 opt, opt-size 인 VOD 의 cache 에서 사용
+func NewWithOracle(cfg data.Config, selector VODSelector, oracle cache.Oracle) (LoadBalancer, error) {
 	fmt.Println("LB created")
 	l := &LB{
 		Caches:        make(map[vod.Key]*cache.Cache),
 		VODs:          make(map[vod.Key]*vod.VOD),
+		Parents:       make(map[string]*Parent),
 		vodSessionMap: make(map[string]vod.Key),
+		vodParentMap:  make(map[vod.Key]string),
+		parentChunks:  make(map[string]bool),
+		rejecter:      newSessionRejecter(cfg.Overflow),
 		Selector:      selector,
+		cfg:           cfg,
+		oracle:        oracle,
+		files:         make(map[string]struct{}),
 	}
-	for _, v := range cfg.VODs {
-		c, err := cache.NewCache(v.StorageSize)
+	for _, v := range cfg.Parents {
+		adm, err := cache.NewAdmission(v.CacheAdmission, v.StorageSize, v.AdmissionMaxSize)
+		if err != nil {
+			return nil, err
+		}
+		c, err := cache.NewCache(v.StorageSize, v.CachePolicy, adm, oracle)
 		if err != nil {
 			return nil, err
 		}
-		l.Caches[vod.Key(v.VodID)] = c
-		l.VODs[vod.Key(v.VodID)] = &vod.VOD{LimitSessionCount: v.LimitSession, LimitBps: v.LimitBps}
+		l.Parents[v.ParentID] = &Parent{Cache: c}
+	}
+	for _, v := range cfg.VODs {
+		if err := l.addVOD(v); err != nil {
+			return nil, err
+		}
 	}
 
-	l.Selector.Init(cfg)
+	if err := l.Selector.Init(cfg); err != nil {
+		return nil, err
+	}
 	return l, nil
 }
 
+func (lb *LB) addVOD(v data.VODConfig) error {
+	adm, err := cache.NewAdmission(v.CacheAdmission, v.StorageSize, v.AdmissionMaxSize)
+	if err != nil {
+		return err
+	}
+	c, err := cache.NewCache(v.StorageSize, v.CachePolicy, adm, lb.oracle)
+	if err != nil {
+		return err
+	}
+	if v.Parent != "" {
+		if _, ok := lb.Parents[v.Parent]; !ok {
+			return fmt.Errorf("not exists parent %v of vod %v", v.Parent, v.VodID)
+		}
+		lb.vodParentMap[vod.Key(v.VodID)] = v.Parent
+	}
+	lb.Caches[vod.Key(v.VodID)] = c
+	lb.VODs[vod.Key(v.VodID)] = &vod.VOD{LimitSessionCount: v.LimitSession, LimitBps: v.LimitBps}
+	return nil
+}
+
 // GetVODs :
 func (lb *LB) GetVODs() map[vod.Key]*vod.VOD {
 	return lb.VODs
 }
 
+// SessionVOD : session 을 처리 중인 VOD
+func (lb *LB) SessionVOD(sid string) (vod.Key, bool) {
+	k, ok := lb.vodSessionMap[sid]
+	return k, ok
+}
+
 // SelectVOD :
 func (lb *LB) SelectVOD(evt *data.SessionEvent) (vod.Key, error) {
 	if len(lb.VODs) != len(lb.Caches) || len(lb.VODs) == 0 || len(lb.Caches) == 0 {
@@ -68,22 +163,68 @@ func (lb *LB) Status(t time.Time) *status.Status {
 	return lb.MakeStatus(t)
 }
 
+// DownVOD : 장애 VOD 는 session 선택에서 제외, 처리 중인 session 은 호출한 쪽에서 종료하거나 이전해야 함
+func (lb *LB) DownVOD(key vod.Key) error {
+	v, ok := lb.VODs[key]
+	if !ok {
+		return fmt.Errorf("not exists vod %v", key)
+	}
+	v.Down = true
+	return nil
+}
+
+// UpVOD : wipeCache 이면 VOD 의 cache 를 비운 후 복구
+func (lb *LB) UpVOD(key vod.Key, wipeCache bool) error {
+	v, ok := lb.VODs[key]
+	if !ok {
+		return fmt.Errorf("not exists vod %v", key)
+	}
+	if wipeCache {
+		lb.Caches[key].Clear()
+	}
+	v.Down = false
+	return nil
+}
+
+// Sessions : VOD 에서 처리 중인 session id 목록
+func (lb *LB) Sessions(key vod.Key) []string {
+	var ret []string
+	for sid, k := range lb.vodSessionMap {
+		if k == key {
+			ret = append(ret, sid)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
 // StartSession :
 func (lb *LB) StartSession(evt *data.SessionEvent) error {
 	key, err := lb.SelectVOD(evt)
-	if err != nil {
+	if isRejectReason(err) {
+		return lb.rejecter.reject(evt, err)
+	} else if err != nil {
 		return fmt.Errorf("failed to select VOD, %v", err)
 	}
 	err = lb.VODs[key].StartSession(evt)
-	if err != nil {
+	if isRejectReason(err) {
+		return lb.rejecter.reject(evt, err)
+	} else if err != nil {
 		return fmt.Errorf("failed to start session in VOD, %v", err)
 	}
 	lb.vodSessionMap[evt.SessionID] = key
+	if _, ok := lb.Selector.(Owner); ok {
+		lb.files[evt.FileName] = struct{}{}
+	}
 	return nil
 }
 
 // EndSession :
 func (lb *LB) EndSession(evt *data.SessionEvent) error {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.endSession(evt)
+		return nil
+	}
 	key, ok := lb.vodSessionMap[evt.SessionID]
 	if !ok {
 		return fmt.Errorf("not exists session %v", evt.SessionID)
@@ -97,62 +238,148 @@ func (lb *LB) EndSession(evt *data.SessionEvent) error {
 }
 
 // StartChunk :
-func (lb *LB) StartChunk(evt *data.ChunkEvent) (useOrigin bool, err error) {
+func (lb *LB) StartChunk(evt *data.ChunkEvent) (ChunkSource, error) {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.startChunk(evt)
+		return SourceOrigin, nil
+	}
 	key, ok := lb.vodSessionMap[evt.SessionID]
 	if !ok {
-		return false, fmt.Errorf("not exists session %v", evt.SessionID)
+		return SourceVOD, fmt.Errorf("not exists session %v", evt.SessionID)
 	}
-	useOrigin, err = lb.Caches[key].StartChunk(evt)
+	useOrigin, err := lb.Caches[key].StartChunk(evt)
 	if err != nil {
-		return false, fmt.Errorf("failed to start chunk in cache, %v", err)
+		return SourceVOD, fmt.Errorf("failed to start chunk in cache, %v", err)
 	}
-	return useOrigin, err
+	if !useOrigin {
+		return SourceVOD, nil
+	}
+	return lb.startParentChunk(key, evt)
+}
+
+// startParentChunk : VOD 의 cache miss 를 parent 에 요청, parent 가 없으면 origin 에서 처리
+func (lb *LB) startParentChunk(key vod.Key, evt *data.ChunkEvent) (ChunkSource, error) {
+	id, ok := lb.vodParentMap[key]
+	if !ok {
+		return SourceOrigin, nil
+	}
+	p := lb.Parents[id]
+	useOrigin, err := p.Cache.StartChunk(evt)
+	if err != nil {
+		return SourceVOD, fmt.Errorf("failed to start chunk in parent cache, %v", err)
+	}
+	p.InBps += evt.Bps
+	lb.parentChunks[evt.SessionID] = useOrigin
+	if useOrigin {
+		return SourceOrigin, nil
+	}
+	return SourceParent, nil
 }
 
 // EndChunk :
-func (lb *LB) EndChunk(evt *data.ChunkEvent, useOrigin bool) error {
+func (lb *LB) EndChunk(evt *data.ChunkEvent, src ChunkSource) error {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.endChunk(evt)
+		return nil
+	}
 	key, ok := lb.vodSessionMap[evt.SessionID]
 	if !ok {
 		return fmt.Errorf("not exists session %v", evt.SessionID)
 	}
-	err := lb.Caches[key].EndChunk(evt, useOrigin)
+	err := lb.Caches[key].EndChunk(evt, src.VODMiss())
 	if err != nil {
 		return fmt.Errorf("failed to end chunk in cache, %v", err)
 	}
+	if src.VODMiss() {
+		return lb.endParentChunk(key, evt)
+	}
 	return nil
 }
 
+func (lb *LB) endParentChunk(key vod.Key, evt *data.ChunkEvent) error {
+	id, ok := lb.vodParentMap[key]
+	if !ok {
+		return nil
+	}
+	useOrigin, ok := lb.parentChunks[evt.SessionID]
+	if !ok {
+		return fmt.Errorf("not exists parent chunk of session %v", evt.SessionID)
+	}
+	delete(lb.parentChunks, evt.SessionID)
+	p := lb.Parents[id]
+	p.InBps -= evt.Bps
+	if err := p.Cache.EndChunk(evt, useOrigin); err != nil {
+		return fmt.Errorf("failed to end chunk in parent cache, %v", err)
+	}
+	return nil
+}
+
+// OriginBps : origin 에 요청되는 bps, parent 가 없는 VOD 와 parent 의 cache miss, overflow session 의 합
+func (lb *LB) OriginBps() int64 {
+	originBps := lb.rejecter.status.OverflowBps
+	for k, v := range lb.Caches {
+		if _, ok := lb.vodParentMap[k]; !ok {
+			originBps += v.OriginBps
+		}
+	}
+	for _, v := range lb.Parents {
+		originBps += v.Cache.OriginBps
+	}
+	return originBps
+}
+
 // MakeStatus :
 func (lb *LB) MakeStatus(t time.Time) *status.Status {
 	st := &status.Status{
-		Time:   t,
-		Origin: &status.OriginStatus{},
-		Vods:   make(map[vod.Key]*status.VODStatus),
-		Caches: make(map[vod.Key]*status.CacheStatus),
+		Time:    t,
+		Origin:  &status.OriginStatus{},
+		Vods:    make(map[vod.Key]*status.VODStatus),
+		Caches:  make(map[vod.Key]*status.CacheStatus),
+		Parents: make(map[string]*status.ParentStatus),
+		Reject:  lb.rejecter.makeStatus(),
 	}
-	originBps := int64(0)
 	allCacheFull := true
 	for k, v := range lb.Caches {
-		originBps += v.OriginBps
-
 		st.Caches[k] = &status.CacheStatus{
 			VODKey:         string(k),
 			CacheHitCount:  v.HitCount,
 			CacheMissCount: v.MissCount,
 			OriginBps:      v.OriginBps,
 			CurSize:        v.CurSize,
+			AdmitCount:     v.AdmitCount,
+			RejectCount:    v.RejectCount,
 		}
 		if !v.IsCacheFull {
 			allCacheFull = false
 		}
 	}
+	for k, v := range lb.Parents {
+		st.Parents[k] = &status.ParentStatus{
+			ParentID:       k,
+			CacheHitCount:  v.Cache.HitCount,
+			CacheMissCount: v.Cache.MissCount,
+			InBps:          v.InBps,
+			OriginBps:      v.Cache.OriginBps,
+			CurSize:        v.Cache.CurSize,
+		}
+		if !v.Cache.IsCacheFull {
+			allCacheFull = false
+		}
+	}
 	st.AllCacheFull = allCacheFull
-	st.Origin.Bps = originBps
+	st.Origin.Bps = lb.OriginBps()
+	if r, ok := lb.Selector.(ReplicationReporter); ok {
+		st.Replication = r.ReplicationStatus()
+	}
+	if lb.topology.ChangeCount > 0 {
+		st.Topology = lb.makeTopologyStatus()
+	}
 	for k, v := range lb.VODs {
 		st.Vods[k] = &status.VODStatus{
 			VODKey:          string(k),
 			CurBps:          v.CurBps,
 			CurSessionCount: v.CurSessionCount,
+			Down:            v.Down,
 		}
 	}
 	return st
@@ -1,7 +1,12 @@
 package lb
 
 import (
+	"fmt"
 	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
 )
 
 // EventTypeEnum :
@@ -330,3 +335,143 @@ func TestLB_OneVOD(t *testing.T) {
 	// 	}
 	// }
 }
+
+func TestLB_Parent(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "v1", StorageSize: 100, LimitSession: 10, LimitBps: 100, Parent: "p1"},
+			data.VODConfig{VodID: "v2", StorageSize: 100, LimitSession: 10, LimitBps: 100, Parent: "p1"},
+		},
+		Parents: []data.ParentConfig{data.ParentConfig{ParentID: "p1", StorageSize: 100}},
+	}
+	l, err := New(cfg, &SameHashingWeight{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := l.(*LB)
+
+	chunk := func(sid string, k string, expect ChunkSource) {
+		lb.vodSessionMap[sid] = vod.Key(k)
+		evt := &data.ChunkEvent{SessionID: sid, IntFileName: 1, ChunkSize: 10, Bps: 1}
+		src, err := lb.StartChunk(evt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src != expect {
+			t.Errorf("%v: chunk source(%v), expected(%v)", sid, src, expect)
+		}
+		if st := lb.MakeStatus(time.Time{}); src.VODMiss() && st.Parents["p1"].InBps != 1 {
+			t.Errorf("parent in-bps(%v)", st.Parents["p1"].InBps)
+		}
+		if err := lb.EndChunk(evt, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chunk("s1", "v1", SourceOrigin) // v1 miss, p1 miss
+	chunk("s2", "v2", SourceParent) // v2 miss, p1 hit
+	chunk("s3", "v2", SourceVOD)    // v2 hit
+
+	st := lb.MakeStatus(time.Time{})
+	p := st.Parents["p1"]
+	if p.CacheHitCount != 1 || p.CacheMissCount != 1 {
+		t.Errorf("parent hit(%v) miss(%v)", p.CacheHitCount, p.CacheMissCount)
+	}
+	if p.InBps != 0 || p.OriginBps != 0 || st.Origin.Bps != 0 {
+		t.Errorf("in-bps(%v) parent origin-bps(%v) origin-bps(%v)", p.InBps, p.OriginBps, st.Origin.Bps)
+	}
+	if c := st.Caches["v2"]; c.CacheHitCount != 1 || c.CacheMissCount != 1 {
+		t.Errorf("v2 hit(%v) miss(%v)", c.CacheHitCount, c.CacheMissCount)
+	}
+
+	cfg.VODs[0].Parent = "p2"
+	if _, err := New(cfg, &SameHashingWeight{}); err == nil {
+		t.Errorf("not exists parent must be failed")
+	}
+}
+
+// failInitSelector : fail 이면 Init 실패
+type failInitSelector struct {
+	SameHashingWeight
+	fail bool
+}
+
+func (s *failInitSelector) Init(cfg data.Config) error {
+	if s.fail {
+		return fmt.Errorf("init failed")
+	}
+	return s.SameHashingWeight.Init(cfg)
+}
+
+func TestLB_RemoveVOD(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "v1", StorageSize: 100, LimitSession: 10, LimitBps: 100},
+			data.VODConfig{VodID: "v2", StorageSize: 100, LimitSession: 10, LimitBps: 100},
+		},
+	}
+	s := &failInitSelector{}
+	l, err := New(cfg, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := l.(*LB)
+
+	// selector 초기화에 실패하면 VOD, cache 는 그대로
+	s.fail = true
+	if err := lb.RemoveVOD("v2"); err == nil {
+		t.Errorf("remove must be failed")
+	}
+	if _, ok := lb.VODs["v2"]; !ok {
+		t.Errorf("v2 must not be removed")
+	}
+	if _, ok := lb.Caches["v2"]; !ok || len(lb.Config().VODs) != 2 {
+		t.Errorf("cache, cfg of v2 must not be removed")
+	}
+
+	s.fail = false
+	if err := lb.RemoveVOD("v2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lb.VODs["v2"]; ok || len(lb.Config().VODs) != 1 {
+		t.Errorf("v2 must be removed")
+	}
+	if owner := s.Owner("a.mpg"); owner != "v1" {
+		t.Errorf("owner must be v1, %v", owner)
+	}
+}
+
+func TestLB_AddVOD_RemappedFiles(t *testing.T) {
+	vodCfg := func(id string) data.VODConfig {
+		return data.VODConfig{VodID: id, StorageSize: 100, LimitSession: 1000, LimitBps: 1000000}
+	}
+	cfg := data.Config{VODs: []data.VODConfig{vodCfg("v1"), vodCfg("v2")}}
+	l, err := New(cfg, &SameHashingWeight{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := l.(*LB)
+	var files []string
+	for i := 0; i < 300; i++ {
+		f := fmt.Sprintf("%d.mpg", i)
+		files = append(files, f)
+		evt := &data.SessionEvent{SessionID: fmt.Sprintf("s%d", i), FileName: f, IntFileName: i, Bps: 1}
+		if err := lb.StartSession(evt); err != nil {
+			t.Fatal(err)
+		}
+		if err := lb.EndSession(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lb.AddVOD(vodCfg("v3")); err != nil {
+		t.Fatal(err)
+	}
+
+	before, after := &SameHashingWeight{}, &SameHashingWeight{}
+	before.Init(cfg)
+	after.Init(lb.Config())
+	moved := MovedOwners(files, before, after)
+	tp := lb.MakeStatus(time.Time{}).Topology
+	if moved == 0 || tp.RemappedFileCount != int64(moved) || tp.TrackedFileCount != 300 {
+		t.Errorf("remapped files must be moved owners(%v), %+v", moved, *tp)
+	}
+}
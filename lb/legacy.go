@@ -16,6 +16,7 @@ type LegacyLB struct {
 	HitCount      int64
 	MissCount     int64
 	OriginBps     int64
+	rejecter      sessionRejecter
 }
 
 // NewLegacyLB :
@@ -24,6 +25,7 @@ func NewLegacyLB(cfg data.Config, selector VODSelector) (LoadBalancer, error) {
 	l := &LegacyLB{
 		VODs:          make(map[vod.Key]*vod.VOD),
 		vodSessionMap: make(map[string]vod.Key),
+		rejecter:      newSessionRejecter(cfg.Overflow),
 	}
 
 	// 1개의 VOD만 있다고 가정
@@ -43,6 +45,12 @@ func (lb *LegacyLB) GetVODs() map[vod.Key]*vod.VOD {
 	return lb.VODs
 }
 
+// SessionVOD : session 을 처리 중인 VOD
+func (lb *LegacyLB) SessionVOD(sid string) (vod.Key, bool) {
+	k, ok := lb.vodSessionMap[sid]
+	return k, ok
+}
+
 // Status :
 func (lb *LegacyLB) Status(t time.Time) *status.Status {
 	return lb.MakeStatus(t)
@@ -58,7 +66,9 @@ func (lb *LegacyLB) StartSession(evt *data.SessionEvent) error {
 	}
 
 	err := lb.VODs[firstK].StartSession(evt)
-	if err != nil {
+	if isRejectReason(err) {
+		return lb.rejecter.reject(evt, err)
+	} else if err != nil {
 		return fmt.Errorf("failed to start session in VOD, %v", err)
 	}
 	lb.vodSessionMap[evt.SessionID] = firstK
@@ -67,6 +77,10 @@ func (lb *LegacyLB) StartSession(evt *data.SessionEvent) error {
 
 // EndSession :
 func (lb *LegacyLB) EndSession(evt *data.SessionEvent) error {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.endSession(evt)
+		return nil
+	}
 	key, ok := lb.vodSessionMap[evt.SessionID]
 	if !ok {
 		return fmt.Errorf("not exists session %v", evt.SessionID)
@@ -80,23 +94,31 @@ func (lb *LegacyLB) EndSession(evt *data.SessionEvent) error {
 }
 
 // StartChunk :
-func (lb *LegacyLB) StartChunk(evt *data.ChunkEvent) (useOrigin bool, err error) {
+func (lb *LegacyLB) StartChunk(evt *data.ChunkEvent) (ChunkSource, error) {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.startChunk(evt)
+		return SourceOrigin, nil
+	}
 	_, ok := lb.vodSessionMap[evt.SessionID]
 	if !ok {
-		return false, fmt.Errorf("not exists session %v", evt.SessionID)
+		return SourceVOD, fmt.Errorf("not exists session %v", evt.SessionID)
 	}
 
 	if evt.IsCenter {
 		lb.MissCount++
 		lb.OriginBps += evt.Bps
-	} else {
-		lb.HitCount++
+		return SourceOrigin, nil
 	}
-	return evt.IsCenter, nil
+	lb.HitCount++
+	return SourceVOD, nil
 }
 
 // EndChunk :
-func (lb *LegacyLB) EndChunk(evt *data.ChunkEvent, useOrigin bool) error {
+func (lb *LegacyLB) EndChunk(evt *data.ChunkEvent, src ChunkSource) error {
+	if lb.rejecter.isOverflow(evt.SessionID) {
+		lb.rejecter.endChunk(evt)
+		return nil
+	}
 	_, ok := lb.vodSessionMap[evt.SessionID]
 	if !ok {
 		return fmt.Errorf("not exists session %v", evt.SessionID)
@@ -114,9 +136,10 @@ func (lb *LegacyLB) MakeStatus(t time.Time) *status.Status {
 		Origin: &status.OriginStatus{},
 		Vods:   make(map[vod.Key]*status.VODStatus),
 		Caches: make(map[vod.Key]*status.CacheStatus),
+		Reject: lb.rejecter.makeStatus(),
 	}
 	st.AllCacheFull = true
-	st.Origin.Bps = lb.OriginBps
+	st.Origin.Bps = lb.OriginBps + st.Reject.OverflowBps
 	for k, v := range lb.VODs {
 		st.Vods[k] = &status.VODStatus{
 			VODKey:          string(k),
@@ -0,0 +1,136 @@
+package lb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+	"github.com/castisdev/gcommon/consistenthash"
+)
+
+// FilePlacement : filebase 에서 file 을 저장할 VOD 결정
+// storage 크기를 weight 로 하는 consistent hash 순서로 처음 replica 수 만큼의 VOD 에 저장
+// 인기 순위 replicaRank 이내의 file 은 replicas 개, 나머지는 1개 VOD 에 저장
+type FilePlacement struct {
+	hash         *consistenthash.Map
+	fileInfos    *data.FileInfos
+	hitRanker    Ranker
+	replicas     int
+	replicaRank  int
+	updatePeriod time.Duration
+	updatedT     time.Time
+	hotFiles     map[int]struct{}
+	items        map[int][]vod.Key // file 별 hash 순서
+}
+
+// NewFilePlacement :
+func NewFilePlacement(cfg data.Config, fi *data.FileInfos, statDuration, shiftPeriod, updatePeriod time.Duration,
+	replicas, replicaRank int) *FilePlacement {
+	hash := consistenthash.New(100, nil)
+	keyMap := make(map[string]int)
+	for _, v := range cfg.VODs {
+		gb := int64(1024 * 1024 * 1024)
+		hashWeight := int(v.StorageSize / (100 * gb))
+		if hashWeight <= 0 {
+			hashWeight = 1
+		}
+		keyMap[v.VodID] = hashWeight
+	}
+	hash.Add(keyMap)
+	if replicas <= 0 {
+		replicas = 1
+	}
+	if replicas > len(cfg.VODs) {
+		replicas = len(cfg.VODs)
+	}
+	p := &FilePlacement{
+		hash:         hash,
+		fileInfos:    fi,
+		replicas:     replicas,
+		replicaRank:  replicaRank,
+		updatePeriod: updatePeriod,
+		hotFiles:     make(map[int]struct{}),
+		items:        make(map[int][]vod.Key),
+	}
+	if replicas > 1 {
+		p.hitRanker = NewHitRanker(statDuration, shiftPeriod, fi, false, false, false)
+	}
+	fmt.Printf("file placement: vods(%v) replicas(%v) replicaRank(%v)\n", len(cfg.VODs), replicas, replicaRank)
+	return p
+}
+
+// UpdateStart :
+func (p *FilePlacement) UpdateStart(evt *data.SessionEvent) {
+	if p.hitRanker == nil {
+		return
+	}
+	p.hitRanker.UpdateStart(evt)
+	if p.updatedT.IsZero() {
+		p.updatedT = evt.Time
+	} else if evt.Time.Sub(p.updatedT) >= p.updatePeriod {
+		p.updatedT = evt.Time
+		p.updateHotFiles()
+	}
+}
+
+// UpdateEnd :
+func (p *FilePlacement) UpdateEnd(evt *data.SessionEvent) {
+	if p.hitRanker != nil {
+		p.hitRanker.UpdateEnd(evt)
+	}
+}
+
+func (p *FilePlacement) updateHotFiles() {
+	hot := make(map[int]struct{})
+	for i, v := range p.hitRanker.HitList(nil) {
+		if i >= p.replicaRank {
+			break
+		}
+		hot[v.filename] = struct{}{}
+	}
+	p.hotFiles = hot
+}
+
+// Replicas : file 을 저장할 VOD 수
+func (p *FilePlacement) Replicas(file int) int {
+	if _, ok := p.hotFiles[file]; ok {
+		return p.replicas
+	}
+	return 1
+}
+
+// Items : file 의 hash 순서 VOD 목록
+func (p *FilePlacement) Items(file int) []vod.Key {
+	if v, ok := p.items[file]; ok {
+		return v
+	}
+	var keys []vod.Key
+	for _, v := range p.hash.GetItems(p.fileInfos.Info(file).File) {
+		keys = append(keys, vod.Key(v))
+	}
+	p.items[file] = keys
+	return keys
+}
+
+// Owns : VOD 가 file 을 저장해야 하는지
+func (p *FilePlacement) Owns(k vod.Key, file int) bool {
+	items := p.Items(file)
+	n := p.Replicas(file)
+	if n > len(items) {
+		n = len(items)
+	}
+	for _, v := range items[:n] {
+		if v == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Owner : k VOD 의 storage 에 사용할 owns 함수
+func (p *FilePlacement) Owner(k vod.Key) func(file int) bool {
+	return func(file int) bool {
+		return p.Owns(k, file)
+	}
+}
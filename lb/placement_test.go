@@ -0,0 +1,99 @@
+package lb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+func TestFilePlacement(t *testing.T) {
+	str := `
+1,a.mpg,6000000,500000000,2016-02-01T00:00:00
+2,b.mpg,6000000,500000000,2016-01-01T00:00:00`
+	fi, err := data.NewFileInfos(strings.NewReader(str))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "v1", StorageSize: 1000000000, LimitSession: 1, LimitBps: 100000000},
+			data.VODConfig{VodID: "v2", StorageSize: 1000000000, LimitSession: 1, LimitBps: 100000000},
+		},
+	}
+	updatePeriod := 5 * time.Minute
+	p := NewFilePlacement(cfg, fi, 24*time.Hour, time.Hour, updatePeriod, 2, 1)
+	storages := make(map[vod.Key]Storage)
+	for _, v := range cfg.VODs {
+		storages[vod.Key(v.VodID)] = NewIdealStorage(updatePeriod, 24*time.Hour, time.Hour, v.StorageSize,
+			fi, p.Owner(vod.Key(v.VodID)), false, false, false)
+	}
+	l, err := NewFilebaseLB(cfg, NewFileBase(storages, p))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evtFn := func(file, strTime, sid string) *data.SessionEvent {
+		return &data.SessionEvent{
+			FileName:    file,
+			SessionID:   sid,
+			Time:        StrToTime(strTime),
+			IntFileName: fi.IntName(file),
+			Bps:         6000000,
+			Duration:    time.Minute,
+		}
+	}
+	for i, v := range []*data.SessionEvent{
+		evtFn("a.mpg", "2017-01-01 00:00:00", "s1"),
+		evtFn("a.mpg", "2017-01-01 00:00:01", "s2"),
+		evtFn("b.mpg", "2017-01-01 00:00:02", "s3"),
+	} {
+		if err := l.StartSession(v); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.EndSession(v); err != nil {
+			t.Fatal(err)
+		}
+		if n := l.Status(v.Time).Origin.MissSessionCount; n != int64(i+1) {
+			t.Errorf("no file count %v != %v", n, i+1)
+		}
+	}
+
+	// update period 이후 인기 1위 a.mpg 는 2개 VOD, b.mpg 는 1개 VOD 에 저장
+	s4 := evtFn("a.mpg", "2017-01-01 00:05:01", "s4")
+	if err := l.StartSession(s4); err != nil {
+		t.Fatal(err)
+	}
+	if p.Replicas(1) != 2 || p.Replicas(2) != 1 {
+		t.Errorf("replicas a(%v) b(%v)", p.Replicas(1), p.Replicas(2))
+	}
+	aCount, bCount := 0, 0
+	for _, v := range storages {
+		if v.Exists(1) {
+			aCount++
+		}
+		if v.Exists(2) {
+			bCount++
+		}
+	}
+	if aCount != 2 || bCount != 1 {
+		t.Errorf("stored vods a(%v) b(%v)", aCount, bCount)
+	}
+
+	// VOD 별 session limit 1, 두번째 session 은 다른 replica 로
+	s5 := evtFn("a.mpg", "2017-01-01 00:05:02", "s5")
+	if err := l.StartSession(s5); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range l.GetVODs() {
+		if v.CurSessionCount != 1 {
+			t.Errorf("%v session %v != 1", k, v.CurSessionCount)
+		}
+	}
+	s6 := evtFn("a.mpg", "2017-01-01 00:05:03", "s6")
+	if err := l.StartSession(s6); err != ErrRejected {
+		t.Errorf("session must be rejected when all replicas are full, %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package lb
+
+import (
+	"errors"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// ErrRejected : session 이 거부됨, 해당 session 의 chunk, end event 는 처리하지 않아야 함
+var ErrRejected = errors.New("session rejected")
+
+// sessionRejecter : VOD 가 처리하지 못한 session 을 reason 별로 기록
+// overflow 이면 거부하지 않고 VOD 를 거치지 않고 origin 에서 직접 처리
+type sessionRejecter struct {
+	overflow bool
+	sessions map[string]struct{} // overflow 로 처리 중인 session
+	status   status.RejectStatus
+}
+
+func newSessionRejecter(overflow bool) sessionRejecter {
+	return sessionRejecter{overflow: overflow, sessions: make(map[string]struct{})}
+}
+
+// reject : reason 은 vod.ErrSessionLimit, vod.ErrBpsLimit, vod.ErrDown 중 하나
+func (r *sessionRejecter) reject(evt *data.SessionEvent, reason error) error {
+	switch reason {
+	case vod.ErrSessionLimit:
+		r.status.SessionLimitCount++
+	case vod.ErrBpsLimit:
+		r.status.BpsLimitCount++
+	case vod.ErrDown:
+		r.status.VODDownCount++
+	}
+	if !r.overflow {
+		return ErrRejected
+	}
+	r.sessions[evt.SessionID] = struct{}{}
+	r.status.OverflowSessionCount++
+	r.status.OverflowTotalCount++
+	return nil
+}
+
+func (r *sessionRejecter) isOverflow(sid string) bool {
+	_, ok := r.sessions[sid]
+	return ok
+}
+
+func (r *sessionRejecter) startChunk(evt *data.ChunkEvent) {
+	r.status.OverflowBps += evt.Bps
+}
+
+func (r *sessionRejecter) endChunk(evt *data.ChunkEvent) {
+	r.status.OverflowBps -= evt.Bps
+}
+
+func (r *sessionRejecter) endSession(evt *data.SessionEvent) {
+	delete(r.sessions, evt.SessionID)
+	r.status.OverflowSessionCount--
+}
+
+func (r *sessionRejecter) makeStatus() *status.RejectStatus {
+	st := r.status
+	return &st
+}
+
+func isRejectReason(err error) bool {
+	return err == vod.ErrSessionLimit || err == vod.ErrBpsLimit || err == vod.ErrDown
+}
@@ -0,0 +1,117 @@
+package lb
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+// rendezvous hashing weight 기준
+const (
+	HRWWeightSame     = "same"
+	HRWWeightStorage  = "storage"
+	HRWWeightBps      = "bps"
+	HRWWeightExplicit = "weight" // VODConfig.HashWeight
+)
+
+type rendezvousVOD struct {
+	key    vod.Key
+	weight float64
+}
+
+// Rendezvous : weighted rendezvous(HRW) hashing
+// file, VOD 별 score = weight / -ln(hash) 가 높은 VOD 순으로 선택
+type Rendezvous struct {
+	weightBy string
+	vods     []rendezvousVOD
+}
+
+// NewRendezvous : weightBy 는 HRWWeightSame, HRWWeightStorage, HRWWeightBps, HRWWeightExplicit 중 하나
+func NewRendezvous(weightBy string) VODSelector {
+	return &Rendezvous{weightBy: weightBy}
+}
+
+// Init :
+func (s *Rendezvous) Init(cfg data.Config) error {
+	s.vods = nil
+	for _, v := range cfg.VODs {
+		var w float64
+		switch s.weightBy {
+		case HRWWeightSame:
+			w = 1
+		case HRWWeightStorage:
+			w = float64(v.StorageSize)
+		case HRWWeightBps:
+			w = float64(v.LimitBps)
+		case HRWWeightExplicit:
+			w = v.HashWeight
+		default:
+			return fmt.Errorf("invalid hrw weight %v", s.weightBy)
+		}
+		if w <= 0 {
+			return fmt.Errorf("invalid hrw weight(%v) of %v", w, v.VodID)
+		}
+		s.vods = append(s.vods, rendezvousVOD{key: vod.Key(v.VodID), weight: w})
+		fmt.Printf("%s: hrw-weight(%v)\n", v.VodID, w)
+	}
+	return nil
+}
+
+// Items : score 가 높은 VOD 순
+func (s *Rendezvous) Items(file string) []string {
+	scores := make([]float64, len(s.vods))
+	idx := make([]int, len(s.vods))
+	for i, v := range s.vods {
+		scores[i] = v.weight / -math.Log(hashUnit(file, string(v.key)))
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return scores[idx[i]] > scores[idx[j]] })
+	ret := make([]string, len(idx))
+	for i, v := range idx {
+		ret[i] = string(s.vods[v].key)
+	}
+	return ret
+}
+
+// Owner :
+func (s *Rendezvous) Owner(file string) vod.Key {
+	var owner vod.Key
+	max := -1.0
+	for _, v := range s.vods {
+		score := v.weight / -math.Log(hashUnit(file, string(v.key)))
+		if score > max {
+			max = score
+			owner = v.key
+		}
+	}
+	return owner
+}
+
+// VODSelect :
+func (s *Rendezvous) VODSelect(evt *data.SessionEvent, lb LoadBalancer) (vod.Key, error) {
+	return SelectAvailableFirst(evt, lb, s.Items(evt.FileName))
+}
+
+// EndSession :
+func (s *Rendezvous) EndSession(evt *data.SessionEvent) {
+}
+
+// hashUnit : (0, 1) 범위의 hash 값
+func hashUnit(file, vodID string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(file))
+	h.Write([]byte{0})
+	h.Write([]byte(vodID))
+	// fnv 는 마지막 byte 차이가 상위 bit 에 고르게 퍼지지 않으므로 섞어서 사용 (murmur3 fmix64)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return (float64(x>>11) + 0.5) / (1 << 53)
+}
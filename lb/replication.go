@@ -0,0 +1,146 @@
+package lb
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// Replication : updatePeriod 마다 ranker 의 hit 에 비례하여 file 별 replica 수를 정하고
+// consistent hash 순서로 처음 replica 수 만큼의 VOD 에 배치, session 은 replica VOD 중 부하가 가장 작은 VOD 선택
+// 인기 1위 file 의 replica 수가 maxReplicas, 나머지는 ceil(maxReplicas * hit / 1위 hit)
+// replica VOD 가 모두 처리할 수 없으면 나머지 VOD 중 SelectAvailableFirst 로 선택
+type Replication struct {
+	SameHashingWeight
+	ranker       Ranker
+	fileInfos    *data.FileInfos
+	updatePeriod time.Duration
+	maxReplicas  int // 설정 값, VOD 수보다 크면 replica 수를 정할 때 VOD 수로 제한
+	vodCount     int
+	metric       string
+	updatedT     time.Time
+	replicas     map[int]int // replica 수가 1 보다 큰 file
+}
+
+// NewReplication : metric 은 LoadSession, LoadBps 중 하나
+func NewReplication(ranker Ranker, fi *data.FileInfos, updatePeriod time.Duration, maxReplicas int, metric string) VODSelector {
+	return &Replication{
+		ranker:       ranker,
+		fileInfos:    fi,
+		updatePeriod: updatePeriod,
+		maxReplicas:  maxReplicas,
+		metric:       metric,
+		replicas:     make(map[int]int),
+	}
+}
+
+// Init :
+func (s *Replication) Init(cfg data.Config) error {
+	if s.maxReplicas <= 0 {
+		return fmt.Errorf("invalid max replicas %v", s.maxReplicas)
+	}
+	if err := validLoadMetric(s.metric); err != nil {
+		return err
+	}
+	s.vodCount = len(cfg.VODs)
+	fmt.Printf("replication: update-period:%v max-replicas:%v metric:%v\n", s.updatePeriod, s.maxReplicas, s.metric)
+	return s.SameHashingWeight.Init(cfg)
+}
+
+// VODSelect :
+func (s *Replication) VODSelect(evt *data.SessionEvent, lb LoadBalancer) (vod.Key, error) {
+	s.ranker.UpdateStart(evt)
+	if s.updatedT.IsZero() {
+		s.updatedT = evt.Time
+	} else if evt.Time.Sub(s.updatedT) >= s.updatePeriod {
+		s.updatedT = evt.Time
+		s.updateReplicas()
+	}
+
+	vodKeys := s.hash.GetItems(evt.FileName)
+	n := s.Replicas(evt.IntFileName)
+	if n > len(vodKeys) {
+		n = len(vodKeys)
+	}
+	vods := lb.GetVODs()
+	var best vod.Key
+	var bestLoad float64
+	for _, k := range vodKeys[:n] {
+		v := vods[vod.Key(k)]
+		if v.Available(evt.Bps) != nil {
+			continue
+		}
+		cur, limit := vodLoad(v, s.metric)
+		l := float64(1)
+		if limit > 0 {
+			l = float64(cur) / float64(limit)
+		}
+		if best == "" || l < bestLoad {
+			best = vod.Key(k)
+			bestLoad = l
+		}
+	}
+	if best != "" {
+		return best, nil
+	}
+	return SelectAvailableFirst(evt, lb, vodKeys)
+}
+
+// EndSession :
+func (s *Replication) EndSession(evt *data.SessionEvent) {
+	s.ranker.UpdateEnd(evt)
+}
+
+func (s *Replication) updateReplicas() {
+	maxReplicas := s.maxReplicas
+	if maxReplicas > s.vodCount {
+		maxReplicas = s.vodCount
+	}
+	replicas := make(map[int]int)
+	var topHit int64
+	for _, v := range s.ranker.HitList(nil) {
+		if topHit == 0 {
+			topHit = v.hit
+		}
+		if topHit <= 0 {
+			break
+		}
+		n := int(math.Ceil(float64(maxReplicas) * float64(v.hit) / float64(topHit)))
+		// HitList 는 hit 순으로 정렬되어 있으므로 이후 file 은 모두 1개
+		if n <= 1 {
+			break
+		}
+		replicas[v.filename] = n
+	}
+	s.replicas = replicas
+}
+
+// Replicas : file 을 배치할 VOD 수
+func (s *Replication) Replicas(file int) int {
+	if n, ok := s.replicas[file]; ok {
+		return n
+	}
+	return 1
+}
+
+// ReplicationStatus : 추가 replica 로 인한 storage overhead, file info 에 없는 file 은 크기를 알 수 없어 overhead 에서 제외
+func (s *Replication) ReplicationStatus() *status.ReplicationStatus {
+	st := &status.ReplicationStatus{}
+	for k, n := range s.replicas {
+		st.ReplicatedFileCount++
+		st.ExtraReplicaCount += int64(n - 1)
+		if fi, ok := s.fileInfos.Infos[k]; ok {
+			st.OverheadSize += int64(n-1) * fi.Size
+		}
+	}
+	return st
+}
+
+// ReplicationReporter : replica 상태를 보고하는 selector
+type ReplicationReporter interface {
+	ReplicationStatus() *status.ReplicationStatus
+}
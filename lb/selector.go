@@ -23,6 +23,35 @@ type VODSelector interface {
 	EndSession(evt *data.SessionEvent)
 }
 
+// load metrics
+const (
+	LoadSession = "session"
+	LoadBps     = "bps"
+)
+
+// vodLoad : metric 에 따른 VOD 의 현재 부하와 최대 부하
+func vodLoad(v *vod.VOD, metric string) (cur, limit int64) {
+	if metric == LoadBps {
+		return v.CurBps, v.LimitBps
+	}
+	return v.CurSessionCount, v.LimitSessionCount
+}
+
+// sessionLoad : metric 에 따른 session 하나의 부하
+func sessionLoad(evt *data.SessionEvent, metric string) int64 {
+	if metric == LoadBps {
+		return evt.Bps
+	}
+	return 1
+}
+
+func validLoadMetric(metric string) error {
+	if metric != LoadSession && metric != LoadBps {
+		return fmt.Errorf("invalid load metric %v", metric)
+	}
+	return nil
+}
+
 // SameHashingWeight :
 type SameHashingWeight struct {
 	hash *consistenthash.Map
@@ -52,18 +81,52 @@ func (s *SameHashingWeight) Init(cfg data.Config) error {
 func (s *SameHashingWeight) EndSession(evt *data.SessionEvent) {
 }
 
-// SelectAvailableFirst :
+// Owner : file 을 우선 처리할 VOD 를 정하는 hashing selector
+type Owner interface {
+	Owner(file string) vod.Key
+}
+
+// MovedOwners : before 와 after 의 owner 가 다른 file 수, VOD 추가/삭제/변경 시 이동하는 file 수 측정에 사용
+// LB 는 topology 변경마다 요청된 적 있는 file 로 측정하여 TopologyStatus.RemappedFileCount 로 보고
+func MovedOwners(files []string, before, after Owner) int {
+	moved := 0
+	for _, f := range files {
+		if before.Owner(f) != after.Owner(f) {
+			moved++
+		}
+	}
+	return moved
+}
+
+// Owner :
+func (s *SameHashingWeight) Owner(file string) vod.Key {
+	items := s.hash.GetItems(file)
+	if len(items) == 0 {
+		return ""
+	}
+	return vod.Key(items[0])
+}
+
+// SelectAvailableFirst : 처리 가능한 VOD 가 없으면 장애가 아닌 첫번째 VOD 가 처리할 수 없는 이유
+// (vod.ErrSessionLimit, vod.ErrBpsLimit) 반환, 모두 장애이면 vod.ErrDown 반환
 func SelectAvailableFirst(evt *data.SessionEvent, lb LoadBalancer, vodKeys []string) (vod.Key, error) {
+	var firstErr error
 	for _, v := range vodKeys {
 		k := vod.Key(v)
-		vod := lb.GetVODs()[k]
-		if vod.LimitSessionCount < vod.CurSessionCount+1 || vod.LimitBps < vod.CurBps+evt.Bps {
-			log.Printf("not available vod[%v], session(%v/%v) bps(%v/%v)",
-				k, vod.CurSessionCount, vod.LimitSessionCount, vod.CurBps, vod.LimitBps)
+		vd := lb.GetVODs()[k]
+		if err := vd.Available(evt.Bps); err != nil {
+			log.Printf("not available vod[%v], session(%v/%v) bps(%v/%v) down(%v)",
+				k, vd.CurSessionCount, vd.LimitSessionCount, vd.CurBps, vd.LimitBps, vd.Down)
+			if firstErr == nil || firstErr == vod.ErrDown {
+				firstErr = err
+			}
 			continue
 		}
 		return k, nil
 	}
+	if firstErr != nil {
+		return "", firstErr
+	}
 	return "", fmt.Errorf("failed to select vod")
 }
 
@@ -255,39 +318,54 @@ func (s *HighLowGroup) isHot(file int) bool {
 	return false
 }
 
-// FileBase :
+// FileBase : VOD 별 storage 에 file 이 있는 VOD 중 placement 의 hash 순서로 처리 가능한 VOD 선택
 type FileBase struct {
-	vodID   string
-	storage Storage
+	storages  map[vod.Key]Storage
+	placement *FilePlacement
 }
 
 // NewFileBase :
-func NewFileBase(s Storage) VODSelector {
-	return &FileBase{storage: s}
+func NewFileBase(storages map[vod.Key]Storage, placement *FilePlacement) VODSelector {
+	return &FileBase{storages: storages, placement: placement}
 }
 
 // Init :
 func (s *FileBase) Init(cfg data.Config) error {
-	fmt.Printf("filebase: storage:%v\n", s.storage.LimitSize())
 	for _, v := range cfg.VODs {
-		s.vodID = v.VodID
-		break
+		st, ok := s.storages[vod.Key(v.VodID)]
+		if !ok {
+			return fmt.Errorf("not exists storage of vod %v", v.VodID)
+		}
+		fmt.Printf("filebase: %s storage:%v\n", v.VodID, st.LimitSize())
 	}
 	return nil
 }
 
-// VODSelect :
+// VODSelect : file 이 있는 VOD 가 없으면 file 을 저장해야 할 첫번째 VOD 와 ErrFileNotFound 반환
 func (s *FileBase) VODSelect(evt *data.SessionEvent, lb LoadBalancer) (vod.Key, error) {
-	if err := s.storage.UpdateStart(evt); err != nil {
-		return "", err
+	s.placement.UpdateStart(evt)
+	for _, v := range s.storages {
+		if err := v.UpdateStart(evt); err != nil {
+			return "", err
+		}
 	}
-	if s.storage.Exists(evt.IntFileName) {
-		return vod.Key(s.vodID), nil
+	items := s.placement.Items(evt.IntFileName)
+	var vodKeys []string
+	for _, k := range items {
+		if s.storages[k].Exists(evt.IntFileName) {
+			vodKeys = append(vodKeys, string(k))
+		}
 	}
-	return "", ErrFileNotFound
+	if len(vodKeys) == 0 {
+		return items[0], ErrFileNotFound
+	}
+	return SelectAvailableFirst(evt, lb, vodKeys)
 }
 
 // EndSession :
 func (s *FileBase) EndSession(evt *data.SessionEvent) {
-	s.storage.UpdateEnd(evt)
+	s.placement.UpdateEnd(evt)
+	for _, v := range s.storages {
+		v.UpdateEnd(evt)
+	}
 }
@@ -0,0 +1,262 @@
+package lb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+func startSessions(t *testing.T, l *LB, n int, file string) {
+	for i := 0; i < n; i++ {
+		evt := &data.SessionEvent{SessionID: fmt.Sprintf("%s-%d", file, i), FileName: file, Bps: 1}
+		if err := l.StartSession(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBoundedHash(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "v1", StorageSize: 100, LimitSession: 100, LimitBps: 100},
+			data.VODConfig{VodID: "v2", StorageSize: 100, LimitSession: 100, LimitBps: 100},
+			data.VODConfig{VodID: "v3", StorageSize: 100, LimitSession: 200, LimitBps: 200},
+		},
+	}
+	if _, err := New(cfg, NewBoundedHash(-1, LoadSession)); err == nil {
+		t.Errorf("negative load factor must be failed")
+	}
+	if _, err := New(cfg, NewBoundedHash(0, "x")); err == nil {
+		t.Errorf("invalid load metric must be failed")
+	}
+
+	l, err := New(cfg, NewBoundedHash(0, LoadSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	startSessions(t, l.(*LB), 40, "a.mpg")
+	// load factor 0 이면 limit 비례로 분배
+	exp := map[vod.Key]int64{"v1": 10, "v2": 10, "v3": 20}
+	for k, v := range l.GetVODs() {
+		if v.CurSessionCount != exp[k] {
+			t.Errorf("%v session %v != %v", k, v.CurSessionCount, exp[k])
+		}
+	}
+
+	l, err = New(cfg, NewBoundedHash(10, LoadSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	startSessions(t, l.(*LB), 40, "a.mpg")
+	max := int64(0)
+	for _, v := range l.GetVODs() {
+		if v.CurSessionCount > max {
+			max = v.CurSessionCount
+		}
+	}
+	if max != 40 {
+		t.Errorf("all sessions must be served by first vod with large load factor, max(%v)", max)
+	}
+}
+
+func TestRendezvous(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "v1", StorageSize: 100, HashWeight: 1},
+			data.VODConfig{VodID: "v2", StorageSize: 100, HashWeight: 1},
+			data.VODConfig{VodID: "v3", StorageSize: 200, HashWeight: 2},
+		},
+	}
+	if err := NewRendezvous("x").Init(cfg); err == nil {
+		t.Errorf("invalid weight must be failed")
+	}
+	if err := NewRendezvous(HRWWeightBps).Init(cfg); err == nil {
+		t.Errorf("zero weight must be failed")
+	}
+
+	var files []string
+	for i := 0; i < 10000; i++ {
+		files = append(files, fmt.Sprintf("%d.mpg", i))
+	}
+	before := NewRendezvous(HRWWeightStorage).(*Rendezvous)
+	if err := before.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	owned := make(map[vod.Key]int)
+	for _, f := range files {
+		owned[before.Owner(f)]++
+		if items := before.Items(f); vod.Key(items[0]) != before.Owner(f) || len(items) != 3 {
+			t.Errorf("invalid items %v of %v", items, f)
+		}
+	}
+	// weight 비례 분배 (v1:v2:v3 = 1:1:2)
+	if owned["v3"] < 4700 || owned["v3"] > 5300 || owned["v1"] < 2200 || owned["v1"] > 2800 {
+		t.Errorf("not weighted distribution %v", owned)
+	}
+
+	// VOD 삭제 시 삭제된 VOD 의 file 만 이동
+	after := NewRendezvous(HRWWeightExplicit).(*Rendezvous)
+	if err := after.Init(data.Config{VODs: cfg.VODs[:2]}); err != nil {
+		t.Fatal(err)
+	}
+	if moved := MovedOwners(files, before, after); moved != owned["v3"] {
+		t.Errorf("moved(%v) != owned by removed vod(%v)", moved, owned["v3"])
+	}
+}
+
+func TestChoicesOfD(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "v1", StorageSize: 100, LimitSession: 100, LimitBps: 100},
+			data.VODConfig{VodID: "v2", StorageSize: 100, LimitSession: 100, LimitBps: 100},
+			data.VODConfig{VodID: "v3", StorageSize: 100, LimitSession: 300, LimitBps: 300},
+		},
+	}
+	if _, err := New(cfg, NewChoicesOfD(-1, LoadSession)); err == nil {
+		t.Errorf("negative choices must be failed")
+	}
+
+	l, err := New(cfg, NewChoicesOfD(1, LoadSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	startSessions(t, l.(*LB), 10, "a.mpg")
+	owner := vod.Key(l.(*LB).Selector.(*ChoicesOfD).hash.GetItems("a.mpg")[0])
+	if n := l.GetVODs()[owner].CurSessionCount; n != 10 {
+		t.Errorf("d=1 must select first hash vod, %v", n)
+	}
+
+	l, err = New(cfg, NewLeastConn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	startSessions(t, l.(*LB), 30, "a.mpg")
+	for k, v := range l.GetVODs() {
+		if v.CurSessionCount != 10 {
+			t.Errorf("least-conn %v session %v != 10", k, v.CurSessionCount)
+		}
+	}
+
+	l, err = New(cfg, NewLeastBps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	startSessions(t, l.(*LB), 50, "a.mpg")
+	exp := map[vod.Key]int64{"v1": 10, "v2": 10, "v3": 30}
+	for k, v := range l.GetVODs() {
+		if v.CurBps != exp[k] {
+			t.Errorf("least-bps %v bps %v != %v", k, v.CurBps, exp[k])
+		}
+	}
+}
+
+func TestReplication(t *testing.T) {
+	str := `
+1,a.mpg,1000000,100,2016-01-01T00:00:00
+2,b.mpg,1000000,200,2016-01-01T00:00:00`
+	fi, err := data.NewFileInfos(strings.NewReader(str))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "v1", StorageSize: 100, LimitSession: 100, LimitBps: 1000000000},
+			data.VODConfig{VodID: "v2", StorageSize: 100, LimitSession: 100, LimitBps: 1000000000},
+			data.VODConfig{VodID: "v3", StorageSize: 100, LimitSession: 100, LimitBps: 1000000000},
+		},
+	}
+	newSelector := func(maxReplicas int, metric string) VODSelector {
+		ranker := NewHitRanker(24*time.Hour, time.Hour, fi, false, false, false)
+		return NewReplication(ranker, fi, time.Minute, maxReplicas, metric)
+	}
+	if _, err := New(cfg, newSelector(0, LoadSession)); err == nil {
+		t.Errorf("zero max replicas must be failed")
+	}
+	if _, err := New(cfg, newSelector(3, "x")); err == nil {
+		t.Errorf("invalid load metric must be failed")
+	}
+
+	s := newSelector(3, LoadSession)
+	l, err := New(cfg, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := StrToTime("2017-01-01 00:00:00")
+	sid := 0
+	start := func(file string, ti time.Time) {
+		sid++
+		evt := &data.SessionEvent{SessionID: fmt.Sprintf("s%d", sid), FileName: file, IntFileName: fi.IntName(file),
+			Time: ti, Bps: 1000000, Duration: time.Hour}
+		if err := l.StartSession(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		start("a.mpg", t0)
+	}
+	for i := 0; i < 2; i++ {
+		start("b.mpg", t0)
+	}
+	used := 0
+	for _, v := range l.GetVODs() {
+		if v.CurSessionCount > 0 {
+			used++
+		}
+	}
+	if used > 2 {
+		t.Errorf("before update, each file must be served by one vod, used(%v)", used)
+	}
+	if l.Status(t0).Replication.ExtraReplicaCount != 0 {
+		t.Errorf("no replica before update")
+	}
+
+	// hit a(6) b(2), replicas a(3) b(ceil(3*2/6)=1)
+	for i := 0; i < 9; i++ {
+		start("a.mpg", t0.Add(time.Minute))
+	}
+	r := s.(*Replication)
+	if r.Replicas(1) != 3 || r.Replicas(2) != 1 {
+		t.Errorf("replicas a(%v) b(%v)", r.Replicas(1), r.Replicas(2))
+	}
+	for k, v := range l.GetVODs() {
+		if v.CurSessionCount == 0 {
+			t.Errorf("%v must serve replica of a.mpg", k)
+		}
+	}
+	st := l.Status(t0).Replication
+	if st.ReplicatedFileCount != 1 || st.ExtraReplicaCount != 2 || st.OverheadSize != 200 {
+		t.Errorf("replication status %+v", *st)
+	}
+
+	// file info 에 없는 file 은 overhead 에서 제외
+	r.replicas[99] = 2
+	st = l.Status(t0).Replication
+	if st.ReplicatedFileCount != 2 || st.ExtraReplicaCount != 3 || st.OverheadSize != 200 {
+		t.Errorf("replication status with unknown file %+v", *st)
+	}
+
+	// max replicas 는 VOD 수로 제한되고 VOD 가 늘어나면 다시 늘어남
+	s = newSelector(3, LoadSession)
+	r = s.(*Replication)
+	if err := s.Init(data.Config{VODs: cfg.VODs[:2]}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		r.ranker.UpdateStart(&data.SessionEvent{FileName: "a.mpg", IntFileName: fi.IntName("a.mpg"), Time: t0, Bps: 1000000})
+	}
+	r.updateReplicas()
+	if r.Replicas(1) != 2 {
+		t.Errorf("replicas must be limited by vod count, %v", r.Replicas(1))
+	}
+	if err := s.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	r.updateReplicas()
+	if r.Replicas(1) != 3 {
+		t.Errorf("replicas must grow after vod added, %v", r.Replicas(1))
+	}
+}
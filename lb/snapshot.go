@@ -0,0 +1,270 @@
+package lb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/castisdev/cdn-simul/lb/cache"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+// Snapshotter : cache, storage 에 저장된 내용을 snapshot 으로 만들고 다른 실행에서 미리 채울 수 있는 LoadBalancer
+// snapshot 은 file 이름으로 기록하므로 selector, cache policy 가 다른 LoadBalancer 에도 사용할 수 있음
+type Snapshotter interface {
+	Snapshot(fileName func(file int) string) (*Snapshot, error)
+	WarmUp(s *Snapshot, fileID func(name string) int, remap bool) error
+}
+
+// StatsResetter : 누적 통계(hit/miss, 거부 수 등)를 초기화할 수 있는 LoadBalancer, warm-up 기간 통계 제외에 사용
+// 처리 중인 session, bps 등 현재 상태 값은 유지
+type StatsResetter interface {
+	ResetStats()
+}
+
+// SnapshotChunk :
+type SnapshotChunk struct {
+	File      string
+	Index     int64
+	Rendition int
+	Size      int64
+}
+
+// Snapshot : VOD, parent 별 cache 된 chunk (먼저 evict 될 chunk 부터) 와 VOD 별 storage 의 file 목록
+type Snapshot struct {
+	VODs     map[vod.Key][]SnapshotChunk
+	Parents  map[string][]SnapshotChunk
+	Storages map[vod.Key][]string
+}
+
+func newSnapshot() *Snapshot {
+	return &Snapshot{
+		VODs:     make(map[vod.Key][]SnapshotChunk),
+		Parents:  make(map[string][]SnapshotChunk),
+		Storages: make(map[vod.Key][]string),
+	}
+}
+
+func snapshotChunks(c *cache.Cache, fileName func(file int) string) ([]SnapshotChunk, error) {
+	entries, err := c.Contents()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]SnapshotChunk, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, SnapshotChunk{File: fileName(e.Key.File), Index: e.Key.Index, Rendition: e.Key.Rendition, Size: e.Size})
+	}
+	return ret, nil
+}
+
+func warmChunks(c *cache.Cache, chunks []SnapshotChunk, fileID func(name string) int) (int, error) {
+	entries := make([]cache.StateEntry, 0, len(chunks))
+	for _, v := range chunks {
+		k := cache.ChunkKey{File: fileID(v.File), Index: v.Index, Rendition: v.Rendition}
+		entries = append(entries, cache.StateEntry{Key: k, Size: v.Size})
+	}
+	return c.Warm(entries)
+}
+
+func sortedVODKeys(m map[vod.Key][]SnapshotChunk) []vod.Key {
+	ret := make([]vod.Key, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// Snapshot :
+func (lb *LB) Snapshot(fileName func(file int) string) (*Snapshot, error) {
+	s := newSnapshot()
+	for k, v := range lb.Caches {
+		chunks, err := snapshotChunks(v, fileName)
+		if err != nil {
+			return nil, err
+		}
+		s.VODs[k] = chunks
+	}
+	for k, v := range lb.Parents {
+		chunks, err := snapshotChunks(v.Cache, fileName)
+		if err != nil {
+			return nil, err
+		}
+		s.Parents[k] = chunks
+	}
+	return s, nil
+}
+
+// WarmUp : remap 이고 selector 가 Owner 이면 chunk 를 file 의 owner VOD 에, 아니면 같은 id 의 VOD 에 채움
+// 없는 VOD, parent 의 chunk 는 버림
+func (lb *LB) WarmUp(s *Snapshot, fileID func(name string) int, remap bool) error {
+	o, isOwner := lb.Selector.(Owner)
+	chunks := make(map[vod.Key][]SnapshotChunk)
+	for _, k := range sortedVODKeys(s.VODs) {
+		for _, v := range s.VODs[k] {
+			dst := k
+			if remap && isOwner {
+				dst = o.Owner(v.File)
+			}
+			if _, ok := lb.Caches[dst]; ok {
+				chunks[dst] = append(chunks[dst], v)
+			}
+		}
+	}
+	var vodN, parentN int
+	for k, v := range chunks {
+		n, err := warmChunks(lb.Caches[k], v, fileID)
+		if err != nil {
+			return fmt.Errorf("failed to warm up vod %v, %v", k, err)
+		}
+		vodN += n
+		if isOwner {
+			for _, c := range v {
+				lb.files[c.File] = struct{}{}
+			}
+		}
+	}
+	for k, v := range s.Parents {
+		p, ok := lb.Parents[k]
+		if !ok {
+			continue
+		}
+		n, err := warmChunks(p.Cache, v, fileID)
+		if err != nil {
+			return fmt.Errorf("failed to warm up parent %v, %v", k, err)
+		}
+		parentN += n
+	}
+	fmt.Printf("warm up: %d vod chunks, %d parent chunks\n", vodN, parentN)
+	return nil
+}
+
+// ResetStats :
+func (lb *LB) ResetStats() {
+	for _, v := range lb.Caches {
+		v.ResetStats()
+	}
+	for _, v := range lb.Parents {
+		v.Cache.ResetStats()
+	}
+	for _, v := range lb.VODs {
+		v.TotalSessionCount, v.HitSessionCount = 0, 0
+	}
+	lb.rejecter.resetStats()
+	lb.topologyHit, lb.topologyMiss = 0, 0
+}
+
+// Snapshot : selector 가 FileBase 인 경우 storage 의 file 목록을 기록
+func (lb *FilebaseLB) Snapshot(fileName func(file int) string) (*Snapshot, error) {
+	fb, ok := lb.selector.(*FileBase)
+	if !ok {
+		return nil, fmt.Errorf("selector %T does not support snapshot", lb.selector)
+	}
+	s := newSnapshot()
+	for k, v := range fb.storages {
+		contents, err := storageContents(v)
+		if err != nil {
+			return nil, err
+		}
+		files := make([]string, 0, len(contents))
+		for _, f := range sortedInts(contents) {
+			files = append(files, fileName(f))
+		}
+		s.Storages[k] = files
+	}
+	return s, nil
+}
+
+// WarmUp : remap 이면 file 을 저장할 수 있는(Owns) 모든 VOD 에, 아니면 같은 id 의 VOD 에 storage 크기 이내로 채움
+func (lb *FilebaseLB) WarmUp(s *Snapshot, fileID func(name string) int, remap bool) error {
+	fb, ok := lb.selector.(*FileBase)
+	if !ok {
+		return fmt.Errorf("selector %T does not support warm up", lb.selector)
+	}
+	keys := make([]vod.Key, 0, len(s.Storages))
+	for k := range s.Storages {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	n := 0
+	for _, k := range keys {
+		for _, f := range s.Storages[k] {
+			id := fileID(f)
+			if !remap {
+				if st, ok := fb.storages[k]; ok && preloadStorage(st, id, false) {
+					n++
+				}
+				continue
+			}
+			for _, st := range fb.storages {
+				if preloadStorage(st, id, true) {
+					n++
+				}
+			}
+		}
+	}
+	fmt.Printf("warm up: %d storage files\n", n)
+	return nil
+}
+
+// ResetStats :
+func (lb *FilebaseLB) ResetStats() {
+	lb.HitCount, lb.MissCount, lb.MissSessionCount = 0, 0, 0
+	for _, v := range lb.Counts {
+		v.HitCount, v.MissCount = 0, 0
+	}
+	for _, v := range lb.VODs {
+		v.TotalSessionCount, v.HitSessionCount = 0, 0
+	}
+	lb.rejecter.resetStats()
+}
+
+// ResetStats :
+func (lb *LegacyLB) ResetStats() {
+	lb.HitCount, lb.MissCount = 0, 0
+	for _, v := range lb.VODs {
+		v.TotalSessionCount, v.HitSessionCount = 0, 0
+	}
+	lb.rejecter.resetStats()
+}
+
+// resetStats : overflow 로 처리 중인 session, bps 는 현재 상태이므로 유지
+func (r *sessionRejecter) resetStats() {
+	r.status.SessionLimitCount = 0
+	r.status.BpsLimitCount = 0
+	r.status.VODDownCount = 0
+	r.status.OverflowTotalCount = 0
+}
+
+func storageContents(s Storage) (map[int]struct{}, error) {
+	switch v := s.(type) {
+	case *FilebaseStorage:
+		return v.contents, nil
+	case *IdealStorage:
+		return v.contents, nil
+	}
+	return nil, fmt.Errorf("storage %T does not support snapshot", s)
+}
+
+// preloadStorage : checkOwner 이면 저장할 수 있는 file 만 추가, 이미 있거나 공간이 부족하면 추가하지 않음
+func preloadStorage(s Storage, file int, checkOwner bool) bool {
+	switch v := s.(type) {
+	case *FilebaseStorage:
+		return preloadContents(v.contents, &v.curSize, v.limitSize, v.fileInfos.Info(file).Size, file, v.owns, checkOwner)
+	case *IdealStorage:
+		return preloadContents(v.contents, &v.curSize, v.limitSize, v.fileInfos.Info(file).Size, file, v.owns, checkOwner)
+	}
+	return false
+}
+
+func preloadContents(contents map[int]struct{}, curSize *int64, limitSize, size int64, file int,
+	owns func(file int) bool, checkOwner bool) bool {
+	if checkOwner && owns != nil && !owns(file) {
+		return false
+	}
+	if _, ok := contents[file]; ok || *curSize+size > limitSize {
+		return false
+	}
+	contents[file] = struct{}{}
+	*curSize += size
+	return true
+}
@@ -13,6 +13,7 @@ type AddDeleter interface {
 	Add(fname int)
 	Delete(minDelSize int64)
 	Exists(fname int) bool
+	Owns(fname int) bool
 }
 
 // Storage :
@@ -21,6 +22,7 @@ type Storage interface {
 	UpdateEnd(evt *data.SessionEvent)
 	Exists(file int) bool
 	LimitSize() int64
+	CurSize() int64
 }
 
 // FilebaseStorage :
@@ -38,11 +40,12 @@ type FilebaseStorage struct {
 	dawnPushN       int // 03 ~ 09시 push할 컨텐츠 수 배수
 	deliverP        *deliverProcessor
 	purgeP          *purgeProcessor
+	owns            func(file int) bool // 저장할 수 있는 file, nil 이면 모든 file
 }
 
-// NewFilebaseStorage :
+// NewFilebaseStorage : owns 가 nil 이 아니면 owns 가 true 인 file 만 저장
 func NewFilebaseStorage(statDuration, statDurationForDel, shiftPeriod, pushPeriod time.Duration,
-	pushDelayN, dawnPushN int, limitSize int64, fi *data.FileInfos,
+	pushDelayN, dawnPushN int, limitSize int64, fi *data.FileInfos, owns func(file int) bool,
 	initContents []string, delivers []*data.DeliverEvent, purges []*data.PurgeEvent,
 	useSessionDuration, useDeleteLru, useFileSize, useTimeWeight bool) *FilebaseStorage {
 	s := &FilebaseStorage{
@@ -53,6 +56,7 @@ func NewFilebaseStorage(statDuration, statDurationForDel, shiftPeriod, pushPerio
 		pushPeriod: pushPeriod,
 		pushDelayN: pushDelayN,
 		dawnPushN:  dawnPushN,
+		owns:       owns,
 	}
 	if dawnPushN <= 0 {
 		s.dawnPushN = 1
@@ -76,6 +80,9 @@ func NewFilebaseStorage(statDuration, statDurationForDel, shiftPeriod, pushPerio
 			continue
 		}
 		f := fi.IntName(v)
+		if !s.Owns(f) {
+			continue
+		}
 		if totalSize+fi.Info(f).Size > limitSize {
 			break
 		}
@@ -132,6 +139,16 @@ func (s *FilebaseStorage) LimitSize() int64 {
 	return s.limitSize
 }
 
+// CurSize :
+func (s *FilebaseStorage) CurSize() int64 {
+	return s.curSize
+}
+
+// Owns :
+func (s *FilebaseStorage) Owns(file int) bool {
+	return s.owns == nil || s.owns(file)
+}
+
 // Add :
 func (s *FilebaseStorage) Add(fname int) {
 	var empty struct{}
@@ -192,7 +209,7 @@ func (s *FilebaseStorage) pushOne() error {
 		s.Add(compl)
 	}
 
-	add, rank, err := s.hitRanker.Addable(s.contents, s.limitSize, s.pushingQ)
+	add, rank, err := s.addable()
 	if err == ErrNotExistsAddable {
 		return nil
 	} else if err != nil {
@@ -210,6 +227,29 @@ func (s *FilebaseStorage) pushOne() error {
 	return nil
 }
 
+// addable : 저장할 수 있는 file 중 저장되지 않은 가장 인기 있는 file
+func (s *FilebaseStorage) addable() (id, rank int, err error) {
+	if s.owns == nil {
+		return s.hitRanker.Addable(s.contents, s.limitSize, s.pushingQ)
+	}
+	var totalSize int64
+	rank = 0
+	for _, v := range s.hitRanker.HitList(s.pushingQ) {
+		if !s.owns(v.filename) {
+			continue
+		}
+		totalSize += v.filesize
+		if s.limitSize < totalSize {
+			break
+		}
+		if _, ok := s.contents[v.filename]; !ok {
+			return v.filename, rank, nil
+		}
+		rank++
+	}
+	return 0, 0, ErrNotExistsAddable
+}
+
 type deliverProcessor struct {
 	events    []*data.DeliverEvent
 	curIdx    int
@@ -230,7 +270,9 @@ func (p *deliverProcessor) process(t time.Time, adder AddDeleter) error {
 			fmt.Printf("add %s (deliver event)\n", ev.FileName)
 		}
 		f := p.fileInfos.IntName(ev.FileName)
-		if adder.Exists(f) {
+		if !adder.Owns(f) {
+			fmt.Printf("not owned %s, no deliver\n", ev.FileName)
+		} else if adder.Exists(f) {
 			fmt.Printf("already exists %s, no deliver\n", ev.FileName)
 		} else {
 			adder.Delete(p.fileInfos.Info(f).Size)
@@ -288,17 +330,19 @@ type IdealStorage struct {
 	limitSize    int64
 	updatedT     time.Time
 	updatePeriod time.Duration
+	owns         func(file int) bool // 저장할 수 있는 file, nil 이면 모든 file
 }
 
-// NewIdealStorage :
+// NewIdealStorage : owns 가 nil 이 아니면 owns 가 true 인 file 만 저장
 func NewIdealStorage(updatePeriod, statDuration, shiftPeriod time.Duration, limitSize int64,
-	fi *data.FileInfos, useSessionDuration, useFileSize, useTimeWeight bool) *IdealStorage {
+	fi *data.FileInfos, owns func(file int) bool, useSessionDuration, useFileSize, useTimeWeight bool) *IdealStorage {
 	s := &IdealStorage{
 		fileInfos:    fi,
 		hitRanker:    NewHitRanker(statDuration, shiftPeriod, fi, useSessionDuration, useFileSize, useTimeWeight),
 		contents:     make(map[int]struct{}),
 		limitSize:    limitSize,
 		updatePeriod: updatePeriod,
+		owns:         owns,
 	}
 	fmt.Printf("new nice storage updatePeriod(%v) statDuration(%v) shiftPeriod(%v) useSessionDuration(%v) useFileSize(%v) useTimeWeight(%v)\n",
 		updatePeriod, statDuration, shiftPeriod, useSessionDuration, useFileSize, useTimeWeight)
@@ -334,17 +378,26 @@ func (s *IdealStorage) LimitSize() int64 {
 	return s.limitSize
 }
 
+// CurSize :
+func (s *IdealStorage) CurSize() int64 {
+	return s.curSize
+}
+
 func (s *IdealStorage) update(t time.Time) {
 	contents := make(map[int]struct{})
 	list := s.hitRanker.HitList(nil)
 	var empty struct{}
 	var totalSize int64
 	for _, v := range list {
-		totalSize += v.filesize
-		if s.limitSize < totalSize {
+		if s.owns != nil && !s.owns(v.filename) {
+			continue
+		}
+		if s.limitSize < totalSize+v.filesize {
 			break
 		}
+		totalSize += v.filesize
 		contents[v.filename] = empty
 	}
 	s.contents = contents
+	s.curSize = totalSize
 }
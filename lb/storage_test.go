@@ -142,7 +142,7 @@ func TestStorage_DeliverPurgeProcessor(t *testing.T) {
 	purges := []*data.PurgeEvent{
 		&data.PurgeEvent{Time: StrToTime("2017-01-01 00:02:00"), FileName: adsFile},
 	}
-	st := NewFilebaseStorage(statDuration, statDuration, shiftPeriod, pushPeriod, 1, 1, 10*GB, fi, nil, delivers, purges, false, false, false, false)
+	st := NewFilebaseStorage(statDuration, statDuration, shiftPeriod, pushPeriod, 1, 1, 10*GB, fi, nil, nil, delivers, purges, false, false, false, false)
 
 	eventFn("2017-01-01 00:00:59", st)
 	if st.Exists(fi.IntName(adsFile)) {
@@ -199,7 +199,7 @@ func TestNiceStorage(t *testing.T) {
 	statDuration := 24 * time.Hour
 	shiftPeriod := 1 * time.Hour
 	limitSize := int64(1000000000)
-	st := NewIdealStorage(updatePeriod, statDuration, shiftPeriod, limitSize, fi, false, false, false)
+	st := NewIdealStorage(updatePeriod, statDuration, shiftPeriod, limitSize, fi, nil, false, false, false)
 
 	eventFn := func(file, strTime, sid string) {
 		evt := &data.SessionEvent{
@@ -0,0 +1,156 @@
+package lb
+
+import (
+	"fmt"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/vod"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// TopologyHandler : 실행 중 VOD 추가/삭제/변경을 처리할 수 있는 LoadBalancer
+// 삭제할 VOD 의 session 은 호출한 쪽에서 DownVOD 후 종료하거나 이전해야 함
+type TopologyHandler interface {
+	FaultHandler
+	AddVOD(cfg data.VODConfig) error
+	RemoveVOD(key vod.Key) error
+	ChangeVOD(cfg data.VODConfig) error
+	Config() data.Config
+}
+
+// Config : topology 변경이 반영된 cfg
+func (lb *LB) Config() data.Config {
+	return lb.cfg
+}
+
+// AddVOD : cache 가 비어있는 VOD 추가
+func (lb *LB) AddVOD(v data.VODConfig) error {
+	if _, ok := lb.VODs[vod.Key(v.VodID)]; ok {
+		return fmt.Errorf("already exists vod %v", v.VodID)
+	}
+	if err := lb.addVOD(v); err != nil {
+		return err
+	}
+	cfg := lb.cfg
+	cfg.VODs = append(append([]data.VODConfig{}, lb.cfg.VODs...), v)
+	if err := lb.reinit(cfg); err != nil {
+		delete(lb.VODs, vod.Key(v.VodID))
+		delete(lb.Caches, vod.Key(v.VodID))
+		delete(lb.vodParentMap, vod.Key(v.VodID))
+		return err
+	}
+	return nil
+}
+
+// RemoveVOD : 처리 중인 session 이 있으면 실패, selector 초기화에 실패하면 VOD 를 그대로 둠
+func (lb *LB) RemoveVOD(key vod.Key) error {
+	if _, ok := lb.VODs[key]; !ok {
+		return fmt.Errorf("not exists vod %v", key)
+	}
+	if n := len(lb.Sessions(key)); n > 0 {
+		return fmt.Errorf("vod %v has %v sessions", key, n)
+	}
+	cfg := lb.cfg
+	cfg.VODs = nil
+	for _, v := range lb.cfg.VODs {
+		if v.VodID != string(key) {
+			cfg.VODs = append(cfg.VODs, v)
+		}
+	}
+	if len(cfg.VODs) == 0 {
+		return fmt.Errorf("can not remove last vod %v", key)
+	}
+	if err := lb.reinit(cfg); err != nil {
+		return err
+	}
+	delete(lb.VODs, key)
+	delete(lb.Caches, key)
+	delete(lb.vodParentMap, key)
+	// 삭제한 VOD 의 hit, miss 는 변경 이후 측정 기준에서 제외
+	lb.topologyHit, lb.topologyMiss = lb.cacheHitMiss()
+	return nil
+}
+
+// ChangeVOD : 0 이 아닌 StorageSize, LimitSession, LimitBps 만 변경
+func (lb *LB) ChangeVOD(v data.VODConfig) error {
+	key := vod.Key(v.VodID)
+	vd, ok := lb.VODs[key]
+	if !ok {
+		return fmt.Errorf("not exists vod %v", key)
+	}
+	cfg := lb.cfg
+	cfg.VODs = append([]data.VODConfig{}, lb.cfg.VODs...)
+	for i := range cfg.VODs {
+		c := &cfg.VODs[i]
+		if c.VodID != v.VodID {
+			continue
+		}
+		if v.StorageSize != 0 {
+			c.StorageSize = v.StorageSize
+			lb.Caches[key].Resize(v.StorageSize)
+		}
+		if v.LimitSession != 0 {
+			c.LimitSession = v.LimitSession
+			vd.LimitSessionCount = v.LimitSession
+		}
+		if v.LimitBps != 0 {
+			c.LimitBps = v.LimitBps
+			vd.LimitBps = v.LimitBps
+		}
+	}
+	return lb.reinit(cfg)
+}
+
+// reinit : 변경된 cfg 로 selector 를 다시 초기화, owner 가 바뀐 file 수와 이후 miss 측정 기준 기록
+func (lb *LB) reinit(cfg data.Config) error {
+	var files []string
+	before := make(ownerMap)
+	o, isOwner := lb.Selector.(Owner)
+	if isOwner {
+		for f := range lb.files {
+			files = append(files, f)
+			before[f] = o.Owner(f)
+		}
+	}
+	if err := lb.Selector.Init(cfg); err != nil {
+		// 실패 시 이전 cfg 로 복구
+		lb.Selector.Init(lb.cfg)
+		return err
+	}
+	lb.cfg = cfg
+
+	moved := int64(0)
+	if isOwner {
+		moved = int64(MovedOwners(files, before, o))
+	}
+	lb.topology.ChangeCount++
+	lb.topology.RemappedFileCount = moved
+	lb.topology.TotalRemappedCount += moved
+	lb.topology.TrackedFileCount = int64(len(files))
+	lb.topologyHit, lb.topologyMiss = lb.cacheHitMiss()
+	return nil
+}
+
+// ownerMap : selector 를 다시 초기화하기 전의 file 별 owner
+type ownerMap map[string]vod.Key
+
+// Owner :
+func (m ownerMap) Owner(file string) vod.Key {
+	return m[file]
+}
+
+func (lb *LB) cacheHitMiss() (hit, miss int64) {
+	for _, v := range lb.Caches {
+		hit += v.HitCount
+		miss += v.MissCount
+	}
+	return hit, miss
+}
+
+func (lb *LB) makeTopologyStatus() *status.TopologyStatus {
+	st := lb.topology
+	hit, miss := lb.cacheHitMiss()
+	st.HitSinceChange = hit - lb.topologyHit
+	st.MissSinceChange = miss - lb.topologyMiss
+	return &st
+}
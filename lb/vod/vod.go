@@ -1,7 +1,7 @@
 package vod
 
 import (
-	"fmt"
+	"errors"
 
 	"github.com/castisdev/cdn-simul/data"
 )
@@ -9,6 +9,15 @@ import (
 // Key :
 type Key string
 
+// ErrSessionLimit :
+var ErrSessionLimit = errors.New("reaches limit session count")
+
+// ErrBpsLimit :
+var ErrBpsLimit = errors.New("reaches limit bps")
+
+// ErrDown :
+var ErrDown = errors.New("vod is down")
+
 // VOD :
 type VOD struct {
 	CurSessionCount   int64
@@ -18,6 +27,7 @@ type VOD struct {
 	CurBps            int64
 	LimitBps          int64
 	TotalBps          int64
+	Down              bool // 장애(점검) 중이면 session 을 처리하지 않음
 }
 
 // HitFail :
@@ -26,13 +36,24 @@ func (v *VOD) HitFail() {
 	v.TotalSessionCount++
 }
 
-// StartSession :
-func (v *VOD) StartSession(evt *data.SessionEvent) error {
+// Available : bps 인 session 을 추가할 수 없으면 ErrDown, ErrSessionLimit, ErrBpsLimit 중 하나 반환
+func (v *VOD) Available(bps int64) error {
+	if v.Down {
+		return ErrDown
+	}
 	if v.CurSessionCount+1 > v.LimitSessionCount {
-		return fmt.Errorf("reaches limit session count, cur(%v) limit(%v)", v.CurSessionCount, v.LimitSessionCount)
+		return ErrSessionLimit
 	}
-	if v.CurBps+evt.Bps > v.LimitBps {
-		return fmt.Errorf("reaches limit bps, cur(%v) limit(%v)", v.CurBps, v.LimitBps)
+	if v.CurBps+bps > v.LimitBps {
+		return ErrBpsLimit
+	}
+	return nil
+}
+
+// StartSession :
+func (v *VOD) StartSession(evt *data.SessionEvent) error {
+	if err := v.Available(evt.Bps); err != nil {
+		return err
 	}
 	v.CurSessionCount++
 	v.CurBps += evt.Bps
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/mrc"
+	"github.com/castisdev/cdn-simul/simul"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// chunkKey : ChunkRefReader 로 펼친 chunk 요청, rendition 은 구분하지 않음
+// origin limit, 거부, fault, topology, abr 에 따른 실제 simulation 의 요청 변화는 반영되지 않음
+type chunkKey struct {
+	file  string
+	index int64
+}
+
+type result struct {
+	Requests   int64       `json:"requests"`
+	Bytes      int64       `json:"bytes"`
+	ColdMisses int64       `json:"coldMisses"`
+	Step       int64       `json:"step"`
+	Points     []mrc.Point `json:"points"`
+}
+
+func main() {
+	var dbFile, start, csvFile, jsonFile string
+	var readEventCount int
+	var step, maxSize, chunkSize int64
+
+	flag.StringVar(&dbFile, "db", "chunk.db", "event db")
+	flag.IntVar(&readEventCount, "event-count", 0, "event count to process. if 0, process all event")
+	flag.StringVar(&start, "start", "", "analysis start point, before that point events will be ignored, (ex)2017-01-01 00:00:00.000")
+	flag.Int64Var(&step, "step", 10*1000*1000*1000, "cache size step (byte)")
+	flag.Int64Var(&chunkSize, "chunk-size", data.DefaultChunkSize, "chunk size (byte)")
+	flag.Int64Var(&maxSize, "max-size", 0, "max cache size (byte). if 0, until all reused chunks are hit")
+	flag.StringVar(&csvFile, "csv", "mrc.csv", "csv output file [cache-size,hit-ratio,byte-hit-ratio]. if empty, not write")
+	flag.StringVar(&jsonFile, "json", "", "json output file. if empty, not write")
+	flag.Parse()
+
+	if step <= 0 {
+		log.Fatalf("invalid step %v", step)
+	}
+	var startT time.Time
+	if start != "" {
+		startT = simul.StrToTime(start)
+	}
+
+	cfg := data.Config{ChunkSize: chunkSize}
+	sizer, err := simul.NewChunkSizer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	meta, err := data.LoadLogMeta(dbFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := simul.CheckLogMeta(cfg, meta); err != nil {
+		log.Printf("warning, %v\n", err)
+	}
+	db, err := leveldb.OpenFile(dbFile, nil)
+	if err != nil {
+		log.Fatalf("failed to open db, %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	r := simul.NewChunkRefReader(simul.NewDBEventReader(db), sizer, startT, readEventCount)
+	a := mrc.NewAnalyzer(step)
+	for {
+		ref := r.ReadChunkRef()
+		if ref == nil {
+			break
+		}
+		a.Access(chunkKey{file: ref.FileName, index: ref.Index}, ref.Size)
+		if a.Requests%10000000 == 0 {
+			log.Printf("%v chunks processed, %s\n", a.Requests, simul.TimeToStr(ref.Time))
+		}
+	}
+	log.Printf("completed. chunks:%v cold-miss:%v elapsed:%v\n", a.Requests, a.ColdMisses, time.Since(now))
+
+	res := result{
+		Requests:   a.Requests,
+		Bytes:      a.Bytes,
+		ColdMisses: a.ColdMisses,
+		Step:       step,
+		Points:     a.Curve(maxSize),
+	}
+	if csvFile != "" {
+		f, err := os.Create(csvFile)
+		if err != nil {
+			log.Fatalf("failed to create csv, %v", err)
+		}
+		defer f.Close()
+		for _, p := range res.Points {
+			fmt.Fprintln(f, p)
+		}
+	}
+	if jsonFile != "" {
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to marshal json, %v", err)
+		}
+		if err := ioutil.WriteFile(jsonFile, b, 0644); err != nil {
+			log.Fatalf("failed to write json, %v", err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package mrc
+
+import (
+	"fmt"
+	"sort"
+)
+
+const initialCapacity = 1 << 20
+
+// Point : 하나의 cache 크기에 대한 LRU hit ratio
+type Point struct {
+	CacheSize    int64   `json:"cacheSize"`
+	HitRatio     float64 `json:"hitRatio"`
+	ByteHitRatio float64 `json:"byteHitRatio"`
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("%d,%.6f,%.6f", p.CacheSize, p.HitRatio, p.ByteHitRatio)
+}
+
+type bucket struct {
+	hits     int64
+	hitBytes int64
+}
+
+type entry struct {
+	pos  int
+	size int64
+}
+
+// Analyzer : 요청마다 LRU stack distance(reuse distance, byte 단위)를 계산하여
+// 한번의 trace 처리로 모든 cache 크기의 hit ratio 를 구함 (Mattson stack algorithm)
+//
+// stack distance 는 마지막 요청 이후 요청된 서로 다른 key 들의 크기 합 + 자신의 크기이며,
+// cache 크기가 stack distance 이상이면 LRU cache 에서 hit 이다.
+// 각 key 의 마지막 요청 위치에 크기를 기록한 fenwick tree 로 구간 합을 계산한다.
+type Analyzer struct {
+	step       int64
+	entries    map[interface{}]*entry
+	tree       []int64
+	pos        int
+	buckets    map[int64]*bucket
+	Requests   int64
+	Bytes      int64
+	ColdMisses int64
+}
+
+// NewAnalyzer : step 은 결과 cache 크기의 간격(byte)
+func NewAnalyzer(step int64) *Analyzer {
+	return &Analyzer{
+		step:    step,
+		entries: make(map[interface{}]*entry),
+		tree:    make([]int64, initialCapacity+1),
+		buckets: make(map[int64]*bucket),
+	}
+}
+
+func (a *Analyzer) add(i int, v int64) {
+	for ; i < len(a.tree); i += i & -i {
+		a.tree[i] += v
+	}
+}
+
+func (a *Analyzer) sum(i int) int64 {
+	s := int64(0)
+	for ; i > 0; i -= i & -i {
+		s += a.tree[i]
+	}
+	return s
+}
+
+// compact : 사용된 위치가 tree 크기에 도달하면 살아있는 key 들의 위치를 1부터 다시 부여
+func (a *Analyzer) compact() {
+	list := make([]*entry, 0, len(a.entries))
+	for _, e := range a.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].pos < list[j].pos })
+
+	capacity := len(a.tree) - 1
+	for capacity < 2*len(list) {
+		capacity *= 2
+	}
+	a.tree = make([]int64, capacity+1)
+	for i, e := range list {
+		e.pos = i + 1
+		a.add(e.pos, e.size)
+	}
+	a.pos = len(list)
+}
+
+// Access : key 요청 처리
+func (a *Analyzer) Access(key interface{}, size int64) {
+	a.Requests++
+	a.Bytes += size
+	if a.pos+1 >= len(a.tree) {
+		a.compact()
+	}
+	a.pos++
+
+	e, ok := a.entries[key]
+	if !ok {
+		a.ColdMisses++
+		e = &entry{}
+		a.entries[key] = e
+	} else {
+		distance := a.sum(a.pos-1) - a.sum(e.pos) + size
+		idx := (distance + a.step - 1) / a.step
+		b, ok := a.buckets[idx]
+		if !ok {
+			b = &bucket{}
+			a.buckets[idx] = b
+		}
+		b.hits++
+		b.hitBytes += size
+		a.add(e.pos, -e.size)
+	}
+	e.pos = a.pos
+	e.size = size
+	a.add(e.pos, size)
+}
+
+// Curve : step 간격의 cache 크기별 hit ratio, maxSize 가 0 이면 모든 요청이 hit 이 되는 크기까지
+func (a *Analyzer) Curve(maxSize int64) []Point {
+	var idxs []int64
+	for k := range a.buckets {
+		idxs = append(idxs, k)
+	}
+	sort.Slice(idxs, func(i, j int) bool { return idxs[i] < idxs[j] })
+
+	maxIdx := int64(0)
+	if len(idxs) > 0 {
+		maxIdx = idxs[len(idxs)-1]
+	}
+	if maxSize > 0 {
+		maxIdx = maxSize / a.step
+	}
+
+	var points []Point
+	var hits, hitBytes int64
+	j := 0
+	for i := int64(1); i <= maxIdx; i++ {
+		for ; j < len(idxs) && idxs[j] <= i; j++ {
+			hits += a.buckets[idxs[j]].hits
+			hitBytes += a.buckets[idxs[j]].hitBytes
+		}
+		p := Point{CacheSize: i * a.step}
+		if a.Requests > 0 {
+			p.HitRatio = float64(hits) / float64(a.Requests)
+		}
+		if a.Bytes > 0 {
+			p.ByteHitRatio = float64(hitBytes) / float64(a.Bytes)
+		}
+		points = append(points, p)
+	}
+	return points
+}
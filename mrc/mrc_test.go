@@ -0,0 +1,64 @@
+package mrc
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb/cache"
+)
+
+func TestAnalyzer_Simple(t *testing.T) {
+	a := NewAnalyzer(10)
+	// a b c a : a 의 stack distance 는 30
+	for _, k := range []string{"a", "b", "c", "a"} {
+		a.Access(k, 10)
+	}
+	curve := a.Curve(40)
+	expected := []float64{0, 0, 0.25, 0.25}
+	if len(curve) != len(expected) {
+		t.Fatalf("%v != %v", len(expected), len(curve))
+	}
+	for i, v := range expected {
+		if curve[i].HitRatio != v {
+			t.Errorf("[%v] %v != %v", curve[i].CacheSize, v, curve[i].HitRatio)
+		}
+	}
+	if a.ColdMisses != 3 {
+		t.Errorf("%v != %v", 3, a.ColdMisses)
+	}
+}
+
+func TestAnalyzer_CompareWithLRU(t *testing.T) {
+	chunkSize := int64(10)
+	r := rand.New(rand.NewSource(1))
+	var evts []*data.ChunkEvent
+	for i := 0; i < 20000; i++ {
+		// 작은 번호의 file 이 더 자주 요청되도록
+		f := int(r.ExpFloat64()*30) + 1
+		evts = append(evts, &data.ChunkEvent{IntFileName: f, Index: int64(r.Intn(4)), ChunkSize: chunkSize})
+	}
+
+	a := NewAnalyzer(5 * chunkSize)
+	// compact 동작도 확인하기 위해 작은 tree 로 시작
+	a.tree = make([]int64, 1025)
+	for _, e := range evts {
+		a.Access([2]int64{int64(e.IntFileName), e.Index}, e.ChunkSize)
+	}
+
+	for _, p := range a.Curve(200 * chunkSize) {
+		c, err := cache.NewCache(p.CacheSize, cache.PolicyLRU, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range evts {
+			if _, err := c.StartChunk(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		hitRatio := float64(c.HitCount) / float64(c.HitCount+c.MissCount)
+		if hitRatio != p.HitRatio {
+			t.Errorf("[%v] %v != %v", p.CacheSize, hitRatio, p.HitRatio)
+		}
+	}
+}
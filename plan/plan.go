@@ -0,0 +1,277 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/simul"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// ErrNoFeasible : 탐색 범위의 모든 config 가 target 을 만족하지 못함
+var ErrNoFeasible = errors.New("no configuration meets targets")
+
+// Target : plan 이 만족해야 하는 조건
+type Target struct {
+	MaxOriginPeakBps int64   // 0 이면 조건 없음
+	MinHitRatio      float64 // 0 이면 조건 없음
+	MaxRejected      int64   // 0 이면 거부 session 이 없어야 함, 음수이면 조건 없음
+}
+
+// Result : config 하나를 simulation 한 결과
+type Result struct {
+	HitRatio      float64
+	OriginPeakBps int64
+	Rejected      int64 // 거부된 session 수, overflow 로 처리하거나 filebase 에서 file 이 없어 origin 에서 처리한 session 은 제외
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("hit-ratio(%.4f) origin-peak(%v) rejected(%v)", r.HitRatio, r.OriginPeakBps, r.Rejected)
+}
+
+// NewResult : simulation 종료 후 status 로 Result 생성
+func NewResult(st status.Status) Result {
+	var r Result
+	var hit, miss int64
+	for _, v := range st.Caches {
+		hit += v.CacheHitCount
+		miss += v.CacheMissCount
+	}
+	if hit+miss > 0 {
+		r.HitRatio = float64(hit) / float64(hit+miss)
+	}
+	if st.Origin != nil {
+		r.OriginPeakBps = st.Origin.PeakBps
+	}
+	if st.Reject != nil {
+		r.Rejected = st.Reject.RefusedCount()
+	}
+	return r
+}
+
+// Met : r 이 target 을 만족하는지
+func (t Target) Met(r Result) bool {
+	if t.MaxOriginPeakBps > 0 && r.OriginPeakBps > t.MaxOriginPeakBps {
+		return false
+	}
+	if t.MinHitRatio > 0 && r.HitRatio < t.MinHitRatio {
+		return false
+	}
+	if t.MaxRejected >= 0 && r.Rejected > t.MaxRejected {
+		return false
+	}
+	return true
+}
+
+// Space : 탐색 범위, VOD 는 모두 같은 storage, bps 를 가짐
+// storage, bps 는 step 단위로 bisection, storage 는 chunk 를 저장할 수 있는 크기 이상이어야 함
+type Space struct {
+	MinVODs     int
+	MaxVODs     int
+	MinStorage  int64
+	MaxStorage  int64
+	StorageStep int64
+	MinBps      int64
+	MaxBps      int64
+	BpsStep     int64
+}
+
+func (s Space) validate() error {
+	if s.MinVODs < 1 || s.MaxVODs < s.MinVODs {
+		return fmt.Errorf("invalid vod count range [%v, %v]", s.MinVODs, s.MaxVODs)
+	}
+	if s.MinStorage <= 0 || s.MaxStorage < s.MinStorage || s.StorageStep <= 0 {
+		return fmt.Errorf("invalid storage range [%v, %v] step %v", s.MinStorage, s.MaxStorage, s.StorageStep)
+	}
+	if s.MinBps < 0 || s.MaxBps < s.MinBps || s.BpsStep <= 0 {
+		return fmt.Errorf("invalid bps range [%v, %v] step %v", s.MinBps, s.MaxBps, s.BpsStep)
+	}
+	return nil
+}
+
+// Cost : VOD 하나의 비용은 VOD + storage(TB) * StorageTB + bps(Gbps) * Gbps
+type Cost struct {
+	VOD       float64
+	StorageTB float64
+	Gbps      float64
+}
+
+// Of : VOD n 개의 비용
+func (c Cost) Of(n int, storage, bps int64) float64 {
+	return float64(n) * (c.VOD + float64(storage)/1e12*c.StorageTB + float64(bps)/1e9*c.Gbps)
+}
+
+// Evaluator : config 를 simulation 하여 결과 반환
+type Evaluator interface {
+	Evaluate(cfg data.Config) (Result, error)
+}
+
+// SimEvaluator : memory 에 읽어둔 trace 로 simulator 를 실행
+// 실행마다 LB, FileInfos 를 새로 생성
+type SimEvaluator struct {
+	Events    []*glblog.SessionInfo
+	Opt       simul.Options
+	LBOpt     simul.LBOption                  // Cfg 는 평가할 config 로 바뀜
+	FileInfos func() (*data.FileInfos, error) // nil 이면 빈 FileInfos
+}
+
+// Evaluate :
+func (e *SimEvaluator) Evaluate(cfg data.Config) (Result, error) {
+	var fi *data.FileInfos
+	var err error
+	if e.FileInfos != nil {
+		fi, err = e.FileInfos()
+	} else {
+		fi, err = data.NewEmptyFileInfos()
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	lbOpt := e.LBOpt
+	lbOpt.Cfg = cfg
+	lbOpt.Fileinfos = fi
+	l, err := simul.NewLoadBalancer(lbOpt)
+	if err != nil {
+		return Result{}, err
+	}
+	si := simul.NewSimulator(cfg, e.Opt, l, simul.NewMemEventReader(e.Events), nil, fi, nil)
+	si.Run()
+	return NewResult(si.Status()), nil
+}
+
+// Plan : target 을 만족하는 가장 싼 config
+type Plan struct {
+	VODCount    int
+	StorageSize int64
+	LimitBps    int64
+	Cost        float64
+	Result      Result
+	Cfg         data.Config
+	Runs        int // simulation 실행 횟수
+}
+
+// Config : base 의 첫 VOD 를 template 으로 VOD n 개 생성, parent 등 나머지 설정은 base 그대로 사용
+func Config(base data.Config, n int, storage, bps int64) data.Config {
+	cfg := base
+	cfg.VODs = make([]data.VODConfig, n)
+	for i := range cfg.VODs {
+		v := base.VODs[0]
+		v.VodID = fmt.Sprintf("vod%d", i+1)
+		v.StorageSize = storage
+		v.LimitBps = bps
+		cfg.VODs[i] = v
+	}
+	return cfg
+}
+
+type planner struct {
+	base    data.Config
+	target  Target
+	eval    Evaluator
+	results map[[3]int64]Result
+}
+
+func (p *planner) met(n int, storage, bps int64) (bool, error) {
+	k := [3]int64{int64(n), storage, bps}
+	r, ok := p.results[k]
+	if !ok {
+		var err error
+		r, err = p.eval.Evaluate(Config(p.base, n, storage, bps))
+		if err != nil {
+			return false, err
+		}
+		p.results[k] = r
+		log.Printf("plan vods(%v) storage(%v) bps(%v): %v met(%v)\n", n, storage, bps, r, p.target.Met(r))
+	}
+	return p.target.Met(r), nil
+}
+
+// bisect : f(hi) 가 true 일 때 [lo, hi] 에서 f 가 true 인 가장 작은 값 (step 단위)
+func bisect(lo, hi, step int64, f func(v int64) (bool, error)) (int64, error) {
+	ok, err := f(lo)
+	if err != nil || ok {
+		return lo, err
+	}
+	for hi-lo > step {
+		mid := lo + (hi-lo)/(2*step)*step
+		if mid <= lo {
+			mid = lo + step
+		}
+		ok, err := f(mid)
+		if err != nil {
+			return hi, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, nil
+}
+
+// Search : VOD 수마다 storage, bps 를 번갈아 bisection 하여 target 을 만족하는 가장 싼 config 를 찾음
+// storage, bps, VOD 수가 늘면 결과가 나빠지지 않는다고 가정
+func Search(base data.Config, target Target, space Space, cost Cost, eval Evaluator) (*Plan, error) {
+	if len(base.VODs) == 0 {
+		return nil, fmt.Errorf("base config must have a vod as template")
+	}
+	if err := space.validate(); err != nil {
+		return nil, err
+	}
+	p := &planner{base: base, target: target, eval: eval, results: make(map[[3]int64]Result)}
+
+	var best *Plan
+	for n := space.MinVODs; n <= space.MaxVODs; n++ {
+		// VOD 수가 늘면 최소 비용도 늘어나므로 더 볼 필요 없음
+		if best != nil && cost.Of(n, space.MinStorage, space.MinBps) >= best.Cost {
+			break
+		}
+		ok, err := p.met(n, space.MaxStorage, space.MaxBps)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		storage, bps := space.MaxStorage, space.MaxBps
+		// bps 를 줄이면 필요한 storage 가 달라질 수 있으므로 바뀌지 않을 때까지 반복
+		for {
+			s, err := bisect(space.MinStorage, storage, space.StorageStep, func(v int64) (bool, error) {
+				return p.met(n, v, bps)
+			})
+			if err != nil {
+				return nil, err
+			}
+			b, err := bisect(space.MinBps, bps, space.BpsStep, func(v int64) (bool, error) {
+				return p.met(n, s, v)
+			})
+			if err != nil {
+				return nil, err
+			}
+			if s == storage && b == bps {
+				break
+			}
+			storage, bps = s, b
+		}
+		c := cost.Of(n, storage, bps)
+		if best == nil || c < best.Cost {
+			best = &Plan{
+				VODCount:    n,
+				StorageSize: storage,
+				LimitBps:    bps,
+				Cost:        c,
+				Result:      p.results[[3]int64{int64(n), storage, bps}],
+				Cfg:         Config(base, n, storage, bps),
+			}
+		}
+	}
+	if best == nil {
+		return nil, ErrNoFeasible
+	}
+	best.Runs = len(p.results)
+	return best, nil
+}
@@ -0,0 +1,152 @@
+package plan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/simul"
+)
+
+// fakeEvaluator : 전체 storage 4TB 에서 hit 100%, 전체 bps 10Gbps 부터 거부 없음
+type fakeEvaluator struct {
+	runs int
+}
+
+func (e *fakeEvaluator) Evaluate(cfg data.Config) (Result, error) {
+	e.runs++
+	var storage, bps int64
+	for _, v := range cfg.VODs {
+		storage += v.StorageSize
+		bps += v.LimitBps
+	}
+	r := Result{HitRatio: float64(storage) / 4e12}
+	if r.HitRatio > 1 {
+		r.HitRatio = 1
+	}
+	r.OriginPeakBps = int64((1 - r.HitRatio) * 10e9)
+	if bps < 10e9 {
+		r.Rejected = (10e9 - bps) / 1e6
+	}
+	return r, nil
+}
+
+func testSpace() Space {
+	return Space{
+		MinVODs: 1, MaxVODs: 8,
+		MinStorage: 1e11, MaxStorage: 4e12, StorageStep: 1e11,
+		MinBps: 0, MaxBps: 10e9, BpsStep: 1e8,
+	}
+}
+
+func TestSearch(t *testing.T) {
+	base := data.Config{VODs: []data.VODConfig{data.VODConfig{VodID: "t", LimitSession: 100, CachePolicy: "lfu"}}}
+	target := Target{MinHitRatio: 0.5, MaxOriginPeakBps: 5e9}
+	e := &fakeEvaluator{}
+	// VOD 비용이 크면 VOD 1개
+	p, err := Search(base, target, testSpace(), Cost{VOD: 100, StorageTB: 1, Gbps: 1}, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.VODCount != 1 || p.StorageSize != 2e12 || p.LimitBps != 10e9 {
+		t.Errorf("invalid plan %+v", *p)
+	}
+	if !target.Met(p.Result) || p.Runs != e.runs {
+		t.Errorf("invalid result %v runs(%v/%v)", p.Result, p.Runs, e.runs)
+	}
+	if len(p.Cfg.VODs) != 1 || p.Cfg.VODs[0].VodID != "vod1" || p.Cfg.VODs[0].CachePolicy != "lfu" || p.Cfg.VODs[0].LimitSession != 100 {
+		t.Errorf("invalid config %+v", p.Cfg)
+	}
+
+	// VOD 2개이면 VOD 당 storage 는 절반, 거부 조건이 없으면 bps 는 최소
+	e = &fakeEvaluator{}
+	target.MaxRejected = -1
+	p, err = Search(base, target, Space{MinVODs: 2, MaxVODs: 2, MinStorage: 1e11, MaxStorage: 4e12, StorageStep: 1e11, MaxBps: 10e9, BpsStep: 1e8}, Cost{StorageTB: 1, Gbps: 1}, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.VODCount != 2 || p.StorageSize != 1e12 || p.LimitBps != 0 {
+		t.Errorf("invalid plan without reject target %+v", *p)
+	}
+}
+
+func TestSearch_NoFeasible(t *testing.T) {
+	base := data.Config{VODs: []data.VODConfig{data.VODConfig{VodID: "t"}}}
+	space := testSpace()
+	space.MaxStorage = 1e12
+	space.MaxVODs = 2
+	_, err := Search(base, Target{MinHitRatio: 0.9}, space, Cost{VOD: 1}, &fakeEvaluator{})
+	if err != ErrNoFeasible {
+		t.Errorf("must be no feasible, %v", err)
+	}
+	space.MinStorage = 0
+	if _, err := Search(base, Target{}, space, Cost{}, &fakeEvaluator{}); err == nil || err == ErrNoFeasible {
+		t.Errorf("invalid space must be failed, %v", err)
+	}
+}
+
+func TestSimEvaluator(t *testing.T) {
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   simul.StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     simul.StrToTime("2017-01-01 00:00:40.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   simul.StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     simul.StrToTime("2017-01-01 00:00:41.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+	base := data.Config{VODs: []data.VODConfig{data.VODConfig{LimitSession: 10}}}
+	e := &SimEvaluator{Events: ss, LBOpt: simul.LBOption{LBType: "hash"}}
+
+	// bps limit 이 session 하나만 받을 수 있으면 1개 거부
+	r, err := e.Evaluate(Config(base, 1, 1000000000, 1000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Rejected != 1 {
+		t.Errorf("invalid result %v", r)
+	}
+	r, err = e.Evaluate(Config(base, 1, 1000000000, 1000000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Rejected != 0 || r.HitRatio != 0.5 || r.OriginPeakBps != 1000000 {
+		t.Errorf("invalid result %v", r)
+	}
+
+	// overflow 로 origin 에서 처리한 session 은 거부가 아님
+	overflow := base
+	overflow.Overflow = true
+	r, err = e.Evaluate(Config(overflow, 1, 1000000000, 1000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Rejected != 0 || !(Target{}).Met(r) {
+		t.Errorf("overflow session must not be rejected, %v", r)
+	}
+
+	// filebase 에서 file 이 없어 origin 에서 처리한 session 은 거부가 아님
+	fb := &SimEvaluator{Events: ss, LBOpt: simul.LBOption{
+		LBType:       "filebase",
+		StatDuration: 24 * time.Hour,
+		ShiftPeriod:  time.Hour,
+		PushPeriod:   5 * time.Minute,
+		PushDelayN:   2,
+		DawnPushN:    2,
+	}}
+	r, err = fb.Evaluate(Config(base, 1, 1000000000, 1000000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Rejected != 0 || !(Target{}).Met(r) {
+		t.Errorf("filebase miss session must not be rejected, %v", r)
+	}
+}
@@ -0,0 +1,91 @@
+package simul
+
+import (
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// DefaultABRSafety : 측정 전송률 중 rendition 선택에 사용하는 비율
+const DefaultABRSafety = 0.8
+
+// abrTracker : ladder 가 있는 file 의 session 은 chunk 를 받은 전송률로 다음 chunk 의 rendition 선택
+// 모든 rendition 의 chunk 는 같은 재생 구간(최고 rendition 으로 chunk 크기만큼 재생하는 시간)이며 rendition 별로 따로 cache 됨
+type abrTracker struct {
+	ladders     *data.Ladders
+	safety      float64
+	renditions  map[string]int // sid - 다음 chunk 의 rendition
+	switchCount int64
+	chunkCounts []int64
+}
+
+// SetLadders : abr session model 사용, ladder 가 없는 file 의 session 은 session bps 하나로 재생
+func (s *Simulator) SetLadders(l *data.Ladders) {
+	safety := s.opt.ABRSafety
+	if safety <= 0 {
+		safety = DefaultABRSafety
+	}
+	s.abr = &abrTracker{ladders: l, safety: safety, renditions: make(map[string]int)}
+}
+
+func (s *Simulator) abrLadder(filename string) data.Ladder {
+	if s.abr == nil {
+		return nil
+	}
+	return s.abr.ladders.Get(filename)
+}
+
+// abrChunk : rendition r chunk 의 bps, 크기, 재생 시간, size 는 최고 rendition 의 chunk 크기
+func abrChunk(ladder data.Ladder, size int64, r int) (bps, chunkSize int64, du time.Duration) {
+	top := ladder[len(ladder)-1]
+	du = time.Duration(float64(8*size)/float64(top)*1000) * time.Millisecond
+	return ladder[r], size * ladder[r] / top, du
+}
+
+// observeABR : rendition r chunk 를 fetch 시간 동안 받은 전송률로 다음 chunk 의 rendition 선택
+// 전송률 * safety 이하인 가장 높은 rendition, 없으면 가장 낮은 rendition
+func (s *Simulator) observeABR(sid string, ladder data.Ladder, r int, chunkSize int64, fetch time.Duration) {
+	for len(s.abr.chunkCounts) <= r {
+		s.abr.chunkCounts = append(s.abr.chunkCounts, 0)
+	}
+	s.abr.chunkCounts[r]++
+
+	next := len(ladder) - 1
+	if fetch > 0 {
+		throughput := s.abr.safety * float64(8*chunkSize) / fetch.Seconds()
+		next = 0
+		for i, v := range ladder {
+			if float64(v) <= throughput {
+				next = i
+			}
+		}
+	}
+	s.abr.renditions[sid] = next
+}
+
+// nextRendition : cur 는 이전 chunk 의 rendition
+func (s *Simulator) nextRendition(sid string, cur int) int {
+	r, ok := s.abr.renditions[sid]
+	if !ok {
+		return cur
+	}
+	if r != cur {
+		s.abr.switchCount++
+	}
+	return r
+}
+
+// endSessionABR :
+func (s *Simulator) endSessionABR(sid string) {
+	if s.abr != nil {
+		delete(s.abr.renditions, sid)
+	}
+}
+
+func (a *abrTracker) status() *status.ABRStatus {
+	return &status.ABRStatus{
+		SwitchCount:     a.switchCount,
+		RenditionChunks: append([]int64{}, a.chunkCounts...),
+	}
+}
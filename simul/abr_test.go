@@ -0,0 +1,83 @@
+package simul
+
+import (
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+func TestSimulator_ABR(t *testing.T) {
+	// 최고 rendition 4Mbps, chunk 재생 시간 4초, session 별 chunk 5개
+	ladders := &data.Ladders{Files: map[string]data.Ladder{"a.mpg": data.Ladder{1000000, 4000000}}}
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:00:20.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:21.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		// ladder 가 없는 file 은 session bps 로 재생
+		&glblog.SessionInfo{
+			SID:       "sess-C",
+			Started:   StrToTime("2017-01-01 00:00:02.000"),
+			Ended:     StrToTime("2017-01-01 00:00:10.000"),
+			Filename:  "b.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+
+	tests := []struct {
+		limitBps int64
+		switches int64
+		chunks   []int64
+	}{
+		// 첫 chunk 이후 최고 rendition 으로 전환
+		{limitBps: 20000000, switches: 2, chunks: []int64{2, 8}},
+		// VOD 대역폭이 부족하여 가장 낮은 rendition 유지
+		{limitBps: 4000000, switches: 0, chunks: []int64{10}},
+	}
+	for _, tt := range tests {
+		cfg := data.Config{
+			VODs: []data.VODConfig{
+				data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: tt.limitBps},
+			},
+		}
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &lastStatusWriter{}
+		si := NewSimulator(cfg, Options{}, l, NewTestEventReader(ss), w, nil, nil)
+		si.SetLadders(ladders)
+		si.Run()
+
+		a := w.st.ABR
+		if a == nil || a.SwitchCount != tt.switches || len(a.RenditionChunks) != len(tt.chunks) {
+			t.Fatalf("limit(%v) abr status %+v", tt.limitBps, a)
+		}
+		for i, v := range tt.chunks {
+			if a.RenditionChunks[i] != v {
+				t.Errorf("limit(%v) rendition(%v) chunks %v != %v", tt.limitBps, i, a.RenditionChunks[i], v)
+			}
+		}
+		// sess-B 는 sess-A 와 같은 rendition 의 chunk 를 받으므로 모두 hit
+		c := w.st.Caches[vod.Key("vod1")]
+		if c.CacheHitCount != 5 || c.CacheMissCount != 6 {
+			t.Errorf("limit(%v) cache hit(%v) miss(%v)", tt.limitBps, c.CacheHitCount, c.CacheMissCount)
+		}
+		if len(si.abr.renditions) != 0 {
+			t.Errorf("limit(%v) renditions of ended sessions remain, %v", tt.limitBps, si.abr.renditions)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package simul
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+// checkpointVersion : checkpoint 형식이 바뀌면 증가
+const checkpointVersion = 2
+
+// EventSeeker : checkpoint 위치부터 다시 읽을 수 있는 EventReader
+// 구현하지 않은 reader 는 resume 시 checkpoint 까지의 event 를 다시 읽어 건너뜀
+type EventSeeker interface {
+	Position() []byte      // 마지막으로 읽은 event 의 위치
+	Seek(pos []byte) error // pos 다음 event 부터 읽음
+}
+
+type checkpoint struct {
+	Version         int
+	Options         string
+	Config          string
+	FaultCount      int
+	TopologyCount   int
+	ReadCount       int64
+	ReaderPos       []byte
+	LastT           time.Time
+	NextLogT        time.Time
+	NextCheckpointT time.Time
+	WarmUpEndT      time.Time
+	WarmUpDone      bool
+	SnapshotDone    bool
+	Events          []endEventState
+	FilenameMap     map[string]int
+	FilenameSeed    int
+	FirstHitFiles   []int
+	MoreHitFiles    []int
+	UpdatedHitFileT time.Time
+	FaultIdx        int
+	TopologyIdx     int
+	Origin          originState
+	QoE             qoeState
+	ABR             *abrState
+	Summary         *summaryTracker
+	FileInfos       *data.FileInfos
+	LB              []byte
+}
+
+type trickEventState struct {
+	Time  time.Time
+	Type  glblog.TrickType
+	Index int
+}
+
+type endEventState struct {
+	EndType        endEventType
+	Time           time.Time
+	SID            string
+	Filename       string
+	IntFilename    int
+	Bps            int
+	Index          int
+	Duration       time.Duration
+	SessionEndTime time.Time
+	Bypass         bool
+	Source         lb.ChunkSource
+	IsCenter       bool
+	ChunkSize      int64
+	Rendition      int
+	Tricks         []trickEventState
+	Paused         bool
+}
+
+type originState struct {
+	PeakBps           int64
+	OverT             time.Time
+	OverDuration      time.Duration
+	DelayedChunkCount int64
+	TotalDelay        time.Duration
+	Delays            map[string]time.Duration
+}
+
+type sessionQoEState struct {
+	Startup   time.Duration
+	Rebuffers int64
+	Stall     time.Duration
+}
+
+type qoeState struct {
+	Sessions               map[string]sessionQoEState
+	Startups               []time.Duration
+	Stalls                 []time.Duration
+	RebufferedSessionCount int64
+	RebufferCount          int64
+	TotalStall             time.Duration
+}
+
+type abrState struct {
+	Renditions  map[string]int
+	SwitchCount int64
+	ChunkCounts []int64
+}
+
+// checkpointOptions : 결과에 영향을 주지 않는 option 을 제외한 option, resume 시 같은 option 인지 확인에 사용
+func checkpointOptions(opt Options) string {
+	opt.InfluxDBAddr, opt.InfluxDBName, opt.InfluxDBUser, opt.InfluxDBPass = "", "", "", ""
+	opt.StatusWritePeriod = 0
+	opt.SimulID = ""
+	opt.CheckpointFile, opt.CheckpointPeriod = "", 0
+	opt.SnapshotFile, opt.SummaryFile = "", ""
+	return fmt.Sprintf("%+v", opt)
+}
+
+func checkpointConfig(cfg data.Config) string {
+	b, _ := json.Marshal(cfg)
+	return string(b)
+}
+
+func newEndEventState(e *endEvent) endEventState {
+	st := endEventState{
+		EndType:        e.endType,
+		Time:           e.time,
+		SID:            e.sid,
+		Filename:       e.filename,
+		IntFilename:    e.intFilename,
+		Bps:            e.bps,
+		Index:          e.index,
+		Duration:       e.duration,
+		SessionEndTime: e.sessionEndTime,
+		Bypass:         e.bypass,
+		Source:         e.source,
+		IsCenter:       e.isCenter,
+		ChunkSize:      e.chunkSize,
+		Rendition:      e.rendition,
+		Paused:         e.paused,
+	}
+	for _, v := range e.tricks {
+		st.Tricks = append(st.Tricks, trickEventState{Time: v.time, Type: v.typ, Index: v.index})
+	}
+	return st
+}
+
+func (st endEventState) endEvent() *endEvent {
+	e := &endEvent{
+		endType:        st.EndType,
+		time:           st.Time,
+		sid:            st.SID,
+		filename:       st.Filename,
+		intFilename:    st.IntFilename,
+		bps:            st.Bps,
+		index:          st.Index,
+		duration:       st.Duration,
+		sessionEndTime: st.SessionEndTime,
+		bypass:         st.Bypass,
+		source:         st.Source,
+		isCenter:       st.IsCenter,
+		chunkSize:      st.ChunkSize,
+		rendition:      st.Rendition,
+		paused:         st.Paused,
+	}
+	for _, v := range st.Tricks {
+		e.tricks = append(e.tricks, trickEvent{time: v.Time, typ: v.Type, index: v.Index})
+	}
+	return e
+}
+
+func intKeys(m map[int]struct{}) []int {
+	ret := make([]int, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Ints(ret)
+	return ret
+}
+
+// checkpointDue : 마지막 checkpoint 이후 CheckpointPeriod(simulation 시간) 가 지났는지
+func (s *Simulator) checkpointDue() bool {
+	if s.opt.CheckpointFile == "" || s.opt.CheckpointPeriod <= 0 || s.lastT.IsZero() {
+		return false
+	}
+	if s.nextCheckpointT.IsZero() {
+		s.nextCheckpointT = s.lastT.Add(s.opt.CheckpointPeriod)
+		return false
+	}
+	if s.lastT.Before(s.nextCheckpointT) {
+		return false
+	}
+	for !s.nextCheckpointT.After(s.lastT) {
+		s.nextCheckpointT = s.nextCheckpointT.Add(s.opt.CheckpointPeriod)
+	}
+	return true
+}
+
+// WriteCheckpoint : 현재 상태를 fpath 에 기록, 기록 중 실패해도 이전 checkpoint 는 유지됨
+// LoadBalancer 가 lb.Checkpointer 를 구현해야 함
+func (s *Simulator) WriteCheckpoint(fpath string) error {
+	c, ok := s.lb.(lb.Checkpointer)
+	if !ok {
+		return fmt.Errorf("lb %T does not support checkpoint", s.lb)
+	}
+	lbState, err := c.SaveState()
+	if err != nil {
+		return err
+	}
+	cp := checkpoint{
+		Version:         checkpointVersion,
+		Options:         checkpointOptions(s.opt),
+		Config:          checkpointConfig(s.initCfg),
+		FaultCount:      len(s.faults),
+		TopologyCount:   len(s.topology),
+		ReadCount:       s.evtCount,
+		LastT:           s.lastT,
+		NextLogT:        s.nextLogT,
+		NextCheckpointT: s.nextCheckpointT,
+		WarmUpEndT:      s.warmUpEndT,
+		WarmUpDone:      s.warmUpDone,
+		SnapshotDone:    s.snapshotDone,
+		FilenameMap:     s.filenameMap,
+		FilenameSeed:    s.filenameSeed,
+		FirstHitFiles:   intKeys(s.firstBypass.firstHitFile),
+		MoreHitFiles:    intKeys(s.firstBypass.moreHitFile),
+		UpdatedHitFileT: s.firstBypass.updatedHitFileT,
+		FaultIdx:        s.faultIdx,
+		TopologyIdx:     s.topologyIdx,
+		Origin: originState{
+			PeakBps:           s.origin.peakBps,
+			OverT:             s.origin.overT,
+			OverDuration:      s.origin.overDuration,
+			DelayedChunkCount: s.origin.delayedChunkCount,
+			TotalDelay:        s.origin.totalDelay,
+			Delays:            s.origin.delays,
+		},
+		QoE: qoeState{
+			Sessions:               make(map[string]sessionQoEState),
+			Startups:               s.qoe.startups,
+			Stalls:                 s.qoe.stalls,
+			RebufferedSessionCount: s.qoe.rebufferedSessionCount,
+			RebufferCount:          s.qoe.rebufferCount,
+			TotalStall:             s.qoe.totalStall,
+		},
+		Summary:   s.summary,
+		FileInfos: s.fileInfos,
+		LB:        lbState,
+	}
+	if r, ok := s.reader.(EventSeeker); ok {
+		cp.ReaderPos = r.Position()
+	}
+	// heap 의 배열 순서를 그대로 기록해야 같은 시각 event 의 처리 순서가 유지됨
+	for _, v := range *s.internalEvents {
+		cp.Events = append(cp.Events, newEndEventState(v))
+	}
+	for k, v := range s.qoe.sessions {
+		cp.QoE.Sessions[k] = sessionQoEState{Startup: v.startup, Rebuffers: v.rebuffers, Stall: v.stall}
+	}
+	if s.abr != nil {
+		cp.ABR = &abrState{Renditions: s.abr.renditions, SwitchCount: s.abr.switchCount, ChunkCounts: s.abr.chunkCounts}
+	}
+
+	tmp := fpath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(&cp); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fpath)
+}
+
+// Resume : fpath 의 checkpoint 상태로 복구, Run 전에 호출
+// checkpoint 를 기록한 simulator 와 같은 cfg, option, LB, 장애/topology 일정, ladder 로 생성해야 함
+func (s *Simulator) Resume(fpath string) error {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var cp checkpoint
+	if err := gob.NewDecoder(f).Decode(&cp); err != nil {
+		return fmt.Errorf("failed to decode checkpoint, %v", err)
+	}
+	if cp.Version != checkpointVersion {
+		return fmt.Errorf("invalid checkpoint version %v", cp.Version)
+	}
+	if cp.Options != checkpointOptions(s.opt) {
+		return fmt.Errorf("options are different from checkpoint, %v", cp.Options)
+	}
+	if cp.Config != checkpointConfig(s.initCfg) {
+		return fmt.Errorf("cfg is different from checkpoint, %v", cp.Config)
+	}
+	if cp.FaultCount != len(s.faults) || cp.TopologyCount != len(s.topology) {
+		return fmt.Errorf("fault(%v) or topology(%v) event count is different from checkpoint", cp.FaultCount, cp.TopologyCount)
+	}
+	if (cp.ABR != nil) != (s.abr != nil) {
+		return fmt.Errorf("abr ladders are different from checkpoint")
+	}
+	c, ok := s.lb.(lb.Checkpointer)
+	if !ok {
+		return fmt.Errorf("lb %T does not support checkpoint", s.lb)
+	}
+	if err := c.LoadState(cp.LB); err != nil {
+		return fmt.Errorf("failed to load lb state, %v", err)
+	}
+	if th, ok := s.lb.(lb.TopologyHandler); ok {
+		s.cfg = th.Config()
+	}
+	if s.fileInfos != nil && cp.FileInfos != nil {
+		*s.fileInfos = *cp.FileInfos
+		if s.fileInfos.Infos == nil {
+			s.fileInfos.Infos = make(map[int]*data.FileInfo)
+		}
+		if s.fileInfos.Keys == nil {
+			s.fileInfos.Keys = make(map[string]int)
+		}
+	}
+
+	if r, ok := s.reader.(EventSeeker); ok && cp.ReaderPos != nil {
+		if err := r.Seek(cp.ReaderPos); err != nil {
+			return err
+		}
+	} else {
+		for i := int64(0); i < cp.ReadCount; i++ {
+			if s.reader.ReadEvent() == nil {
+				return fmt.Errorf("event count(%v) is less than checkpoint(%v)", i, cp.ReadCount)
+			}
+		}
+	}
+	s.evtCount = cp.ReadCount
+	s.lastT, s.nextLogT, s.nextCheckpointT = cp.LastT, cp.NextLogT, cp.NextCheckpointT
+	s.warmUpEndT, s.warmUpDone, s.snapshotDone = cp.WarmUpEndT, cp.WarmUpDone, cp.SnapshotDone
+
+	events := make(eventHeap, 0, len(cp.Events))
+	for _, v := range cp.Events {
+		events = append(events, v.endEvent())
+	}
+	*s.internalEvents = events
+
+	s.filenameMap = make(map[string]int)
+	for k, v := range cp.FilenameMap {
+		s.filenameMap[k] = v
+	}
+	s.filenameSeed = cp.FilenameSeed
+	s.firstBypass.firstHitFile = make(map[int]struct{})
+	for _, v := range cp.FirstHitFiles {
+		s.firstBypass.firstHitFile[v] = struct{}{}
+	}
+	s.firstBypass.moreHitFile = make(map[int]struct{})
+	for _, v := range cp.MoreHitFiles {
+		s.firstBypass.moreHitFile[v] = struct{}{}
+	}
+	s.firstBypass.updatedHitFileT = cp.UpdatedHitFileT
+	s.faultIdx, s.topologyIdx = cp.FaultIdx, cp.TopologyIdx
+
+	o := cp.Origin
+	s.origin.peakBps, s.origin.overT, s.origin.overDuration = o.PeakBps, o.OverT, o.OverDuration
+	s.origin.delayedChunkCount, s.origin.totalDelay = o.DelayedChunkCount, o.TotalDelay
+	s.origin.delays = make(map[string]time.Duration)
+	for k, v := range o.Delays {
+		s.origin.delays[k] = v
+	}
+
+	q := cp.QoE
+	s.qoe.sessions = make(map[string]*sessionQoE)
+	for k, v := range q.Sessions {
+		s.qoe.sessions[k] = &sessionQoE{startup: v.Startup, rebuffers: v.Rebuffers, stall: v.Stall}
+	}
+	s.qoe.startups, s.qoe.stalls = q.Startups, q.Stalls
+	s.qoe.rebufferedSessionCount, s.qoe.rebufferCount, s.qoe.totalStall = q.RebufferedSessionCount, q.RebufferCount, q.TotalStall
+
+	if cp.ABR != nil {
+		s.abr.renditions = make(map[string]int)
+		for k, v := range cp.ABR.Renditions {
+			s.abr.renditions[k] = v
+		}
+		s.abr.switchCount, s.abr.chunkCounts = cp.ABR.SwitchCount, cp.ABR.ChunkCounts
+	}
+	if cp.Summary != nil {
+		s.summary = cp.Summary
+		if s.summary.Days == nil {
+			s.summary.Days = make(map[string]*periodCount)
+		}
+		if s.summary.VODs == nil {
+			s.summary.VODs = make(map[vod.Key]*vodSamples)
+		}
+	}
+	log.Printf("resumed from %v, %v events read, simulation time %v\n", fpath, cp.ReadCount, TimeToStr(cp.LastT))
+	return nil
+}
+
+// Position : 마지막으로 읽은 event 의 key, 읽은 event 가 없으면 nil
+func (r *DBEventReader) Position() []byte {
+	if !r.iter.Valid() {
+		return nil
+	}
+	return append([]byte(nil), r.iter.Key()...)
+}
+
+// Seek :
+func (r *DBEventReader) Seek(pos []byte) error {
+	if !r.iter.Seek(pos) || string(r.iter.Key()) != string(pos) {
+		return fmt.Errorf("not exists event of checkpoint position %s", pos)
+	}
+	return nil
+}
+
+// Position :
+func (t *MemEventReader) Position() []byte {
+	return []byte(fmt.Sprint(t.curEventIdx))
+}
+
+// Seek :
+func (t *MemEventReader) Seek(pos []byte) error {
+	var idx int
+	if _, err := fmt.Sscan(string(pos), &idx); err != nil {
+		return err
+	}
+	if idx < 0 || idx > len(t.events) {
+		return fmt.Errorf("invalid event position %v", idx)
+	}
+	t.curEventIdx = idx
+	return nil
+}
@@ -0,0 +1,94 @@
+package simul
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+)
+
+// skipReader : EventSeeker 를 구현하지 않은 reader
+type skipReader struct {
+	EventReader
+}
+
+func TestSimulator_Resume(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 20000000, LimitSession: 10, LimitBps: 20000000, CachePolicy: "lfu", CacheAdmission: "tinylfu"},
+			data.VODConfig{VodID: "vod2", StorageSize: 20000000, LimitSession: 10, LimitBps: 20000000, CachePolicy: "s3fifo"},
+		},
+		OriginLimitBps: 5000000,
+	}
+	r := rand.New(rand.NewSource(1))
+	var ss []*glblog.SessionInfo
+	st := StrToTime("2017-01-01 00:00:00.000")
+	for i := 0; i < 200; i++ {
+		st = st.Add(time.Duration(r.Intn(60)) * time.Second)
+		ss = append(ss, &glblog.SessionInfo{
+			SID:       fmt.Sprintf("sess-%d", i),
+			Started:   st,
+			Ended:     st.Add(time.Duration(10+r.Intn(600)) * time.Second),
+			Filename:  fmt.Sprintf("%d.mpg", r.Intn(10)*r.Intn(4)),
+			Bandwidth: 1000000 + r.Intn(3)*1000000,
+		})
+	}
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newSimulator := func(opt Options, reader EventReader) *Simulator {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewSimulator(cfg, opt, l, reader, nil, nil, nil)
+	}
+	opt := Options{StatusWritePeriod: time.Hour}
+	expected := newSimulator(opt, NewMemEventReader(ss))
+	expected.Run()
+
+	fpath := filepath.Join(dir, "simul.checkpoint")
+	opt.CheckpointFile = fpath
+	opt.CheckpointPeriod = 30 * time.Minute
+	si := newSimulator(opt, NewMemEventReader(ss))
+	si.Run()
+	if !reflect.DeepEqual(si.Status(), expected.Status()) {
+		t.Errorf("checkpoint must not change result")
+	}
+
+	for _, reader := range []EventReader{NewMemEventReader(ss), skipReader{NewMemEventReader(ss)}} {
+		resumed := newSimulator(opt, reader)
+		if err := resumed.Resume(fpath); err != nil {
+			t.Fatal(err)
+		}
+		if resumed.evtCount <= 0 || int(resumed.evtCount) >= len(ss) || resumed.internalEvents.Len() == 0 {
+			t.Errorf("checkpoint must be in the middle of simulation, %v events, %v internal events", resumed.evtCount, resumed.internalEvents.Len())
+		}
+		resumed.Run()
+		if got, want := resumed.Status(), expected.Status(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%T: different status after resume\n%+v\n%+v", reader, got, want)
+		}
+		if got, want := resumed.QoE(), expected.QoE(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%T: different qoe after resume %+v %+v", reader, got, want)
+		}
+		if got, want := resumed.Summary(), expected.Summary(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%T: different summary after resume %+v %+v", reader, got, want)
+		}
+	}
+
+	opt.QoE.Buffer = time.Second
+	if err := newSimulator(opt, NewMemEventReader(ss)).Resume(fpath); err == nil {
+		t.Errorf("resume with different options must be failed")
+	}
+}
@@ -0,0 +1,134 @@
+package simul
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/castisdev/cdn-simul/glblog"
+)
+
+// ChunkRef : 하나의 chunk 요청
+type ChunkRef struct {
+	Time      time.Time
+	SessionID string
+	FileName  string
+	Index     int64
+	Size      int64
+}
+
+// ChunkRefReader : EventReader 의 session 들을 Simulator.Run 과 같은 시각/순서의 chunk 요청들로 펼침
+// trace 만으로 펼치므로 실행 중 상태에 따른 변화는 반영하지 않음
+// origin limit 으로 늦어진 chunk, 거부된 session, fault, topology 변경으로 끝나거나 옮겨진 session 은 trace 그대로 요청되고
+// abr session 의 rendition 은 알 수 없어 chunk 크기, index 는 ladder 가 없을 때의 값이며 요청을 (file, index) 로만 구분함
+type ChunkRefReader struct {
+	reader    EventReader
+	startT    time.Time
+	maxEvents int
+	readCount int
+	next      *glblog.SessionInfo
+	eof       bool
+	chunkEnds *eventHeap
+	sizer     *ChunkSizer
+}
+
+// NewChunkRefReader : startT 이전에 시작된 session 은 무시, maxEvents 가 0 이면 모든 session 처리
+// chunk 크기는 Simulator 와 같은 cfg 로 만든 sizer 를 사용해야 함
+func NewChunkRefReader(r EventReader, sizer *ChunkSizer, startT time.Time, maxEvents int) *ChunkRefReader {
+	h := &eventHeap{}
+	heap.Init(h)
+	return &ChunkRefReader{
+		reader:    r,
+		startT:    startT,
+		maxEvents: maxEvents,
+		chunkEnds: h,
+		sizer:     sizer,
+	}
+}
+
+func (r *ChunkRefReader) peekSession() *glblog.SessionInfo {
+	for r.next == nil && !r.eof {
+		r.readCount++
+		if r.maxEvents != 0 && r.readCount > r.maxEvents {
+			r.eof = true
+			break
+		}
+		ev := r.reader.ReadEvent()
+		if ev == nil {
+			r.eof = true
+			break
+		}
+		if r.startT.IsZero() == false && r.startT.After(ev.Started) {
+			continue
+		}
+		r.next = ev
+	}
+	return r.next
+}
+
+// ReadChunkRef : 더 이상 chunk 요청이 없으면 nil
+func (r *ChunkRefReader) ReadChunkRef() *ChunkRef {
+	diffLastChunkTandSessionEndT := time.Millisecond
+	for {
+		ev := r.peekSession()
+		if r.chunkEnds.Len() > 0 && (ev == nil || !(*r.chunkEnds)[0].time.After(ev.Started)) {
+			endEv := heap.Pop(r.chunkEnds).(*endEvent)
+			if endEv.endType == chunkEnd {
+				if endEv.sessionEndTime.Sub(endEv.time) == diffLastChunkTandSessionEndT {
+					continue
+				}
+				endEv.index++
+			}
+			if endEv.pauseChunk(r.chunkEnds) {
+				continue
+			}
+			ref := &ChunkRef{
+				Time:      endEv.time,
+				SessionID: endEv.sid,
+				FileName:  endEv.filename,
+				Index:     int64(endEv.index),
+				Size:      endEv.chunkSize,
+			}
+			nextEndT := endEv.time.Add(endEv.duration)
+			if nextEndT.Before(endEv.sessionEndTime) {
+				endEv.time = nextEndT
+			} else {
+				endEv.time = endEv.sessionEndTime.Add(-diffLastChunkTandSessionEndT)
+			}
+			heap.Push(r.chunkEnds, endEv)
+			return ref
+		}
+		if ev == nil {
+			return nil
+		}
+		r.next = nil
+
+		size := r.sizer.Size(ev.Filename)
+		idx := int(ev.Offset / size)
+		du := time.Duration(float64(8*size)/float64(ev.Bandwidth)*1000) * time.Millisecond
+		ecEv := &endEvent{
+			time:           ev.Started.Add(du),
+			endType:        chunkEnd,
+			sid:            ev.SID,
+			filename:       ev.Filename,
+			bps:            ev.Bandwidth,
+			index:          idx,
+			duration:       du,
+			sessionEndTime: ev.Ended,
+			chunkSize:      size,
+			tricks: newTrickEvents(ev.Tricks, func(offset int64) int {
+				return int(offset / size)
+			}),
+		}
+		if ecEv.time.Sub(ev.Ended) >= 0 {
+			ecEv.time = ev.Ended.Add(-diffLastChunkTandSessionEndT)
+		}
+		heap.Push(r.chunkEnds, ecEv)
+		return &ChunkRef{
+			Time:      ev.Started,
+			SessionID: ev.SID,
+			FileName:  ev.Filename,
+			Index:     int64(idx),
+			Size:      size,
+		}
+	}
+}
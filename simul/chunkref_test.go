@@ -0,0 +1,76 @@
+package simul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+)
+
+func TestChunkRefReader(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10000, LimitBps: 1000000000000}},
+	}
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-1",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:00:10.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 10000000,
+			Offset:    0,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-2",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:05.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 10000000,
+			Offset:    4000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-3",
+			Started:   StrToTime("2017-01-01 00:00:02.000"),
+			Ended:     StrToTime("2017-01-01 00:00:03.000"),
+			Filename:  "b.mpg",
+			Bandwidth: 5000000,
+			Offset:    0,
+		},
+	}
+
+	l, err := lb.New(cfg, &lb.SameHashingWeight{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := NewSimulator(cfg, Options{StatusWritePeriod: time.Hour}, l, NewTestEventReader(ss), nil, nil, nil)
+	si.Run()
+	c := l.(*lb.LB).Caches["vod1"]
+
+	r := NewChunkRefReader(NewTestEventReader(ss), testChunkSizer(t), time.Time{}, 0)
+	var refs []*ChunkRef
+	for {
+		ref := r.ReadChunkRef()
+		if ref == nil {
+			break
+		}
+		if len(refs) > 0 && ref.Time.Before(refs[len(refs)-1].Time) {
+			t.Errorf("not ordered by time, %v < %v", ref.Time, refs[len(refs)-1].Time)
+		}
+		refs = append(refs, ref)
+	}
+	if int64(len(refs)) != c.HitCount+c.MissCount {
+		t.Errorf("%v != %v", c.HitCount+c.MissCount, len(refs))
+	}
+	if refs[0].SessionID != "sess-1" || refs[0].Index != 0 {
+		t.Errorf("invalid first chunk, %v", refs[0])
+	}
+	// sess-1 의 두번째 chunk 는 1.6초에 시작되므로 sess-2 의 첫 chunk 가 먼저
+	if refs[1].SessionID != "sess-2" || refs[1].Index != 2 {
+		t.Errorf("invalid second chunk, %v", refs[1])
+	}
+	if refs[2].SessionID != "sess-1" || refs[2].Index != 1 || !refs[2].Time.Equal(StrToTime("2017-01-01 00:00:01.600")) {
+		t.Errorf("invalid third chunk, %v", refs[2])
+	}
+}
@@ -0,0 +1,75 @@
+package simul
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+// ChunkSizer : file 별 chunk 크기, cfg 의 chunkClasses 중 처음 match 되는 class 의 크기, 없으면 cfg 의 chunkSize
+type ChunkSizer struct {
+	size    int64
+	classes []data.ChunkClassConfig
+	sizes   map[string]int64
+}
+
+// NewChunkSizer :
+func NewChunkSizer(cfg data.Config) (*ChunkSizer, error) {
+	size := cfg.ChunkSize
+	if size == 0 {
+		size = data.DefaultChunkSize
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid chunk size %v", size)
+	}
+	for _, v := range cfg.ChunkClasses {
+		if v.ChunkSize <= 0 {
+			return nil, fmt.Errorf("invalid chunk size %v of class %v", v.ChunkSize, v.Pattern)
+		}
+		if _, err := path.Match(v.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid chunk class pattern %v, %v", v.Pattern, err)
+		}
+	}
+	return &ChunkSizer{size: size, classes: cfg.ChunkClasses, sizes: make(map[string]int64)}, nil
+}
+
+// Size :
+func (c *ChunkSizer) Size(filename string) int64 {
+	if len(c.classes) == 0 {
+		return c.size
+	}
+	if v, ok := c.sizes[filename]; ok {
+		return v
+	}
+	size := c.size
+	for _, v := range c.classes {
+		if ok, _ := path.Match(v.Pattern, filename); ok {
+			size = v.ChunkSize
+			break
+		}
+	}
+	c.sizes[filename] = size
+	return size
+}
+
+// CheckLogMeta : event db 를 만든 로그 가공 도구의 chunk 크기가 cfg 의 chunkSize, chunkClasses 의 크기와 다르면 error
+// event db 의 offset 은 byte 단위라 chunk 크기와 무관하므로 호출하는 쪽은 경고로만 출력
+func CheckLogMeta(cfg data.Config, meta *data.LogMeta) error {
+	if meta == nil {
+		return nil
+	}
+	size := cfg.ChunkSize
+	if size == 0 {
+		size = data.DefaultChunkSize
+	}
+	if meta.ChunkSize != size {
+		return fmt.Errorf("chunk size of event db(%v) != chunk size of cfg(%v)", meta.ChunkSize, size)
+	}
+	for _, v := range cfg.ChunkClasses {
+		if meta.ChunkSize != v.ChunkSize {
+			return fmt.Errorf("chunk size of event db(%v) != chunk size of class %v(%v)", meta.ChunkSize, v.Pattern, v.ChunkSize)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package simul
+
+import (
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+func testChunkSizer(t *testing.T) *ChunkSizer {
+	c, err := NewChunkSizer(data.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestChunkSizer(t *testing.T) {
+	cfg := data.Config{
+		ChunkSize: 1000000,
+		ChunkClasses: []data.ChunkClassConfig{
+			data.ChunkClassConfig{Pattern: "*HD.mpg", ChunkSize: 4000000},
+			data.ChunkClassConfig{Pattern: "*.mpg", ChunkSize: 3000000},
+		},
+	}
+	c, err := NewChunkSizer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, exp := range map[string]int64{"aHD.mpg": 4000000, "a.mpg": 3000000, "a.ts": 1000000} {
+		if v := c.Size(file); v != exp {
+			t.Errorf("%v size %v != %v", file, v, exp)
+		}
+	}
+	if v := testChunkSizer(t).Size("a.mpg"); v != data.DefaultChunkSize {
+		t.Errorf("default size %v != %v", v, data.DefaultChunkSize)
+	}
+
+	for _, cls := range []data.ChunkClassConfig{
+		data.ChunkClassConfig{Pattern: "[", ChunkSize: 1},
+		data.ChunkClassConfig{Pattern: "*", ChunkSize: 0},
+	} {
+		if _, err := NewChunkSizer(data.Config{ChunkClasses: []data.ChunkClassConfig{cls}}); err == nil {
+			t.Errorf("%+v must be failed", cls)
+		}
+	}
+}
+
+func TestCheckLogMeta(t *testing.T) {
+	if err := CheckLogMeta(data.Config{}, nil); err != nil {
+		t.Errorf("no meta must be passed, %v", err)
+	}
+	if err := CheckLogMeta(data.Config{}, &data.LogMeta{ChunkSize: data.DefaultChunkSize}); err != nil {
+		t.Error(err)
+	}
+	if err := CheckLogMeta(data.Config{ChunkSize: 1000000}, &data.LogMeta{ChunkSize: data.DefaultChunkSize}); err == nil {
+		t.Errorf("different chunk size must be failed")
+	}
+	cfg := data.Config{ChunkClasses: []data.ChunkClassConfig{data.ChunkClassConfig{Pattern: "*.ts", ChunkSize: 1000000}}}
+	if err := CheckLogMeta(cfg, &data.LogMeta{ChunkSize: data.DefaultChunkSize}); err == nil {
+		t.Errorf("different chunk size of class must be failed")
+	}
+}
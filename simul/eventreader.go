@@ -3,6 +3,7 @@ package simul
 import (
 	"bytes"
 	"encoding/gob"
+	"fmt"
 	"log"
 
 	"github.com/castisdev/cdn-simul/glblog"
@@ -42,22 +43,68 @@ func (r *DBEventReader) ReadEvent() *glblog.SessionInfo {
 	return &e
 }
 
-// TestEventReader :
-type TestEventReader struct {
+var eventKeyLayout = "2006-01-02 15:04:05.000"
+
+// WriteDBEvents : r 의 모든 session 을 glb-log-manip 과 같은 key(시작 시각 + SID) 로 기록, 기록한 session 수 반환
+func WriteDBEvents(db *leveldb.DB, r EventReader) (int, error) {
+	count := 0
+	batch := new(leveldb.Batch)
+	for {
+		e := r.ReadEvent()
+		if e == nil {
+			break
+		}
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		if err := enc.Encode(e); err != nil {
+			return count, fmt.Errorf("failed to encode event, %v", err)
+		}
+		batch.Put([]byte(e.Started.Format(eventKeyLayout)+e.SID), buf.Bytes())
+		count++
+		if batch.Len() >= 10000 {
+			if err := db.Write(batch, nil); err != nil {
+				return count, err
+			}
+			batch.Reset()
+		}
+	}
+	return count, db.Write(batch, nil)
+}
+
+// MemEventReader : memory 에 읽어둔 event 를 순서대로 읽음
+// simulator 는 event 를 바꾸지 않으므로 여러 reader 가 같은 events 를 동시에 읽을 수 있음
+type MemEventReader struct {
 	curEventIdx int
 	events      []*glblog.SessionInfo
 }
 
-// NewTestEventReader :
-func NewTestEventReader(evt []*glblog.SessionInfo) EventReader {
-	return &TestEventReader{events: evt}
+// NewMemEventReader :
+func NewMemEventReader(evt []*glblog.SessionInfo) *MemEventReader {
+	return &MemEventReader{events: evt}
 }
 
 // ReadEvent :
-func (t *TestEventReader) ReadEvent() *glblog.SessionInfo {
+func (t *MemEventReader) ReadEvent() *glblog.SessionInfo {
 	t.curEventIdx++
 	if t.curEventIdx > len(t.events) {
 		return nil
 	}
 	return t.events[t.curEventIdx-1]
 }
+
+// NewTestEventReader :
+func NewTestEventReader(evt []*glblog.SessionInfo) EventReader {
+	return NewMemEventReader(evt)
+}
+
+// LoadEvents : reader 의 event 를 모두 memory 에 읽음
+func LoadEvents(r EventReader) []*glblog.SessionInfo {
+	var ret []*glblog.SessionInfo
+	for {
+		ev := r.ReadEvent()
+		if ev == nil {
+			return ret
+		}
+		ret = append(ret, ev)
+	}
+}
@@ -0,0 +1,182 @@
+package simul
+
+import (
+	"container/heap"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+type faultEvent struct {
+	time      time.Time
+	vodID     string
+	down      bool
+	wipeCache bool
+}
+
+// SetFaults : 장애(점검) 일정 설정, LoadBalancer 가 lb.FaultHandler 를 구현해야 함
+func (s *Simulator) SetFaults(faults []*data.FaultEvent) error {
+	if _, ok := s.lb.(lb.FaultHandler); !ok {
+		return fmt.Errorf("loadbalancer does not support vod fault")
+	}
+	var events []*faultEvent
+	for _, v := range faults {
+		if FindConfig(&s.cfg, v.VodID).VodID == "" {
+			return fmt.Errorf("not exists vod %v", v.VodID)
+		}
+		events = append(events,
+			&faultEvent{time: v.DownTime, vodID: v.VodID, down: true},
+			&faultEvent{time: v.UpTime, vodID: v.VodID, wipeCache: v.WipeCache})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].time.Before(events[j].time) })
+	s.faults = events
+	s.faultIdx = 0
+	return nil
+}
+
+func (s *Simulator) applyFault(f *faultEvent) {
+	fh := s.lb.(lb.FaultHandler)
+	key := vod.Key(f.vodID)
+	if !f.down {
+		if err := fh.UpVOD(key, f.wipeCache); err != nil {
+			log.Fatalf("failed to up vod, %v", err)
+		}
+		fmt.Printf("%s vod up: %v wipe-cache(%v)\n", TimeToStr(f.time), f.vodID, f.wipeCache)
+		return
+	}
+
+	if err := fh.DownVOD(key); err != nil {
+		log.Fatalf("failed to down vod, %v", err)
+	}
+	sessions, migrated := s.evacuate(f.time, key)
+	fmt.Printf("%s vod down: %v sessions(%v) migrated(%v) terminated(%v)\n",
+		TimeToStr(f.time), f.vodID, sessions, migrated, sessions-migrated)
+}
+
+// evacuate : 장애 VOD 의 session 을 모두 종료하거나 이전, VOD 는 미리 DownVOD 되어 있어야 함
+func (s *Simulator) evacuate(t time.Time, key vod.Key) (sessions, migrated int) {
+	sids := s.lb.(lb.FaultHandler).Sessions(key)
+	sidMap := make(map[string]struct{})
+	for _, v := range sids {
+		sidMap[v] = struct{}{}
+	}
+	chunks := make(map[string]*endEvent)
+	resumes := make(map[string]*endEvent) // trick-play 로 chunk 요청이 멈춘 session
+	ends := make(map[string]*endEvent)
+	for _, v := range *s.internalEvents {
+		if _, ok := sidMap[v.sid]; !ok {
+			continue
+		}
+		switch v.endType {
+		case chunkEnd:
+			chunks[v.sid] = v
+		case chunkResume:
+			resumes[v.sid] = v
+		default:
+			ends[v.sid] = v
+		}
+	}
+
+	removed := make(map[*endEvent]struct{})
+	for _, sid := range sids {
+		if s.stopSession(t, chunks[sid], ends[sid]) {
+			migrated++
+			continue
+		}
+		if v, ok := chunks[sid]; ok {
+			removed[v] = struct{}{}
+		}
+		if v, ok := resumes[sid]; ok {
+			removed[v] = struct{}{}
+		}
+		removed[ends[sid]] = struct{}{}
+	}
+	if len(removed) == 0 && migrated > 0 {
+		heap.Init(s.internalEvents)
+	}
+	if len(removed) > 0 {
+		var events eventHeap
+		for _, v := range *s.internalEvents {
+			if _, ok := removed[v]; !ok {
+				events = append(events, v)
+			}
+		}
+		*s.internalEvents = events
+		heap.Init(s.internalEvents)
+	}
+	return len(sids), migrated
+}
+
+// stopSession : 장애 VOD 의 session 을 종료, FaultMigrate 이면 다른 VOD 로 이전
+// 이전에 성공하면 true, chunk 는 마지막 chunk 가 끝난 경우 nil, 이전하면 chunk 의 종료 시각이 늦어질 수 있음
+func (s *Simulator) stopSession(t time.Time, chunk, end *endEvent) bool {
+	var cEvt data.ChunkEvent
+	if chunk != nil {
+		cEvt = data.ChunkEvent{
+			Time:        t,
+			SessionID:   chunk.sid,
+			FileName:    chunk.filename,
+			IntFileName: chunk.intFilename,
+			Bps:         int64(chunk.bps),
+			Index:       int64(chunk.index),
+			ChunkSize:   chunk.chunkSize,
+			Rendition:   chunk.rendition,
+			Bypass:      chunk.bypass,
+			IsCenter:    chunk.isCenter,
+		}
+		if err := s.lb.EndChunk(&cEvt, chunk.source); err != nil {
+			log.Fatalf("failed to process end-chunk-event, %v", err)
+		}
+		s.endOriginChunk(t, chunk.source)
+	}
+	sEvt := data.SessionEvent{
+		Time:        t,
+		SessionID:   end.sid,
+		FileName:    end.filename,
+		IntFileName: end.intFilename,
+		Bps:         int64(end.bps),
+		Duration:    end.duration - end.time.Sub(t),
+	}
+	if err := s.lb.EndSession(&sEvt); err != nil {
+		log.Fatalf("failed to process end-sesison-event, %v", err)
+	}
+	if !s.opt.FaultMigrate {
+		s.origin.takeDelay(end.sid)
+		s.endSessionQoE(end.sid)
+		s.endSessionABR(end.sid)
+		return false
+	}
+
+	sEvt.Duration = end.time.Sub(t)
+	err := s.lb.StartSession(&sEvt)
+	if err == lb.ErrRejected {
+		s.origin.takeDelay(end.sid)
+		s.endSessionQoE(end.sid)
+		s.endSessionABR(end.sid)
+		return false
+	} else if err != nil {
+		log.Fatalf("failed to process start-session-event, %v", err)
+	}
+	if chunk != nil {
+		chunk.source, err = s.lb.StartChunk(&cEvt)
+		if err != nil {
+			log.Fatalf("failed to process start-chunk-event, %v", err)
+		}
+		// 이전한 VOD 에서 다시 받는 chunk 도 origin limit 초과 시 늦어짐
+		if delay := s.startOriginChunk(t, chunk.duration, chunk.source); delay > 0 {
+			chunk.sessionEndTime = chunk.sessionEndTime.Add(delay)
+			s.origin.delays[chunk.sid] += delay
+			chunk.time = chunk.time.Add(delay)
+		}
+		fetch := s.startChunkQoE(t, chunk.sid, cEvt.Bps, chunk.chunkSize, chunk.duration, chunk.source)
+		if ladder := s.abrLadder(chunk.filename); ladder != nil {
+			s.observeABR(chunk.sid, ladder, chunk.rendition, chunk.chunkSize, fetch)
+		}
+	}
+	return true
+}
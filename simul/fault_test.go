@@ -0,0 +1,136 @@
+package simul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+)
+
+func TestSimulator_Fault(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000},
+			data.VODConfig{VodID: "vod2", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000},
+		},
+	}
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:10.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:00:20.000"),
+			Ended:     StrToTime("2017-01-01 00:00:25.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+	// sess-A 는 vod2 장애로 vod1 에서 시작, vod1 장애 시 vod2 로 이전
+	// sess-B 는 vod1, vod2 모두 장애
+	faults := []*data.FaultEvent{
+		&data.FaultEvent{VodID: "vod2", DownTime: StrToTime("2017-01-01 00:00:00.000"), UpTime: StrToTime("2017-01-01 00:00:01.500")},
+		&data.FaultEvent{VodID: "vod1", DownTime: StrToTime("2017-01-01 00:00:02.000"), UpTime: StrToTime("2017-01-01 00:00:30.000")},
+		&data.FaultEvent{VodID: "vod2", DownTime: StrToTime("2017-01-01 00:00:15.000"), UpTime: StrToTime("2017-01-01 00:00:30.000"), WipeCache: true},
+	}
+
+	for _, migrate := range []bool{false, true} {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		si := NewSimulator(cfg, Options{FaultMigrate: migrate}, l, NewTestEventReader(ss), nil, nil, nil)
+		if err := si.SetFaults(faults); err != nil {
+			t.Fatal(err)
+		}
+		si.Run()
+
+		st := l.Status(StrToTime("2017-01-01 00:00:30.000"))
+		if st.Reject.VODDownCount != 1 {
+			t.Errorf("migrate(%v) reject status %+v", migrate, *st.Reject)
+		}
+		for _, v := range st.Vods {
+			if v.CurSessionCount != 0 || v.CurBps != 0 || v.Down {
+				t.Errorf("migrate(%v) vod status %+v", migrate, *v)
+			}
+		}
+		if c := st.Caches["vod1"]; c.CacheMissCount != 1 {
+			t.Errorf("migrate(%v) vod1 cache status %+v", migrate, *c)
+		}
+		c := st.Caches["vod2"]
+		if migrate && (c.CacheMissCount != 1 || c.CurSize != 0) {
+			t.Errorf("migrated session must be served by vod2 and its cache must be wiped, %+v", *c)
+		}
+		if !migrate && c.CacheMissCount != 0 {
+			t.Errorf("terminated session must not be served by vod2, %+v", *c)
+		}
+	}
+
+	l, _ := lb.NewLegacyLB(cfg, nil)
+	si := NewSimulator(cfg, Options{}, l, NewTestEventReader(ss), nil, nil, nil)
+	if err := si.SetFaults(faults); err == nil {
+		t.Errorf("legacy loadbalancer must not support fault")
+	}
+}
+
+func TestSimulator_Fault_OriginLimit(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000},
+			data.VODConfig{VodID: "vod2", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000},
+		},
+		OriginLimitBps: 1000000,
+	}
+	session := func(sid, filename, started, ended string) *glblog.SessionInfo {
+		return &glblog.SessionInfo{SID: sid, Started: StrToTime(started), Ended: StrToTime(ended), Filename: filename, Bandwidth: 1000000}
+	}
+	// sess-A, sess-B 는 vod1 에서 origin 요구량 2Mbps 로 limit 초과, vod1 장애 시 종료 또는 vod2 로 이전
+	// sess-C 는 장애 복구 후 limit 이하
+	ss := []*glblog.SessionInfo{
+		session("sess-A", "a.mpg", "2017-01-01 00:00:01.000", "2017-01-01 00:00:10.000"),
+		session("sess-B", "b.mpg", "2017-01-01 00:00:01.000", "2017-01-01 00:00:10.000"),
+		session("sess-C", "c.mpg", "2017-01-01 00:01:40.000", "2017-01-01 00:01:41.000"),
+	}
+	faults := []*data.FaultEvent{
+		&data.FaultEvent{VodID: "vod2", DownTime: StrToTime("2017-01-01 00:00:00.000"), UpTime: StrToTime("2017-01-01 00:00:01.500")},
+		&data.FaultEvent{VodID: "vod1", DownTime: StrToTime("2017-01-01 00:00:05.000"), UpTime: StrToTime("2017-01-01 00:01:30.000")},
+	}
+
+	for _, migrate := range []bool{false, true} {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &lastStatusWriter{}
+		si := NewSimulator(cfg, Options{FaultMigrate: migrate}, l, NewTestEventReader(ss), w, nil, nil)
+		if err := si.SetFaults(faults); err != nil {
+			t.Fatal(err)
+		}
+		si.Run()
+
+		o := w.st.Origin
+		if o.Bps != 0 {
+			t.Errorf("migrate(%v) origin bps must be 0, %+v", migrate, *o)
+		}
+		if !migrate {
+			// 종료된 session 의 chunk 가 끝나면 limit 초과도 끝남
+			if o.OverLimitDuration != 4*time.Second || o.DelayedChunkCount != 1 {
+				t.Errorf("terminated chunks must end over-limit, %+v", *o)
+			}
+			continue
+		}
+		// vod2 에서 다시 받는 chunk 2개도 limit 초과로 늦어짐
+		if o.PeakBps != 2000000 || o.DelayedChunkCount < 3 || o.TotalDelay < 3*16*time.Second {
+			t.Errorf("migrated chunks must be delayed, %+v", *o)
+		}
+		if w.t.Before(StrToTime("2017-01-01 00:00:42.000")) {
+			t.Errorf("migrated sessions must be ended late, %v", TimeToStr(w.t))
+		}
+	}
+}
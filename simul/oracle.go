@@ -0,0 +1,62 @@
+package simul
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+)
+
+type oracleKey struct {
+	file  string
+	index int64
+}
+
+// Oracle : trace 를 미리 읽어 chunk 별 요청 시각을 기록, OPT cache policy 에서 사용
+type Oracle struct {
+	refs map[oracleKey][]int64 // 요청 시각(UnixNano) 목록, 시간순
+}
+
+// NewOracle : Simulator.Run 과 같은 sizer, startT, maxEvents 를 사용해야 같은 chunk 요청을 얻을 수 있음
+func NewOracle(r EventReader, sizer *ChunkSizer, startT time.Time, maxEvents int) *Oracle {
+	o := &Oracle{refs: make(map[oracleKey][]int64)}
+	cr := NewChunkRefReader(r, sizer, startT, maxEvents)
+	count := 0
+	for {
+		ref := cr.ReadChunkRef()
+		if ref == nil {
+			break
+		}
+		k := oracleKey{file: ref.FileName, index: ref.Index}
+		o.refs[k] = append(o.refs[k], ref.Time.UnixNano())
+		count++
+	}
+	fmt.Printf("oracle created, chunks(%v) references(%v)\n", len(o.refs), count)
+	return o
+}
+
+// CheckOracleConfig : oracle 은 simulation 전에 trace 의 chunk 요청을 미리 펼친 것이므로
+// 실행 중 상태에 따라 chunk 요청 시각이나 session 이 바뀌는 설정에서는 실제 요청과 달라짐
+// origin limit 은 chunk 전송을 늦추고, overflow 가 아니면 거부된 session 의 chunk 요청이 사라짐
+// fault, topology, abr 도 같은 이유로 oracle 과 함께 사용할 수 없음 (호출하는 쪽에서 확인)
+func CheckOracleConfig(cfg data.Config) error {
+	if cfg.OriginLimitBps > 0 {
+		return fmt.Errorf("origin limit delays chunk requests")
+	}
+	if !cfg.Overflow {
+		return fmt.Errorf("rejected sessions remove chunk requests, overflow must be used")
+	}
+	return nil
+}
+
+// NextRef :
+func (o *Oracle) NextRef(fileName string, index int64, t time.Time) time.Time {
+	times := o.refs[oracleKey{file: fileName, index: index}]
+	n := t.UnixNano()
+	i := sort.Search(len(times), func(i int) bool { return times[i] > n })
+	if i == len(times) {
+		return time.Time{}
+	}
+	return time.Unix(0, times[i])
+}
@@ -0,0 +1,89 @@
+package simul
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/lb/cache"
+)
+
+func TestOracle_NextRef(t *testing.T) {
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{SID: "sess-1", Started: StrToTime("2017-01-01 00:00:00.000"), Ended: StrToTime("2017-01-01 00:00:01.000"),
+			Filename: "a.mpg", Bandwidth: 10000000},
+		&glblog.SessionInfo{SID: "sess-2", Started: StrToTime("2017-01-01 00:00:05.000"), Ended: StrToTime("2017-01-01 00:00:06.000"),
+			Filename: "a.mpg", Bandwidth: 10000000},
+	}
+	o := NewOracle(NewTestEventReader(ss), testChunkSizer(t), time.Time{}, 0)
+	next := o.NextRef("a.mpg", 0, StrToTime("2017-01-01 00:00:00.000"))
+	if !next.Equal(StrToTime("2017-01-01 00:00:05.000")) {
+		t.Errorf("%v != %v", StrToTime("2017-01-01 00:00:05.000"), next)
+	}
+	if next := o.NextRef("a.mpg", 0, StrToTime("2017-01-01 00:00:05.000")); !next.IsZero() {
+		t.Errorf("must be zero, %v", next)
+	}
+}
+
+func TestSimulator_Run_OPT(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var ss []*glblog.SessionInfo
+	st := StrToTime("2017-01-01 00:00:00.000")
+	for i := 0; i < 300; i++ {
+		st = st.Add(time.Duration(r.Intn(3000)) * time.Millisecond)
+		ss = append(ss, &glblog.SessionInfo{
+			SID:       fmt.Sprintf("sess-%d", i),
+			Started:   st,
+			Ended:     st.Add(time.Duration(1+r.Intn(5)) * time.Second),
+			Filename:  fmt.Sprintf("%d.mpg", int(r.ExpFloat64()*5)),
+			Bandwidth: 10000000,
+		})
+	}
+
+	hitCount := func(policy string, oracle *Oracle) int64 {
+		cfg := data.Config{
+			VODs: []data.VODConfig{data.VODConfig{VodID: "vod1", StorageSize: 5 * data.DefaultChunkSize, LimitSession: 10000, LimitBps: 1000000000000, CachePolicy: policy}},
+		}
+		l, err := NewLoadBalancer(LBOption{Cfg: cfg, LBType: "hash", Oracle: oracle})
+		if err != nil {
+			t.Fatal(err)
+		}
+		si := NewSimulator(cfg, Options{StatusWritePeriod: time.Hour}, l, NewTestEventReader(ss), nil, nil, nil)
+		si.Run()
+		return l.(*lb.LB).Caches["vod1"].HitCount
+	}
+
+	o := NewOracle(NewTestEventReader(ss), testChunkSizer(t), time.Time{}, 0)
+	lru := hitCount(cache.PolicyLRU, nil)
+	opt := hitCount(cache.PolicyOPT, o)
+	optSize := hitCount(cache.PolicyOPTSize, o)
+	if opt < lru {
+		t.Errorf("opt hit(%v) < lru hit(%v)", opt, lru)
+	}
+	if optSize < lru {
+		t.Errorf("opt-size hit(%v) < lru hit(%v)", optSize, lru)
+	}
+	if _, err := NewLoadBalancer(LBOption{Cfg: data.Config{VODs: []data.VODConfig{data.VODConfig{VodID: "vod1", StorageSize: 10, CachePolicy: cache.PolicyOPT}}}}); err == nil {
+		t.Errorf("opt policy without oracle must be failed")
+	}
+}
+
+func TestCheckOracleConfig(t *testing.T) {
+	tests := []struct {
+		cfg     data.Config
+		wantErr bool
+	}{
+		{data.Config{Overflow: true}, false},
+		{data.Config{}, true},
+		{data.Config{Overflow: true, OriginLimitBps: 1000000}, true},
+	}
+	for i, tt := range tests {
+		if err := CheckOracleConfig(tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("[%v] %+v: %v", i, tt.cfg, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package simul
+
+import (
+	"time"
+
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// originLink : origin 요구량이 limit 을 넘으면 origin 에서 받는 chunk 는 limit/요구량 비율의 대역폭만 받는 것으로 보고
+// chunk 전송 시간을 늘림, session 종료도 같은 만큼 늦어짐
+// 전송 시간은 chunk 시작 시점의 요구량으로 정하며 parent 에서 hit 된 chunk 는 origin link 를 쓰지 않으므로 늦어지지 않음
+type originLink struct {
+	limitBps          int64
+	peakBps           int64
+	overT             time.Time // limit 을 넘기 시작한 시각, limit 이하이면 zero
+	overDuration      time.Duration
+	delayedChunkCount int64
+	totalDelay        time.Duration
+	delays            map[string]time.Duration // session 별 아직 session end event 에 반영되지 않은 지연
+}
+
+func newOriginLink(limitBps int64) *originLink {
+	return &originLink{limitBps: limitBps, delays: make(map[string]time.Duration)}
+}
+
+// observe : t 시점의 origin 요구량 기록
+func (o *originLink) observe(t time.Time, bps int64) {
+	if bps > o.peakBps {
+		o.peakBps = bps
+	}
+	over := o.limitBps > 0 && bps > o.limitBps
+	if over && o.overT.IsZero() {
+		o.overT = t
+	} else if !over && !o.overT.IsZero() {
+		o.overDuration += t.Sub(o.overT)
+		o.overT = time.Time{}
+	}
+}
+
+// delay : origin 요구량이 bps 일 때 du 동안 전송할 chunk 의 추가 전송 시간
+func (o *originLink) delay(du time.Duration, bps int64) time.Duration {
+	if o.limitBps <= 0 || bps <= o.limitBps {
+		return 0
+	}
+	d := time.Duration(float64(du) * (float64(bps)/float64(o.limitBps) - 1))
+	o.delayedChunkCount++
+	o.totalDelay += d
+	return d
+}
+
+// takeDelay : session end event 에 반영할 지연
+func (o *originLink) takeDelay(sid string) time.Duration {
+	d := o.delays[sid]
+	delete(o.delays, sid)
+	return d
+}
+
+func (o *originLink) fillStatus(st *status.OriginStatus, t time.Time) {
+	st.LimitBps = o.limitBps
+	st.PeakBps = o.peakBps
+	st.OverLimitDuration = o.overDuration
+	if !o.overT.IsZero() && t.After(o.overT) {
+		st.OverLimitDuration += t.Sub(o.overT)
+	}
+	st.DelayedChunkCount = o.delayedChunkCount
+	st.TotalDelay = o.totalDelay
+}
+
+func (s *Simulator) originBps(t time.Time) int64 {
+	if r, ok := s.lb.(lb.OriginReporter); ok {
+		return r.OriginBps()
+	}
+	return s.lb.Status(t).Origin.Bps
+}
+
+// startOriginChunk : chunk 시작 후 origin 요구량 기록, origin 에서 받는 chunk 이면 추가 전송 시간 반환
+func (s *Simulator) startOriginChunk(t time.Time, du time.Duration, src lb.ChunkSource) time.Duration {
+	if src != lb.SourceOrigin {
+		return 0
+	}
+	bps := s.originBps(t)
+	s.origin.observe(t, bps)
+	s.summaryOrigin(t, bps)
+	return s.origin.delay(du, bps)
+}
+
+// endOriginChunk : chunk 종료 후 origin 요구량 기록
+func (s *Simulator) endOriginChunk(t time.Time, src lb.ChunkSource) {
+	if src == lb.SourceOrigin {
+		s.origin.observe(t, s.originBps(t))
+	}
+}
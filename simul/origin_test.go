@@ -0,0 +1,124 @@
+package simul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+type lastStatusWriter struct {
+	t  time.Time
+	st status.Status
+}
+
+func (w *lastStatusWriter) WriteStatus(ti time.Time, st status.Status, cfg data.Config, opt Options) {
+	w.t = ti
+	w.st = st
+}
+
+func TestSimulator_OriginLimit(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000},
+		},
+	}
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:00:40.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:41.000"),
+			Filename:  "b.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+
+	// origin 요구량 2Mbps, limit 이 1Mbps 이면 chunk 전송 시간이 2배
+	for _, limit := range []int64{0, 1000000} {
+		cfg.OriginLimitBps = limit
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &lastStatusWriter{}
+		si := NewSimulator(cfg, Options{}, l, NewTestEventReader(ss), w, nil, nil)
+		si.Run()
+
+		o := w.st.Origin
+		if o.Bps != 0 || o.PeakBps != 2000000 || o.LimitBps != limit {
+			t.Errorf("limit(%v) origin status %+v", limit, *o)
+		}
+		for _, v := range w.st.Vods {
+			if v.CurSessionCount != 0 {
+				t.Errorf("limit(%v) all sessions must be ended, %+v", limit, *v)
+			}
+		}
+		lastEnd := StrToTime("2017-01-01 00:00:41.000")
+		if limit == 0 {
+			if o.DelayedChunkCount != 0 || o.TotalDelay != 0 || o.OverLimitDuration != 0 {
+				t.Errorf("no chunk must be delayed without limit, %+v", *o)
+			}
+			if !w.t.Equal(lastEnd) {
+				t.Errorf("last session must be ended at %v, %v", TimeToStr(lastEnd), TimeToStr(w.t))
+			}
+			continue
+		}
+		if o.DelayedChunkCount == 0 || o.TotalDelay < 16*time.Second || o.OverLimitDuration == 0 {
+			t.Errorf("chunks over limit must be delayed, %+v", *o)
+		}
+		if !w.t.After(lastEnd) {
+			t.Errorf("delayed session must be ended after %v, %v", TimeToStr(lastEnd), TimeToStr(w.t))
+		}
+	}
+}
+
+func TestSimulator_OriginLimit_ParentHit(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 250000, LimitSession: 10, LimitBps: 1000000000, Parent: "p1"},
+		},
+		Parents:        []data.ParentConfig{data.ParentConfig{ParentID: "p1", StorageSize: 100000000}},
+		ChunkSize:      125000,
+		OriginLimitBps: 1000000,
+	}
+	session := func(sid, filename, started, ended string) *glblog.SessionInfo {
+		return &glblog.SessionInfo{SID: sid, Started: StrToTime(started), Ended: StrToTime(ended), Filename: filename, Bandwidth: 1000000}
+	}
+	// sess-W 가 parent 에 a.mpg 를 채운 후 sess-X 는 vod1 miss, parent hit
+	// sess-B, sess-C 가 origin 에서 받는 동안 origin 요구량이 limit 을 넘음
+	ss := []*glblog.SessionInfo{
+		session("sess-W", "a.mpg", "2017-01-01 00:00:00.000", "2017-01-01 00:00:40.000"),
+		session("sess-B", "b.mpg", "2017-01-01 00:01:00.000", "2017-01-01 00:01:10.000"),
+		session("sess-C", "c.mpg", "2017-01-01 00:01:00.000", "2017-01-01 00:01:10.000"),
+		session("sess-X", "a.mpg", "2017-01-01 00:01:00.500", "2017-01-01 00:01:40.500"),
+	}
+	l, err := lb.New(cfg, &lb.SameHashingWeight{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &lastStatusWriter{}
+	si := NewSimulator(cfg, Options{}, l, NewTestEventReader(ss), w, nil, nil)
+	si.Run()
+
+	o := w.st.Origin
+	if o.DelayedChunkCount == 0 || o.DelayedChunkCount > 20 || o.OverLimitDuration == 0 {
+		t.Errorf("only origin chunks must be delayed, %+v", *o)
+	}
+	if p := w.st.Parents["p1"]; p.CacheHitCount < 30 {
+		t.Errorf("chunks of sess-X must be parent hits, %+v", *p)
+	}
+	// parent hit 은 늦어지지 않으므로 sess-X 가 원래 시각에 마지막으로 끝남
+	if lastEnd := StrToTime("2017-01-01 00:01:40.500"); !w.t.Equal(lastEnd) {
+		t.Errorf("parent hit session must be ended at %v, %v", TimeToStr(lastEnd), TimeToStr(w.t))
+	}
+}
@@ -0,0 +1,150 @@
+package simul
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+// QoEOption : chunk 전송률 모델
+// cache hit chunk 와 parent 에서 hit 된 chunk 는 VOD 대역폭을 session 수로 나눈 전송률로 받고
+// origin 에서 받는 chunk 는 OriginFetchBps 와 VOD 전송률 중 작은 값(origin limit 초과 시 limit/요구량 비율로 감소)으로 받음
+type QoEOption struct {
+	OriginFetchBps int64         // session 하나가 origin 에서 받는 최대 전송률, 0 이면 제한 없음
+	OriginLatency  time.Duration // origin 요청 지연
+	Buffer         time.Duration // 재생 buffer, 0 이면 chunk 하나의 재생 시간, chunk 전송 시간이 buffer 보다 길면 rebuffering
+}
+
+type sessionQoE struct {
+	startup   time.Duration
+	rebuffers int64
+	stall     time.Duration
+}
+
+// qoeTracker : session 별 시작 지연(첫 chunk 전송 시간)과 rebuffering 추정
+type qoeTracker struct {
+	opt                    QoEOption
+	sessions               map[string]*sessionQoE
+	startups               []time.Duration
+	stalls                 []time.Duration
+	rebufferedSessionCount int64
+	rebufferCount          int64
+	totalStall             time.Duration
+}
+
+func newQoETracker(opt QoEOption) *qoeTracker {
+	return &qoeTracker{opt: opt, sessions: make(map[string]*sessionQoE)}
+}
+
+// chunkFetchTime : bps 로 재생되는 size 크기 chunk 를 받는데 걸리는 시간
+func (s *Simulator) chunkFetchTime(t time.Time, sid string, bps, size int64, src lb.ChunkSource) time.Duration {
+	rate := float64(0) // 0 이면 제한 없음
+	if k, ok := s.lb.SessionVOD(sid); ok {
+		if v := s.lb.GetVODs()[k]; v != nil && v.CurSessionCount > 0 {
+			rate = float64(v.LimitBps) / float64(v.CurSessionCount)
+		}
+	}
+	latency := time.Duration(0)
+	if src == lb.SourceOrigin {
+		latency = s.opt.QoE.OriginLatency
+		originRate := float64(s.opt.QoE.OriginFetchBps)
+		if limit := s.cfg.OriginLimitBps; limit > 0 {
+			if demand := s.originBps(t); demand > limit {
+				if originRate == 0 {
+					originRate = float64(bps)
+				}
+				originRate = originRate * float64(limit) / float64(demand)
+			}
+		}
+		if originRate > 0 && (rate == 0 || originRate < rate) {
+			rate = originRate
+		}
+	}
+	if rate <= 0 {
+		return latency
+	}
+	return latency + time.Duration(float64(8*size)/rate*float64(time.Second))
+}
+
+// startChunkQoE : 첫 chunk 이면 시작 지연, 이후 chunk 는 buffer 보다 늦게 받으면 rebuffering, chunk 전송 시간을 반환
+func (s *Simulator) startChunkQoE(t time.Time, sid string, bps, size int64, playDu time.Duration, src lb.ChunkSource) time.Duration {
+	fetch := s.chunkFetchTime(t, sid, bps, size, src)
+	q, ok := s.qoe.sessions[sid]
+	if !ok {
+		s.qoe.sessions[sid] = &sessionQoE{startup: fetch}
+		return fetch
+	}
+	buffer := s.opt.QoE.Buffer
+	if buffer == 0 {
+		buffer = playDu
+	}
+	if fetch > buffer {
+		q.rebuffers++
+		q.stall += fetch - buffer
+	}
+	return fetch
+}
+
+// endSessionQoE :
+func (s *Simulator) endSessionQoE(sid string) {
+	q, ok := s.qoe.sessions[sid]
+	if !ok {
+		return
+	}
+	delete(s.qoe.sessions, sid)
+	s.qoe.startups = append(s.qoe.startups, q.startup)
+	s.qoe.stalls = append(s.qoe.stalls, q.stall)
+	if q.rebuffers > 0 {
+		s.qoe.rebufferedSessionCount++
+	}
+	s.qoe.rebufferCount += q.rebuffers
+	s.qoe.totalStall += q.stall
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+func sortedDurations(v []time.Duration) []time.Duration {
+	ret := append([]time.Duration{}, v...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// QoE : 종료된 session 의 QoE 통계
+func (s *Simulator) QoE() *status.QoEStatus {
+	startups := sortedDurations(s.qoe.startups)
+	stalls := sortedDurations(s.qoe.stalls)
+	return &status.QoEStatus{
+		SessionCount:           int64(len(startups)),
+		StartupP50:             percentile(startups, 0.5),
+		StartupP90:             percentile(startups, 0.9),
+		StartupP99:             percentile(startups, 0.99),
+		RebufferedSessionCount: s.qoe.rebufferedSessionCount,
+		RebufferCount:          s.qoe.rebufferCount,
+		StallP90:               percentile(stalls, 0.9),
+		StallP99:               percentile(stalls, 0.99),
+		TotalStall:             s.qoe.totalStall,
+	}
+}
+
+func (s *Simulator) printQoE() {
+	q := s.QoE()
+	fmt.Printf("qoe: sessions(%v) startup-delay(p50:%v p90:%v p99:%v) rebuffered-sessions(%v) rebuffers(%v) stall(p90:%v p99:%v total:%v)\n",
+		q.SessionCount, q.StartupP50, q.StartupP90, q.StartupP99,
+		q.RebufferedSessionCount, q.RebufferCount, q.StallP90, q.StallP99, q.TotalStall)
+}
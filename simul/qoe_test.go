@@ -0,0 +1,145 @@
+package simul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+)
+
+func TestSimulator_QoE(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 10000000},
+		},
+	}
+	// chunk 재생 시간 16초, session 별 chunk 3개
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:00:40.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:41.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+	near := func(a, b time.Duration) bool {
+		d := a - b
+		return d > -time.Millisecond && d < time.Millisecond
+	}
+
+	// sess-A 는 origin(4Mbps + 100ms) 에서, sess-B 는 cache hit 으로 VOD 대역폭의 절반(5Mbps) 으로 받음
+	opt := QoEOption{OriginFetchBps: 4000000, OriginLatency: 100 * time.Millisecond}
+	for _, buffer := range []time.Duration{0, 3 * time.Second} {
+		opt.Buffer = buffer
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		si := NewSimulator(cfg, Options{QoE: opt}, l, NewTestEventReader(ss), nil, nil, nil)
+		si.Run()
+
+		q := si.QoE()
+		if q.SessionCount != 2 || !near(q.StartupP50, 3200*time.Millisecond) || !near(q.StartupP99, 4100*time.Millisecond) {
+			t.Errorf("buffer(%v) startup %+v", buffer, *q)
+		}
+		if buffer == 0 {
+			if q.RebufferCount != 0 || q.TotalStall != 0 {
+				t.Errorf("no rebuffering within one chunk buffer, %+v", *q)
+			}
+			continue
+		}
+		// sess-A 는 chunk 마다 1.1초, sess-B 는 0.2초 rebuffering
+		if q.RebufferCount != 4 || q.RebufferedSessionCount != 2 || !near(q.TotalStall, 2600*time.Millisecond) ||
+			!near(q.StallP99, 2200*time.Millisecond) {
+			t.Errorf("buffer(%v) rebuffer %+v", buffer, *q)
+		}
+	}
+}
+
+func TestSimulator_QoE_ParentHit(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 2000000, LimitSession: 10, LimitBps: 10000000, Parent: "p1"},
+		},
+		Parents: []data.ParentConfig{data.ParentConfig{ParentID: "p1", StorageSize: 1000000000}},
+	}
+	// sess-A 가 parent 에 a.mpg 를 채운 후 sess-B 의 첫 chunk 는 vod1 miss, parent hit
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:00:40.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:01:00.000"),
+			Ended:     StrToTime("2017-01-01 00:01:40.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+	l, err := lb.New(cfg, &lb.SameHashingWeight{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := QoEOption{OriginFetchBps: 4000000, OriginLatency: 100 * time.Millisecond}
+	si := NewSimulator(cfg, Options{QoE: opt}, l, NewTestEventReader(ss), nil, nil, nil)
+	si.Run()
+
+	// parent hit 은 origin 지연 없이 VOD 전송률(10Mbps) 로 받음
+	q := si.QoE()
+	if q.SessionCount != 2 || q.StartupP50 != 1600*time.Millisecond || q.StartupP99 != 4100*time.Millisecond {
+		t.Errorf("parent hit must not use origin fetch rate, %+v", *q)
+	}
+}
+
+func TestSimulator_QoE_FaultMigrate(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 10000000},
+			data.VODConfig{VodID: "vod2", StorageSize: 1000000000, LimitSession: 10, LimitBps: 10000000},
+		},
+	}
+	// chunk 재생 시간 16초, chunk 3개 모두 origin(4Mbps + 100ms) 에서 4.1초에 받음
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:41.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+	// vod1 장애로 vod2 에서 첫 chunk 를 다시 받음
+	faults := []*data.FaultEvent{
+		&data.FaultEvent{VodID: "vod2", DownTime: StrToTime("2017-01-01 00:00:00.000"), UpTime: StrToTime("2017-01-01 00:00:01.500")},
+		&data.FaultEvent{VodID: "vod1", DownTime: StrToTime("2017-01-01 00:00:05.000"), UpTime: StrToTime("2017-01-01 00:01:00.000")},
+	}
+	opt := QoEOption{OriginFetchBps: 4000000, OriginLatency: 100 * time.Millisecond, Buffer: 3 * time.Second}
+	l, err := lb.New(cfg, &lb.SameHashingWeight{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := NewSimulator(cfg, Options{QoE: opt, FaultMigrate: true}, l, NewTestEventReader(ss), nil, nil, nil)
+	if err := si.SetFaults(faults); err != nil {
+		t.Fatal(err)
+	}
+	si.Run()
+
+	q := si.QoE()
+	if q.SessionCount != 1 || q.RebufferCount != 3 || q.TotalStall != 3300*time.Millisecond {
+		t.Errorf("re-fetched chunk must be rebuffered, %+v", *q)
+	}
+}
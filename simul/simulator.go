@@ -8,11 +8,10 @@ import (
 
 	"github.com/castisdev/cdn-simul/data"
 	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/lb/vod"
 	"github.com/castisdev/cdn-simul/status"
 )
 
-const chunkSize int64 = 2000000 //2 * 1024 * 1024
-
 // Options :
 type Options struct {
 	MaxReadEventCount int
@@ -26,6 +25,15 @@ type Options struct {
 	FBPeriod          time.Duration
 	SimulID           string
 	StartTime         time.Time
+	FaultMigrate      bool // true 이면 장애 VOD 의 session 을 다른 VOD 로 이전, false 이면 종료
+	QoE               QoEOption
+	ABRSafety         float64       // abr 에서 측정 전송률 중 rendition 선택에 사용하는 비율, 0 이면 DefaultABRSafety
+	CheckpointFile    string        // 상태를 주기적으로 기록할 파일, 빈 문자열이면 기록하지 않음
+	CheckpointPeriod  time.Duration // checkpoint 기록 주기 (simulation 시간)
+	SnapshotFile      string        // cache, storage 내용을 기록할 파일, 빈 문자열이면 기록하지 않음
+	SnapshotTime      time.Time     // snapshot 을 기록할 시각, zero 이면 실행이 끝날 때 기록
+	WarmUp            time.Duration // 처음 처리하는 event 시각부터 WarmUp 동안의 통계는 결과에서 제외
+	SummaryFile       string        // 실행이 끝날 때 요약 결과를 json 으로 기록할 파일, 빈 문자열이면 기록하지 않음
 }
 
 var layout = "2006-01-02 15:04:05.000"
@@ -62,6 +70,16 @@ func FindConfig(c *data.Config, k string) data.VODConfig {
 	return data.VODConfig{}
 }
 
+// FindParentConfig :
+func FindParentConfig(c *data.Config, k string) data.ParentConfig {
+	for _, v := range c.Parents {
+		if v.ParentID == k {
+			return v
+		}
+	}
+	return data.ParentConfig{}
+}
+
 type eventHeap []*endEvent
 
 func (h eventHeap) Len() int           { return len(h) }
@@ -85,6 +103,7 @@ type endEventType int
 const (
 	sessionEnd endEventType = iota
 	chunkEnd
+	chunkResume // trick-play 로 멈춘 chunk 요청을 다시 확인
 )
 
 type endEvent struct {
@@ -98,8 +117,12 @@ type endEvent struct {
 	duration       time.Duration
 	sessionEndTime time.Time
 	bypass         bool
-	useOrigin      bool
+	source         lb.ChunkSource
 	isCenter       bool
+	chunkSize      int64
+	rendition      int
+	tricks         []trickEvent // 아직 적용하지 않은 trick-play event
+	paused         bool
 }
 
 func (e endEvent) String() string {
@@ -144,27 +167,49 @@ func (s *firstBypassChecker) isBypass(file int) bool {
 
 // Simulator :
 type Simulator struct {
-	cfg            data.Config
-	opt            Options
-	reader         EventReader
-	writer         StatusWriter
-	lb             lb.LoadBalancer
-	internalEvents *eventHeap
-	bypassMap      map[string]interface{}
-	filenameMap    map[string]int
-	filenameSeed   int
-	firstBypass    *firstBypassChecker
-	fileInfos      *data.FileInfos
-	startT         time.Time
+	cfg             data.Config
+	opt             Options
+	reader          EventReader
+	writer          StatusWriter
+	lb              lb.LoadBalancer
+	internalEvents  *eventHeap
+	bypassMap       map[string]interface{}
+	filenameMap     map[string]int
+	filenameSeed    int
+	firstBypass     *firstBypassChecker
+	fileInfos       *data.FileInfos
+	startT          time.Time
+	faults          []*faultEvent
+	faultIdx        int
+	topology        []*data.TopologyEvent
+	topologyIdx     int
+	origin          *originLink
+	qoe             *qoeTracker
+	chunkSizer      *ChunkSizer
+	abr             *abrTracker
+	lastT           time.Time // 마지막으로 처리한 event 시각
+	initCfg         data.Config
+	evtCount        int64 // 읽은 session event 수
+	nextLogT        time.Time
+	nextCheckpointT time.Time
+	warmUpEndT      time.Time
+	warmUpDone      bool
+	snapshotDone    bool
+	summary         *summaryTracker
 }
 
 // NewSimulator :
 func NewSimulator(cfg data.Config, opt Options, lb lb.LoadBalancer, r EventReader, w StatusWriter, fi *data.FileInfos, bypass []string) *Simulator {
 	ie := &eventHeap{}
 	heap.Init(ie)
+	sizer, err := NewChunkSizer(cfg)
+	if err != nil {
+		log.Fatalf("failed to create simulator, %v", err)
+	}
 
 	si := &Simulator{
 		cfg:            cfg,
+		initCfg:        cfg,
 		opt:            opt,
 		reader:         r,
 		writer:         w,
@@ -177,8 +222,12 @@ func NewSimulator(cfg data.Config, opt Options, lb lb.LoadBalancer, r EventReade
 			moreHitFile:     make(map[int]struct{}),
 			updateHitPeriod: opt.FBPeriod,
 		},
-		fileInfos: fi,
-		startT:    opt.StartTime,
+		fileInfos:  fi,
+		startT:     opt.StartTime,
+		origin:     newOriginLink(cfg.OriginLimitBps),
+		qoe:        newQoETracker(opt.QoE),
+		chunkSizer: sizer,
+		summary:    newSummaryTracker(),
 	}
 	for _, v := range bypass {
 		si.bypassMap[v] = nil
@@ -206,12 +255,16 @@ func (s *Simulator) getFilename(filename string) int {
 
 // Run :
 func (s *Simulator) Run() {
-	var nextLogT time.Time
 	var procT time.Time
-	evtCount := int64(0)
 	for {
-		evtCount++
-		if s.opt.MaxReadEventCount != 0 && int(evtCount) > s.opt.MaxReadEventCount {
+		if s.checkpointDue() {
+			if err := s.WriteCheckpoint(s.opt.CheckpointFile); err != nil {
+				log.Fatalf("failed to write checkpoint, %v", err)
+			}
+			log.Printf("checkpoint written at %v\n", TimeToStr(s.lastT))
+		}
+		s.evtCount++
+		if s.opt.MaxReadEventCount != 0 && int(s.evtCount) > s.opt.MaxReadEventCount {
 			break
 		}
 		ev := s.reader.ReadEvent()
@@ -219,8 +272,8 @@ func (s *Simulator) Run() {
 			s.processEventsUntil(StrToTime("9999-12-31 00:00:00.000"), s.internalEvents, s.lb)
 			break
 		}
-		if evtCount == 1 {
-			nextLogT = ev.Started
+		if s.evtCount == 1 {
+			s.nextLogT = ev.Started
 		}
 		if s.fileInfos != nil && s.fileInfos.Exists(ev.Filename) == false {
 			sz := ev.Filesize
@@ -235,7 +288,10 @@ func (s *Simulator) Run() {
 		}
 		procT = ev.Started
 
+		s.applyMarksUntil(procT)
 		s.processEventsUntil(procT, s.internalEvents, s.lb)
+		s.lastT = procT
+		s.sampleSummary(procT)
 
 		if s.opt.StatusWritePeriod == 0 {
 			log.Printf("session event: %s\n", ev)
@@ -257,26 +313,43 @@ func (s *Simulator) Run() {
 			Duration:    ev.Ended.Sub(ev.Started),
 		}
 		err = s.lb.StartSession(&sEvt)
-		if err != nil {
+		rejected := err == lb.ErrRejected
+		if err != nil && !rejected {
 			log.Fatalf("failed to process start-session-event, %v", err)
 		}
+		s.summarySession(ev.Started, rejected)
 		if s.opt.StatusWritePeriod == 0 {
-			log.Printf("session start: %s\n", sEvt)
+			if rejected {
+				log.Printf("session rejected: %s\n", sEvt)
+			} else {
+				log.Printf("session start: %s\n", sEvt)
+			}
 			st := s.lb.Status(sEvt.Time)
 			s.writeStatus(ev.Started, *st, s.cfg, s.opt)
-		} else if ev.Started.After(nextLogT) {
+		} else if ev.Started.After(s.nextLogT) {
 			st := s.lb.Status(sEvt.Time)
 			s.writeStatus(ev.Started, *st, s.cfg, s.opt)
 			for {
-				nextLogT = nextLogT.Add(s.opt.StatusWritePeriod)
-				if nextLogT.After(ev.Started) {
+				s.nextLogT = s.nextLogT.Add(s.opt.StatusWritePeriod)
+				if s.nextLogT.After(ev.Started) {
 					break
 				}
 			}
 		}
+		// 거부된 session 은 chunk, end event 를 만들지 않음
+		if rejected {
+			continue
+		}
 
-		idx := int(ev.Offset / chunkSize)
-		du := time.Duration(float64(8*chunkSize)/float64(ev.Bandwidth)*1000) * time.Millisecond
+		size := s.chunkSizer.Size(ev.Filename)
+		idx := s.offsetIndex(ev.Filename, ev.Offset, ev.Bandwidth)
+		du := time.Duration(float64(8*size)/float64(ev.Bandwidth)*1000) * time.Millisecond
+		bps, chunkSize := int64(ev.Bandwidth), size
+		ladder := s.abrLadder(ev.Filename)
+		if ladder != nil {
+			// 첫 chunk 는 가장 낮은 rendition
+			bps, chunkSize, du = abrChunk(ladder, size, 0)
+		}
 		_, bypass := s.bypassMap[ev.Filename]
 		if s.opt.FirstBypass {
 			bypass = bypass || s.firstBypass.isBypass(fn)
@@ -287,17 +360,25 @@ func (s *Simulator) Run() {
 			FileName:    ev.Filename,
 			IntFileName: fn,
 			FileSize:    ev.Filesize,
-			Bps:         int64(ev.Bandwidth),
+			Bps:         bps,
 			Index:       int64(idx),
 			ChunkSize:   chunkSize,
 			Bypass:      bypass,
 			IsCenter:    ev.IsCenter,
 		}
-		var useOrigin bool
-		useOrigin, err = s.lb.StartChunk(&cEvt)
+		var src lb.ChunkSource
+		src, err = s.lb.StartChunk(&cEvt)
 		if err != nil {
 			log.Fatalf("failed to process start-chunk-event, %v", err)
 		}
+		s.summaryChunk(ev.Started, chunkSize, src.VODMiss())
+		// origin limit 초과로 늦어진 만큼 session 종료도 늦어짐
+		delay := s.startOriginChunk(ev.Started, du, src)
+		fetch := s.startChunkQoE(ev.Started, ev.SID, bps, chunkSize, du, src)
+		if ladder != nil {
+			s.observeABR(ev.SID, ladder, 0, chunkSize, fetch)
+		}
+		ended := ev.Ended.Add(delay)
 		if s.opt.StatusWritePeriod == 0 {
 			log.Printf("chunk start: %s\n", cEvt)
 			st := s.lb.Status(cEvt.Time)
@@ -305,26 +386,30 @@ func (s *Simulator) Run() {
 		}
 
 		ecEv := endEvent{
-			time:           ev.Started.Add(du),
+			time:           ev.Started.Add(du + delay),
 			endType:        chunkEnd,
 			sid:            ev.SID,
 			filename:       ev.Filename,
 			intFilename:    fn,
-			bps:            ev.Bandwidth,
+			bps:            int(bps),
 			index:          idx,
 			duration:       du,
-			sessionEndTime: ev.Ended,
+			sessionEndTime: ended,
 			bypass:         bypass,
-			useOrigin:      useOrigin,
+			source:         src,
 			isCenter:       ev.IsCenter,
+			chunkSize:      chunkSize,
+			tricks: newTrickEvents(ev.Tricks, func(offset int64) int {
+				return s.offsetIndex(ev.Filename, offset, ev.Bandwidth)
+			}),
 		}
-		if ecEv.time.Sub(ev.Ended) >= 0 {
-			ecEv.time = ev.Ended.Add(-time.Millisecond)
+		if ecEv.time.Sub(ended) >= 0 {
+			ecEv.time = ended.Add(-time.Millisecond)
 		}
 		heap.Push(s.internalEvents, &ecEv)
 
 		esEv := endEvent{
-			time:           ev.Ended,
+			time:           ended,
 			endType:        sessionEnd,
 			sid:            ev.SID,
 			filename:       ev.Filename,
@@ -332,12 +417,38 @@ func (s *Simulator) Run() {
 			bps:            ev.Bandwidth,
 			index:          idx,
 			duration:       ev.Ended.Sub(ev.Started),
-			sessionEndTime: ev.Ended,
+			sessionEndTime: ended,
 		}
 		heap.Push(s.internalEvents, &esEv)
 	}
+	s.finishMarks()
+	if s.opt.SummaryFile != "" {
+		if err := s.WriteSummary(s.opt.SummaryFile); err != nil {
+			log.Fatalf("failed to write summary, %v", err)
+		}
+	}
+	if s.writer != nil {
+		s.printQoE()
+		s.printSummary()
+	}
+}
+
+// Status : 마지막으로 처리한 event 시각의 status, Run 이후 결과 비교에 사용
+func (s *Simulator) Status() status.Status {
+	st := *s.lb.Status(s.lastT)
+	s.fillStatus(&st, s.lastT)
+	return st
+}
+
+func (s *Simulator) fillStatus(st *status.Status, ti time.Time) {
+	s.origin.fillStatus(st.Origin, ti)
+	if s.abr != nil {
+		st.ABR = s.abr.status()
+	}
 }
+
 func (s *Simulator) writeStatus(ti time.Time, st status.Status, cfg data.Config, opt Options) {
+	s.fillStatus(&st, ti)
 	if s.writer != nil {
 		s.writer.WriteStatus(ti, st, cfg, opt)
 	}
@@ -345,17 +456,21 @@ func (s *Simulator) writeStatus(ti time.Time, st status.Status, cfg data.Config,
 
 func (s *Simulator) processEventsUntil(ti time.Time, events *eventHeap, lb lb.LoadBalancer) {
 	for events.Len() > 0 {
+		if (*events)[0].time.After(ti) {
+			break
+		}
+		s.sampleSummary((*events)[0].time)
+		// 장애, topology 변경 처리로 event 가 삭제되거나 바뀔 수 있음
+		if s.applyScheduleUntil((*events)[0].time) {
+			continue
+		}
 		e := heap.Pop(events)
 		endEv := e.(*endEvent)
-
-		if endEv.time.After(ti) {
-			heap.Push(events, endEv)
-			return
-		}
+		s.lastT = endEv.time
 
 		var err error
 		diffLastChunkTandSessionEndT := time.Millisecond
-		if endEv.endType == chunkEnd {
+		if endEv.endType != sessionEnd {
 			evt := data.ChunkEvent{
 				Time:        endEv.time,
 				SessionID:   endEv.sid,
@@ -363,43 +478,74 @@ func (s *Simulator) processEventsUntil(ti time.Time, events *eventHeap, lb lb.Lo
 				IntFileName: endEv.intFilename,
 				Bps:         int64(endEv.bps),
 				Index:       int64(endEv.index),
-				ChunkSize:   chunkSize,
+				ChunkSize:   endEv.chunkSize,
+				Rendition:   endEv.rendition,
 				Bypass:      endEv.bypass,
 				IsCenter:    endEv.isCenter,
 			}
-			err = lb.EndChunk(&evt, endEv.useOrigin)
-			if err != nil {
-				log.Fatalf("failed to process end-chunk-event, %v", err)
-			}
-			if s.opt.StatusWritePeriod == 0 {
-				log.Printf("chunk end: %s\n", evt)
-				st := lb.Status(evt.Time)
-				s.writeStatus(evt.Time, *st, s.cfg, s.opt)
+			if endEv.endType == chunkEnd {
+				err = lb.EndChunk(&evt, endEv.source)
+				if err != nil {
+					log.Fatalf("failed to process end-chunk-event, %v", err)
+				}
+				s.endOriginChunk(evt.Time, endEv.source)
+				if s.opt.StatusWritePeriod == 0 {
+					log.Printf("chunk end: %s\n", evt)
+					st := lb.Status(evt.Time)
+					s.writeStatus(evt.Time, *st, s.cfg, s.opt)
+				}
+				if endEv.sessionEndTime.Sub(endEv.time) == diffLastChunkTandSessionEndT {
+					continue
+				}
+				endEv.index++
 			}
-			if endEv.sessionEndTime.Sub(endEv.time) == diffLastChunkTandSessionEndT {
+			if endEv.pauseChunk(events) {
 				continue
 			}
 
-			evt.Index++
-			endEv.useOrigin, err = lb.StartChunk(&evt)
+			evt.Index = int64(endEv.index)
+			ladder := s.abrLadder(endEv.filename)
+			if ladder != nil {
+				endEv.rendition = s.nextRendition(endEv.sid, endEv.rendition)
+				bps, size, _ := abrChunk(ladder, s.chunkSizer.Size(endEv.filename), endEv.rendition)
+				endEv.bps, endEv.chunkSize = int(bps), size
+				evt.Bps, evt.ChunkSize, evt.Rendition = bps, size, endEv.rendition
+			}
+			endEv.source, err = lb.StartChunk(&evt)
 			if err != nil {
 				log.Fatalf("failed to process start-chunk-event, %v", err)
 			}
+			s.summaryChunk(evt.Time, endEv.chunkSize, endEv.source.VODMiss())
+			// session end event 는 pop 될 때 지연 반영
+			delay := s.startOriginChunk(evt.Time, endEv.duration, endEv.source)
+			fetch := s.startChunkQoE(evt.Time, endEv.sid, evt.Bps, endEv.chunkSize, endEv.duration, endEv.source)
+			if ladder != nil {
+				s.observeABR(endEv.sid, ladder, endEv.rendition, endEv.chunkSize, fetch)
+			}
+			if delay > 0 {
+				endEv.sessionEndTime = endEv.sessionEndTime.Add(delay)
+				s.origin.delays[endEv.sid] += delay
+			}
 			if s.opt.StatusWritePeriod == 0 {
 				log.Printf("chunk start: %s\n", evt)
 				st := lb.Status(evt.Time)
 				s.writeStatus(evt.Time, *st, s.cfg, s.opt)
 			}
 
-			nextEndT := endEv.time.Add(endEv.duration)
+			nextEndT := endEv.time.Add(endEv.duration + delay)
 			if nextEndT.Before(endEv.sessionEndTime) {
 				endEv.time = nextEndT
 			} else {
 				endEv.time = endEv.sessionEndTime.Add(-diffLastChunkTandSessionEndT)
 			}
-			endEv.index++
 			heap.Push(events, endEv)
 		} else {
+			if delay := s.origin.takeDelay(endEv.sid); delay > 0 {
+				endEv.time = endEv.time.Add(delay)
+				endEv.sessionEndTime = endEv.time
+				heap.Push(events, endEv)
+				continue
+			}
 			evt := data.SessionEvent{
 				Time:        endEv.time,
 				SessionID:   endEv.sid,
@@ -412,6 +558,8 @@ func (s *Simulator) processEventsUntil(ti time.Time, events *eventHeap, lb lb.Lo
 			if err != nil {
 				log.Fatalf("failed to process end-sesison-event, %v", err)
 			}
+			s.endSessionQoE(evt.SessionID)
+			s.endSessionABR(evt.SessionID)
 			if s.opt.StatusWritePeriod == 0 {
 				log.Printf("session end: %s\n", evt)
 				st := lb.Status(evt.Time)
@@ -419,6 +567,7 @@ func (s *Simulator) processEventsUntil(ti time.Time, events *eventHeap, lb lb.Lo
 			}
 		}
 	}
+	s.applyScheduleUntil(ti)
 }
 
 // LBOption :
@@ -442,6 +591,14 @@ type LBOption struct {
 	UseFileSize         bool
 	UseTimeWeight       bool
 	UseIdeal            bool
+	Oracle              *Oracle // cache policy 가 opt, opt-size 인 경우 사용
+	LoadFactor          float64 // bounded-hash
+	LoadMetric          string  // bounded-hash, choices, session | bps
+	HRWWeight           string  // hrw, same | storage | bps | weight
+	Choices             int     // choices, 0 이면 모든 VOD
+	Replicas            int     // filebase, 인기 file 을 저장할 VOD 수
+	ReplicaRank         int     // filebase, 인기 순위 ReplicaRank 이내의 file 은 Replicas 개 VOD 에 저장
+	MaxReplicas         int     // replication, 인기 1위 file 의 replica 수
 }
 
 // NewLoadBalancer :
@@ -450,24 +607,37 @@ func NewLoadBalancer(opt LBOption) (lb.LoadBalancer, error) {
 	case "legacy":
 		return lb.NewLegacyLB(opt.Cfg, &lb.SameHashingWeight{})
 	case "filebase":
-		var st lb.Storage
-		if opt.UseIdeal {
-			st = lb.NewIdealStorage(5*time.Minute, opt.StatDuration, opt.ShiftPeriod, opt.Cfg.VODs[0].StorageSize,
-				opt.Fileinfos, opt.UseSessionDuration, opt.UseFileSize, opt.UseTimeWeight)
-		} else {
-			st = lb.NewFilebaseStorage(opt.StatDuration, opt.StatDurationForDel, opt.ShiftPeriod, opt.PushPeriod, opt.PushDelayN, opt.DawnPushN,
-				opt.Cfg.VODs[0].StorageSize, opt.Fileinfos, opt.InitContents, opt.DeliverEvent, opt.PurgeEvent,
-				opt.UseSessionDuration, opt.UseDeleteLru, opt.UseFileSize, opt.UseTimeWeight)
+		placement := lb.NewFilePlacement(opt.Cfg, opt.Fileinfos, opt.StatDuration, opt.ShiftPeriod, opt.PushPeriod,
+			opt.Replicas, opt.ReplicaRank)
+		storages := make(map[vod.Key]lb.Storage)
+		for _, v := range opt.Cfg.VODs {
+			k := vod.Key(v.VodID)
+			// VOD 가 1개이면 모든 file 저장
+			var owns func(file int) bool
+			if len(opt.Cfg.VODs) > 1 {
+				owns = placement.Owner(k)
+			}
+			if opt.UseIdeal {
+				storages[k] = lb.NewIdealStorage(5*time.Minute, opt.StatDuration, opt.ShiftPeriod, v.StorageSize,
+					opt.Fileinfos, owns, opt.UseSessionDuration, opt.UseFileSize, opt.UseTimeWeight)
+			} else {
+				storages[k] = lb.NewFilebaseStorage(opt.StatDuration, opt.StatDurationForDel, opt.ShiftPeriod, opt.PushPeriod, opt.PushDelayN, opt.DawnPushN,
+					v.StorageSize, opt.Fileinfos, owns, opt.InitContents, opt.DeliverEvent, opt.PurgeEvent,
+					opt.UseSessionDuration, opt.UseDeleteLru, opt.UseFileSize, opt.UseTimeWeight)
+			}
 		}
-		return lb.NewFilebaseLB(opt.Cfg, lb.NewFileBase(st))
+		return lb.NewFilebaseLB(opt.Cfg, lb.NewFileBase(storages, placement))
+	}
+	s := NewVODSelector(opt)
+	if opt.Oracle != nil {
+		return lb.NewWithOracle(opt.Cfg, s, opt.Oracle)
 	}
-	s := NewVODSelector(opt.LBType, opt.HotListUpdatePeriod, opt.HotRankLimit)
 	return lb.New(opt.Cfg, s)
 }
 
 // NewVODSelector :
-func NewVODSelector(algorithm string, hotListUpdatePeriod time.Duration, hotRankLimit int) lb.VODSelector {
-	switch algorithm {
+func NewVODSelector(opt LBOption) lb.VODSelector {
+	switch opt.LBType {
 	case "weight-storage-bps":
 		return &lb.WeightStorageBps{}
 	case "dup2":
@@ -475,7 +645,20 @@ func NewVODSelector(algorithm string, hotListUpdatePeriod time.Duration, hotRank
 	case "weight-storage":
 		return &lb.WeightStorage{}
 	case "high-low":
-		return lb.NewHighLowGroup(hotListUpdatePeriod, hotRankLimit)
+		return lb.NewHighLowGroup(opt.HotListUpdatePeriod, opt.HotRankLimit)
+	case "bounded-hash":
+		return lb.NewBoundedHash(opt.LoadFactor, opt.LoadMetric)
+	case "hrw":
+		return lb.NewRendezvous(opt.HRWWeight)
+	case "choices":
+		return lb.NewChoicesOfD(opt.Choices, opt.LoadMetric)
+	case "least-conn":
+		return lb.NewLeastConn()
+	case "least-bps":
+		return lb.NewLeastBps()
+	case "replication":
+		ranker := lb.NewHitRanker(opt.StatDuration, opt.ShiftPeriod, opt.Fileinfos, opt.UseSessionDuration, opt.UseFileSize, opt.UseTimeWeight)
+		return lb.NewReplication(ranker, opt.Fileinfos, opt.HotListUpdatePeriod, opt.MaxReplicas, opt.LoadMetric)
 	}
 	return &lb.SameHashingWeight{}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/castisdev/cdn-simul/data"
 	"github.com/castisdev/cdn-simul/glblog"
 	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/status"
 )
 
 var q []int
@@ -345,3 +346,155 @@ func TestSimulator_Run_Filebase(t *testing.T) {
 
 	si.Run()
 }
+
+// historyStatusWriter : 기록된 모든 status 를 저장
+type historyStatusWriter struct {
+	times []time.Time
+	sts   []status.Status
+}
+
+func (w *historyStatusWriter) WriteStatus(ti time.Time, st status.Status, cfg data.Config, opt Options) {
+	w.times = append(w.times, ti)
+	w.sts = append(w.sts, st)
+}
+
+// at : ti 이전에 마지막으로 기록된 status
+func (w *historyStatusWriter) at(ti time.Time) status.Status {
+	var st status.Status
+	for i, v := range w.times {
+		if v.After(ti) {
+			break
+		}
+		st = w.sts[i]
+	}
+	return st
+}
+
+func TestSimulator_Run_Reject(t *testing.T) {
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:00:10.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:05.000"),
+			Filename:  "b.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+
+	for _, overflow := range []bool{false, true} {
+		cfg := data.Config{
+			VODs:     []data.VODConfig{data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 1, LimitBps: 1000000000}},
+			Overflow: overflow,
+		}
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &historyStatusWriter{}
+		si := NewSimulator(cfg, Options{}, l, NewTestEventReader(ss), w, nil, nil)
+		si.Run()
+
+		// sess-B 진행 중, overflow 이면 sess-B 의 chunk 는 VOD 를 거치지 않고 origin 에서 처리
+		st := w.at(StrToTime("2017-01-01 00:00:02.000"))
+		originBps, overflowSessions := int64(1000000), int64(0)
+		if overflow {
+			originBps, overflowSessions = 2000000, 1
+		}
+		if st.Origin.Bps != originBps || st.Reject.OverflowSessionCount != overflowSessions ||
+			st.Reject.OverflowBps != originBps-1000000 {
+			t.Errorf("overflow(%v) origin(%+v) reject(%+v) during sess-B", overflow, *st.Origin, *st.Reject)
+		}
+		if v := st.Vods["vod1"]; v.CurSessionCount != 1 || v.CurBps != 1000000 {
+			t.Errorf("overflow(%v) sess-B must not be in vod1, %+v", overflow, *v)
+		}
+		// sess-B 종료는 vod1 의 sess-A 에 영향 없음
+		st = w.at(StrToTime("2017-01-01 00:00:05.000"))
+		if v := st.Vods["vod1"]; v.CurSessionCount != 1 || v.CurBps != 1000000 || st.Reject.OverflowBps != 0 {
+			t.Errorf("overflow(%v) end of sess-B must not change vod1, %+v %+v", overflow, *v, *st.Reject)
+		}
+
+		st = *l.Status(StrToTime("2017-01-01 00:00:10.000"))
+		refused := int64(1)
+		if overflow {
+			refused = 0
+		}
+		if st.Reject.SessionLimitCount != 1 || st.Reject.RefusedCount() != refused ||
+			st.Reject.OverflowSessionCount != 0 || st.Reject.OverflowBps != 0 {
+			t.Errorf("overflow(%v) reject status %+v", overflow, *st.Reject)
+		}
+		if v := st.Vods["vod1"]; v.CurSessionCount != 0 || v.CurBps != 0 {
+			t.Errorf("overflow(%v) vod status %+v", overflow, *v)
+		}
+		if c := st.Caches["vod1"]; c.CacheHitCount+c.CacheMissCount != 1 {
+			t.Errorf("chunks of rejected session must not be processed, %+v", *c)
+		}
+	}
+}
+
+func TestSimulator_Status(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000},
+		},
+	}
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:00:40.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:41.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+
+	// 같은 event 를 공유하는 simulator 를 동시에 실행
+	var sims []*Simulator
+	for i := 0; i < 2; i++ {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sims = append(sims, NewSimulator(cfg, Options{StatusWritePeriod: time.Hour}, l, NewMemEventReader(ss), nil, nil, nil))
+	}
+	done := make(chan struct{})
+	for _, si := range sims {
+		go func(si *Simulator) {
+			si.Run()
+			done <- struct{}{}
+		}(si)
+	}
+	for range sims {
+		<-done
+	}
+
+	for i, si := range sims {
+		st := si.Status()
+		if !st.Time.Equal(StrToTime("2017-01-01 00:00:41.000")) {
+			t.Errorf("%v: invalid status time %v", i, TimeToStr(st.Time))
+		}
+		if st.Origin.PeakBps != 1000000 {
+			t.Errorf("%v: invalid origin status %+v", i, *st.Origin)
+		}
+		c := FindCacheStatus(&st, "vod1")
+		if c == nil || c.CacheMissCount != 3 || c.CacheHitCount != 3 {
+			t.Errorf("%v: invalid cache status %+v", i, c)
+		}
+	}
+	if got := LoadEvents(NewMemEventReader(ss)); len(got) != 2 || got[1] != ss[1] {
+		t.Errorf("invalid loaded events %v", got)
+	}
+}
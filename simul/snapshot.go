@@ -0,0 +1,182 @@
+package simul
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/castisdev/cdn-simul/lb"
+)
+
+// snapshotFile : 특정 시각의 cache, storage 내용, 다른 실행의 warm start 에 사용
+type snapshotFile struct {
+	Time  time.Time
+	Files map[string]int64 // file 크기, warm start 하는 실행의 file info 에 없는 file 추가에 사용
+	LB    *lb.Snapshot
+}
+
+// WriteSnapshot : 현재 cache, storage 내용을 t 시각의 snapshot 으로 fpath 에 기록
+// LoadBalancer 가 lb.Snapshotter 를 구현해야 함
+func (s *Simulator) WriteSnapshot(fpath string, t time.Time) error {
+	sn, ok := s.lb.(lb.Snapshotter)
+	if !ok {
+		return fmt.Errorf("lb %T does not support snapshot", s.lb)
+	}
+	sf := snapshotFile{Time: t, Files: make(map[string]int64)}
+	names := make(map[int]string)
+	for k, v := range s.filenameMap {
+		names[v] = k
+	}
+	var err error
+	sf.LB, err = sn.Snapshot(func(file int) string {
+		if s.fileInfos != nil {
+			fi := s.fileInfos.Info(file)
+			sf.Files[fi.File] = fi.Size
+			return fi.File
+		}
+		return names[file]
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp := fpath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(&sf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fpath)
+}
+
+// WarmStart : fpath 의 snapshot 으로 cache, storage 를 채움, Run 전에 호출
+// remap 이면 snapshot 의 VOD 가 아니라 현재 selector 가 정하는 VOD 에 채움
+// StartTime 이 없으면 snapshot 시각 이전의 event 는 무시
+func (s *Simulator) WarmStart(fpath string, remap bool) error {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var sf snapshotFile
+	if err := gob.NewDecoder(f).Decode(&sf); err != nil {
+		return fmt.Errorf("failed to decode snapshot, %v", err)
+	}
+	sn, ok := s.lb.(lb.Snapshotter)
+	if !ok {
+		return fmt.Errorf("lb %T does not support warm start", s.lb)
+	}
+	fileID := func(name string) int {
+		if s.fileInfos != nil && s.fileInfos.Exists(name) == false {
+			sz, ok := sf.Files[name]
+			if !ok || sz == 0 {
+				sz = 2 * 1024 * 1024 * 1024
+			}
+			s.fileInfos.AddOne(name, sz, StrToTime("2017-01-01 00:00:00.000"))
+		}
+		return s.getFilename(name)
+	}
+	if err := sn.WarmUp(sf.LB, fileID, remap); err != nil {
+		return err
+	}
+	if s.startT.IsZero() {
+		s.startT = sf.Time
+		fmt.Printf("events (started time < %v) will be ignored\n", TimeToStr(s.startT))
+	}
+	log.Printf("warm started from %v, snapshot time %v\n", fpath, TimeToStr(sf.Time))
+	return nil
+}
+
+// applyMarksUntil : t 이전에 끝나는 warm-up, 기록할 snapshot 을 시각 순서대로 처리
+func (s *Simulator) applyMarksUntil(t time.Time) {
+	for {
+		warmUp := s.warmUpDue(t)
+		snapshot := s.snapshotDue(t)
+		switch {
+		case warmUp && (!snapshot || !s.warmUpEndT.After(s.opt.SnapshotTime)):
+			s.processEventsUntil(s.warmUpEndT, s.internalEvents, s.lb)
+			s.endWarmUp()
+		case snapshot:
+			s.processEventsUntil(s.opt.SnapshotTime, s.internalEvents, s.lb)
+			s.takeSnapshot(s.opt.SnapshotTime)
+		default:
+			return
+		}
+	}
+}
+
+// warmUpDue : warm-up 은 처음 처리하는 event 시각부터 WarmUp 동안
+func (s *Simulator) warmUpDue(t time.Time) bool {
+	if s.opt.WarmUp <= 0 || s.warmUpDone {
+		return false
+	}
+	if s.warmUpEndT.IsZero() {
+		s.warmUpEndT = t.Add(s.opt.WarmUp)
+	}
+	return !t.Before(s.warmUpEndT)
+}
+
+func (s *Simulator) snapshotDue(t time.Time) bool {
+	return s.opt.SnapshotFile != "" && !s.snapshotDone && !s.opt.SnapshotTime.IsZero() && !t.Before(s.opt.SnapshotTime)
+}
+
+func (s *Simulator) takeSnapshot(t time.Time) {
+	if err := s.WriteSnapshot(s.opt.SnapshotFile, t); err != nil {
+		log.Fatalf("failed to write snapshot, %v", err)
+	}
+	s.snapshotDone = true
+	log.Printf("snapshot written at %v\n", TimeToStr(t))
+}
+
+// endWarmUp : warm-up 동안의 누적 통계를 초기화, 진행 중인 session, chunk 와 cache 내용은 유지
+// 진행 중인 session 의 QoE 는 session 이 끝날 때 집계되므로 warm-up 동안 시작한 session 도 포함됨
+func (s *Simulator) endWarmUp() {
+	r, ok := s.lb.(lb.StatsResetter)
+	if !ok {
+		log.Fatalf("lb %T does not support warm-up", s.lb)
+	}
+	r.ResetStats()
+
+	o := s.origin
+	o.peakBps, o.overDuration = 0, 0
+	if !o.overT.IsZero() {
+		o.overT = s.warmUpEndT
+	}
+	o.delayedChunkCount, o.totalDelay = 0, 0
+
+	q := s.qoe
+	q.startups, q.stalls = nil, nil
+	q.rebufferedSessionCount, q.rebufferCount, q.totalStall = 0, 0, 0
+
+	if s.abr != nil {
+		s.abr.switchCount = 0
+		for i := range s.abr.chunkCounts {
+			s.abr.chunkCounts[i] = 0
+		}
+	}
+	s.summary = newSummaryTracker()
+	s.summary.Start, s.summary.NextSampleT = s.warmUpEndT, s.warmUpEndT
+	s.warmUpDone = true
+	log.Printf("warm-up ended at %v, statistics are reset\n", TimeToStr(s.warmUpEndT))
+}
+
+// finishMarks : 실행이 끝날 때까지 기록하지 않은 snapshot 은 마지막 event 시각으로 기록
+func (s *Simulator) finishMarks() {
+	if s.opt.WarmUp > 0 && !s.warmUpDone {
+		log.Printf("simulation ended before warm-up(%v) ends\n", s.opt.WarmUp)
+	}
+	if s.opt.SnapshotFile != "" && !s.snapshotDone {
+		if !s.opt.SnapshotTime.IsZero() {
+			log.Printf("simulation ended before snapshot time %v\n", TimeToStr(s.opt.SnapshotTime))
+		}
+		s.takeSnapshot(s.lastT)
+	}
+}
@@ -0,0 +1,144 @@
+package simul
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+func randomSessions(n int) []*glblog.SessionInfo {
+	r := rand.New(rand.NewSource(1))
+	var ss []*glblog.SessionInfo
+	st := StrToTime("2017-01-01 00:00:00.000")
+	for i := 0; i < n; i++ {
+		st = st.Add(time.Duration(r.Intn(60)) * time.Second)
+		ss = append(ss, &glblog.SessionInfo{
+			SID:       fmt.Sprintf("sess-%d", i),
+			Started:   st,
+			Ended:     st.Add(time.Duration(10+r.Intn(600)) * time.Second),
+			Filename:  fmt.Sprintf("%d.mpg", r.Intn(10)*r.Intn(4)),
+			Bandwidth: 1000000 + r.Intn(3)*1000000,
+		})
+	}
+	return ss
+}
+
+func cacheHitMiss(st status.Status) (hit, miss int64) {
+	for _, v := range st.Caches {
+		hit += v.CacheHitCount
+		miss += v.CacheMissCount
+	}
+	return hit, miss
+}
+
+func TestSimulator_WarmStart(t *testing.T) {
+	vodCfg := func(id string) data.VODConfig {
+		return data.VODConfig{VodID: id, StorageSize: 20000000, LimitSession: 100, LimitBps: 200000000, CachePolicy: "lru"}
+	}
+	cfg := data.Config{VODs: []data.VODConfig{vodCfg("vod1"), vodCfg("vod2")}}
+	ss := randomSessions(300)
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newSimulator := func(cfg data.Config, opt Options) *Simulator {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewSimulator(cfg, opt, l, NewMemEventReader(ss), nil, nil, nil)
+	}
+	fpath := filepath.Join(dir, "simul.snapshot")
+	snapT := StrToTime("2017-01-01 01:00:00.000")
+	newSimulator(cfg, Options{StatusWritePeriod: time.Hour, SnapshotFile: fpath, SnapshotTime: snapT}).Run()
+
+	cold := newSimulator(cfg, Options{StatusWritePeriod: time.Hour, StartTime: snapT})
+	cold.Run()
+	warm := newSimulator(cfg, Options{StatusWritePeriod: time.Hour})
+	if err := warm.WarmStart(fpath, false); err != nil {
+		t.Fatal(err)
+	}
+	if !warm.startT.Equal(snapT) {
+		t.Errorf("start time must be snapshot time, %v", warm.startT)
+	}
+	warm.Run()
+	coldHit, coldMiss := cacheHitMiss(cold.Status())
+	warmHit, warmMiss := cacheHitMiss(warm.Status())
+	if coldHit+coldMiss != warmHit+warmMiss || warmHit <= coldHit {
+		t.Errorf("warm start must have more hits, cold(%v/%v) warm(%v/%v)", coldHit, coldMiss, warmHit, warmMiss)
+	}
+
+	// VOD 가 바뀐 설정에서 remap 하면 chunk 는 owner VOD 에 채워짐
+	cfg3 := data.Config{VODs: []data.VODConfig{vodCfg("vod1"), vodCfg("vod2"), vodCfg("vod3")}}
+	remapped := newSimulator(cfg3, Options{StatusWritePeriod: time.Hour})
+	if err := remapped.WarmStart(fpath, true); err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[int]string)
+	for k, v := range remapped.filenameMap {
+		names[v] = k
+	}
+	l := remapped.lb.(*lb.LB)
+	var chunks int
+	for k, c := range l.Caches {
+		contents, err := c.Contents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range contents {
+			if owner := l.Selector.(lb.Owner).Owner(names[e.Key.File]); owner != k {
+				t.Errorf("chunk of %v must be in %v, not %v", names[e.Key.File], owner, k)
+			}
+		}
+		chunks += len(contents)
+	}
+	if chunks == 0 {
+		t.Errorf("remapped caches must not be empty")
+	}
+}
+
+func TestSimulator_WarmUp(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 20000000, LimitSession: 3, LimitBps: 20000000},
+			data.VODConfig{VodID: "vod2", StorageSize: 20000000, LimitSession: 3, LimitBps: 20000000},
+		},
+	}
+	ss := randomSessions(300)
+	run := func(opt Options) status.Status {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		si := NewSimulator(cfg, opt, l, NewMemEventReader(ss), nil, nil, nil)
+		si.Run()
+		return si.Status()
+	}
+	full := run(Options{StatusWritePeriod: time.Hour})
+	warmUp := run(Options{StatusWritePeriod: time.Hour, WarmUp: time.Hour})
+
+	fullHit, fullMiss := cacheHitMiss(full)
+	hit, miss := cacheHitMiss(warmUp)
+	if hit+miss == 0 || hit+miss >= fullHit+fullMiss {
+		t.Errorf("warm-up statistics must be excluded, full(%v/%v) warm-up(%v/%v)", fullHit, fullMiss, hit, miss)
+	}
+	for k, v := range full.Caches {
+		if warmUp.Caches[k].CurSize != v.CurSize {
+			t.Errorf("warm-up must not change cache, %v %v", warmUp.Caches[k].CurSize, v.CurSize)
+		}
+	}
+	if warmUp.Reject.SessionLimitCount+warmUp.Reject.BpsLimitCount >= full.Reject.SessionLimitCount+full.Reject.BpsLimitCount {
+		t.Errorf("rejected sessions of warm-up must be excluded, full(%+v) warm-up(%+v)", full.Reject, warmUp.Reject)
+	}
+}
@@ -30,10 +30,41 @@ func (w DBStatusWriter) WriteStatus(ti time.Time, st status.Status, cfg data.Con
 	for _, v := range st.Caches {
 		vcfg := FindConfig(&cfg, v.VODKey)
 		vod := st.Vods[vod.Key(v.VODKey)]
-		str += fmt.Sprintf("cache,simul=%s,vod=%s hit=%d,miss=%d,originbps=%d,disk=%d,disklimit=%d %d\n",
-			opt.SimulID, v.VODKey, v.CacheHitCount, v.CacheMissCount, v.OriginBps, v.CurSize, vcfg.StorageSize, t)
-		str += fmt.Sprintf("vod,simul=%s,vod=%s bps=%d,bpslimit=%d,session=%d,sessionlimit=%d,sessiontotal=%d,hit=%d %d\n",
-			opt.SimulID, v.VODKey, vod.CurBps, vcfg.LimitBps, vod.CurSessionCount, vcfg.LimitSession, vod.TotalSessionCount, vod.HitSessionCount, t)
+		str += fmt.Sprintf("cache,simul=%s,vod=%s hit=%d,miss=%d,originbps=%d,disk=%d,disklimit=%d,admit=%d,reject=%d %d\n",
+			opt.SimulID, v.VODKey, v.CacheHitCount, v.CacheMissCount, v.OriginBps, v.CurSize, vcfg.StorageSize, v.AdmitCount, v.RejectCount, t)
+		str += fmt.Sprintf("vod,simul=%s,vod=%s bps=%d,bpslimit=%d,session=%d,sessionlimit=%d,sessiontotal=%d,hit=%d,down=%v %d\n",
+			opt.SimulID, v.VODKey, vod.CurBps, vcfg.LimitBps, vod.CurSessionCount, vcfg.LimitSession, vod.TotalSessionCount, vod.HitSessionCount, vod.Down, t)
+	}
+	if o := st.Origin; o != nil {
+		str += fmt.Sprintf("origin,simul=%s bps=%d,bpslimit=%d,peakbps=%d,overlimit=%d,delayedchunk=%d,delay=%d,misssession=%d %d\n",
+			opt.SimulID, o.Bps, o.LimitBps, o.PeakBps, int64(o.OverLimitDuration.Seconds()), o.DelayedChunkCount,
+			int64(o.TotalDelay.Seconds()), o.MissSessionCount, t)
+	}
+	if st.Reject != nil {
+		str += fmt.Sprintf("reject,simul=%s sessionlimit=%d,bpslimit=%d,voddown=%d,overflowsession=%d,overflowbps=%d %d\n",
+			opt.SimulID, st.Reject.SessionLimitCount, st.Reject.BpsLimitCount, st.Reject.VODDownCount,
+			st.Reject.OverflowSessionCount, st.Reject.OverflowBps, t)
+	}
+	if r := st.Replication; r != nil {
+		str += fmt.Sprintf("replication,simul=%s files=%d,replicas=%d,overhead=%d %d\n",
+			opt.SimulID, r.ReplicatedFileCount, r.ExtraReplicaCount, r.OverheadSize, t)
+	}
+	if r := st.Topology; r != nil {
+		str += fmt.Sprintf("topology,simul=%s changes=%d,remapped=%d,totalremapped=%d,tracked=%d,hit=%d,miss=%d %d\n",
+			opt.SimulID, r.ChangeCount, r.RemappedFileCount, r.TotalRemappedCount, r.TrackedFileCount,
+			r.HitSinceChange, r.MissSinceChange, t)
+	}
+	if r := st.ABR; r != nil {
+		fields := fmt.Sprintf("switches=%d", r.SwitchCount)
+		for i, n := range r.RenditionChunks {
+			fields += fmt.Sprintf(",rendition%d=%d", i, n)
+		}
+		str += fmt.Sprintf("abr,simul=%s %s %d\n", opt.SimulID, fields, t)
+	}
+	for _, v := range st.Parents {
+		pcfg := FindParentConfig(&cfg, v.ParentID)
+		str += fmt.Sprintf("parent,simul=%s,parent=%s hit=%d,miss=%d,inbps=%d,originbps=%d,disk=%d,disklimit=%d %d\n",
+			opt.SimulID, v.ParentID, v.CacheHitCount, v.CacheMissCount, v.InBps, v.OriginBps, v.CurSize, pcfg.StorageSize, t)
 	}
 
 	reqBody := bytes.NewBufferString(str)
@@ -103,6 +134,60 @@ func (w *StdStatusWriter) WriteStatus(ti time.Time, st status.Status, cfg data.C
 			humanize.IBytes(uint64(cache.CurSize)), humanize.IBytes(uint64(vc.StorageSize)), int(float64(cache.CurSize)*100/float64(vc.StorageSize)),
 			hit, hit+miss, hitRateFn(hit, miss),
 			humanize.Bytes(uint64(cache.OriginBps)))
+		if v.Down {
+			str += fmt.Sprintf("%s [%15s down]\n", st.Time.Format(layout), v.VODKey)
+		}
+		if vc.CacheAdmission != "" {
+			str += fmt.Sprintf("%s [%15s admission(%v) admit(%v) reject(%v)]\n",
+				st.Time.Format(layout), v.VODKey, vc.CacheAdmission, cache.AdmitCount, cache.RejectCount)
+		}
+	}
+
+	parentHit := int64(0)
+	parentMiss := int64(0)
+	// cfg의 parent 순으로 logging
+	for _, pc := range cfg.Parents {
+		p, ok := st.Parents[pc.ParentID]
+		if !ok {
+			continue
+		}
+		parentHit += p.CacheHitCount
+		parentMiss += p.CacheMissCount
+		str += fmt.Sprintf("%s [%15s parent in-bps(%7v) disk(%8v/%8v/%3v%%) hit(%5v/%5v: %3v %%) origin(%6v)]\n",
+			st.Time.Format(layout), p.ParentID, humanize.Bytes(uint64(p.InBps)),
+			humanize.IBytes(uint64(p.CurSize)), humanize.IBytes(uint64(pc.StorageSize)), int(float64(p.CurSize)*100/float64(pc.StorageSize)),
+			p.CacheHitCount, p.CacheHitCount+p.CacheMissCount, hitRateFn(p.CacheHitCount, p.CacheMissCount),
+			humanize.Bytes(uint64(p.OriginBps)))
+	}
+	if r := st.Reject; r != nil && r.SessionLimitCount+r.BpsLimitCount+r.VODDownCount > 0 {
+		str = fmt.Sprintf("%s rejected(session-limit:%v bps-limit:%v vod-down:%v) overflow(session:%v bps:%v)\n",
+			st.Time.Format(layout), r.SessionLimitCount, r.BpsLimitCount, r.VODDownCount,
+			r.OverflowSessionCount, humanize.Bytes(uint64(r.OverflowBps))) + str
+	}
+	if o := st.Origin; o != nil && o.MissSessionCount > 0 {
+		str = fmt.Sprintf("%s no-file(origin-session:%v)\n", st.Time.Format(layout), o.MissSessionCount) + str
+	}
+	if r := st.Replication; r != nil {
+		str = fmt.Sprintf("%s replication(files:%v extra-replicas:%v overhead:%v)\n",
+			st.Time.Format(layout), r.ReplicatedFileCount, r.ExtraReplicaCount, humanize.IBytes(uint64(r.OverheadSize))) + str
+	}
+	if o := st.Origin; o != nil && o.LimitBps > 0 {
+		str = fmt.Sprintf("%s origin(limit:%v peak:%v over-limit:%v delayed-chunk:%v delay:%v)\n",
+			st.Time.Format(layout), humanize.Bytes(uint64(o.LimitBps)), humanize.Bytes(uint64(o.PeakBps)),
+			o.OverLimitDuration, o.DelayedChunkCount, o.TotalDelay) + str
+	}
+	if r := st.Topology; r != nil {
+		str = fmt.Sprintf("%s topology(changes:%v remapped-files:%v/%v total-remapped:%v) hit-since-change(%4v/%4v: %3v %%)\n",
+			st.Time.Format(layout), r.ChangeCount, r.RemappedFileCount, r.TrackedFileCount, r.TotalRemappedCount,
+			r.HitSinceChange, r.HitSinceChange+r.MissSinceChange, hitRateFn(r.HitSinceChange, r.MissSinceChange)) + str
+	}
+	if r := st.ABR; r != nil {
+		str = fmt.Sprintf("%s abr(switches:%v rendition-chunks:%v)\n",
+			st.Time.Format(layout), r.SwitchCount, r.RenditionChunks) + str
+	}
+	if len(cfg.Parents) > 0 {
+		str = fmt.Sprintf("%s parent-hit(%4v/%4v: %3v %%)\n",
+			st.Time.Format(layout), parentHit, parentHit+parentMiss, hitRateFn(parentHit, parentMiss)) + str
 	}
 
 	str = fmt.Sprintf("\n%s all-full:%v originBps(cur:%4v) hit(%4v/%4v: %3v %%)\n",
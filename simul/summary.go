@@ -0,0 +1,298 @@
+package simul
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/castisdev/cdn-simul/lb/vod"
+	"github.com/castisdev/cdn-simul/status"
+	humanize "github.com/dustin/go-humanize"
+)
+
+// summarySamplePeriod : origin bps, VOD 사용률 percentile 계산에 사용하는 sample 주기 (simulation 시간)
+const summarySamplePeriod = time.Minute
+
+type periodCount struct {
+	Sessions   int64
+	Rejected   int64
+	Chunks     int64
+	HitChunks  int64
+	Bytes      int64
+	HitBytes   int64
+	PeakOrigin status.BpsSample
+}
+
+type vodSamples struct {
+	Sessions []float64
+	Bps      []float64
+}
+
+// summaryTracker : 실행 종료 시 출력할 요약 결과를 누적, checkpoint 에 그대로 기록하므로 field 를 export 함
+type summaryTracker struct {
+	Start       time.Time
+	NextSampleT time.Time
+	Total       periodCount
+	Hours       [24]periodCount
+	Days        map[string]*periodCount
+	Origin      []status.BpsSample
+	VODs        map[vod.Key]*vodSamples
+}
+
+func newSummaryTracker() *summaryTracker {
+	return &summaryTracker{Days: make(map[string]*periodCount), VODs: make(map[vod.Key]*vodSamples)}
+}
+
+func (m *summaryTracker) periods(t time.Time) []*periodCount {
+	day := t.Format("2006-01-02")
+	d, ok := m.Days[day]
+	if !ok {
+		d = &periodCount{}
+		m.Days[day] = d
+	}
+	return []*periodCount{&m.Total, &m.Hours[t.Hour()], d}
+}
+
+// sampleSummary : t 이전의 sample 시각마다 origin 요구량, VOD 사용률 기록, event 처리 전에 호출
+// sample 시각의 값은 그 이전 마지막 event 처리 후의 값
+func (s *Simulator) sampleSummary(t time.Time) {
+	m := s.summary
+	if m.Start.IsZero() {
+		m.Start, m.NextSampleT = t, t
+	}
+	for !m.NextSampleT.After(t) {
+		m.Origin = append(m.Origin, status.BpsSample{Bps: s.originBps(m.NextSampleT), Time: m.NextSampleT})
+		for k, v := range s.lb.GetVODs() {
+			vs, ok := m.VODs[k]
+			if !ok {
+				vs = &vodSamples{}
+				m.VODs[k] = vs
+			}
+			if v.LimitSessionCount > 0 {
+				vs.Sessions = append(vs.Sessions, float64(v.CurSessionCount)/float64(v.LimitSessionCount))
+			}
+			if v.LimitBps > 0 {
+				vs.Bps = append(vs.Bps, float64(v.CurBps)/float64(v.LimitBps))
+			}
+		}
+		m.NextSampleT = m.NextSampleT.Add(summarySamplePeriod)
+	}
+}
+
+func (s *Simulator) summarySession(t time.Time, rejected bool) {
+	for _, p := range s.summary.periods(t) {
+		p.Sessions++
+		if rejected {
+			p.Rejected++
+		}
+	}
+}
+
+func (s *Simulator) summaryChunk(t time.Time, size int64, useOrigin bool) {
+	for _, p := range s.summary.periods(t) {
+		p.Chunks++
+		p.Bytes += size
+		if !useOrigin {
+			p.HitChunks++
+			p.HitBytes += size
+		}
+	}
+}
+
+// summaryOrigin : origin chunk 시작 시점의 요구량으로 기간별 최대값 기록
+func (s *Simulator) summaryOrigin(t time.Time, bps int64) {
+	for _, p := range s.summary.periods(t) {
+		if bps > p.PeakOrigin.Bps {
+			p.PeakOrigin = status.BpsSample{Bps: bps, Time: t}
+		}
+	}
+}
+
+func ratio(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func percentileIdx(n int, p float64) int {
+	idx := int(math.Ceil(float64(n)*p)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= n {
+		idx = n - 1
+	}
+	return idx
+}
+
+func floatPercentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[percentileIdx(len(sorted), p)]
+}
+
+func bpsPercentile(sorted []status.BpsSample, p float64) status.BpsSample {
+	if len(sorted) == 0 {
+		return status.BpsSample{}
+	}
+	return sorted[percentileIdx(len(sorted), p)]
+}
+
+func (p *periodCount) summary(period string) status.PeriodSummary {
+	return status.PeriodSummary{
+		Period:               period,
+		SessionCount:         p.Sessions,
+		RejectedSessionCount: p.Rejected,
+		ChunkCount:           p.Chunks,
+		HitChunkCount:        p.HitChunks,
+		Bytes:                p.Bytes,
+		HitBytes:             p.HitBytes,
+		HitRatio:             ratio(p.HitChunks, p.Chunks),
+		ByteHitRatio:         ratio(p.HitBytes, p.Bytes),
+		PeakOriginBps:        p.PeakOrigin,
+	}
+}
+
+// Summary : 처음 처리한 event(warm-up 이 있으면 warm-up 종료) 부터 마지막 event 까지의 결과 요약
+func (s *Simulator) Summary() *status.Summary {
+	m := s.summary
+	total := m.Total.summary("")
+	sm := &status.Summary{
+		Start:                m.Start,
+		End:                  s.lastT,
+		SessionCount:         total.SessionCount,
+		RejectedSessionCount: total.RejectedSessionCount,
+		RejectReasons:        make(map[string]int64),
+		ChunkCount:           total.ChunkCount,
+		HitChunkCount:        total.HitChunkCount,
+		Bytes:                total.Bytes,
+		HitBytes:             total.HitBytes,
+		HitRatio:             total.HitRatio,
+		ByteHitRatio:         total.ByteHitRatio,
+	}
+	st := s.Status()
+	sm.MissSessionCount = st.Origin.MissSessionCount
+	if r := st.Reject; r != nil {
+		sm.RejectReasons["session-limit"] = r.SessionLimitCount
+		sm.RejectReasons["bps-limit"] = r.BpsLimitCount
+		sm.RejectReasons["vod-down"] = r.VODDownCount
+		sm.OverflowSessionCount = r.OverflowTotalCount
+	}
+
+	origin := append([]status.BpsSample{}, m.Origin...)
+	sort.SliceStable(origin, func(i, j int) bool { return origin[i].Bps < origin[j].Bps })
+	sm.Origin = status.OriginSummary{
+		LimitBps:          st.Origin.LimitBps,
+		P50:               bpsPercentile(origin, 0.5),
+		P95:               bpsPercentile(origin, 0.95),
+		P99:               bpsPercentile(origin, 0.99),
+		Max:               m.Total.PeakOrigin,
+		OverLimitDuration: st.Origin.OverLimitDuration,
+	}
+
+	keys := make([]string, 0, len(m.VODs))
+	for k := range m.VODs {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := m.VODs[vod.Key(k)]
+		sessions := append([]float64{}, v.Sessions...)
+		sort.Float64s(sessions)
+		bps := append([]float64{}, v.Bps...)
+		sort.Float64s(bps)
+		sm.VODs = append(sm.VODs, status.VODSummary{
+			VODKey:     k,
+			SessionP50: floatPercentile(sessions, 0.5),
+			SessionP95: floatPercentile(sessions, 0.95),
+			SessionP99: floatPercentile(sessions, 0.99),
+			SessionMax: floatPercentile(sessions, 1),
+			BpsP50:     floatPercentile(bps, 0.5),
+			BpsP95:     floatPercentile(bps, 0.95),
+			BpsP99:     floatPercentile(bps, 0.99),
+			BpsMax:     floatPercentile(bps, 1),
+		})
+	}
+
+	for h := range m.Hours {
+		sm.Hours = append(sm.Hours, m.Hours[h].summary(fmt.Sprintf("%02d", h)))
+	}
+	days := make([]string, 0, len(m.Days))
+	for k := range m.Days {
+		days = append(days, k)
+	}
+	sort.Strings(days)
+	for _, k := range days {
+		sm.Days = append(sm.Days, m.Days[k].summary(k))
+	}
+	return sm
+}
+
+// WriteSummary : Summary 를 json 으로 fpath 에 기록
+func (s *Simulator) WriteSummary(fpath string) error {
+	b, err := json.MarshalIndent(s.Summary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, b, 0644)
+}
+
+func (s *Simulator) printSummary() {
+	WriteSummaryText(os.Stdout, s.Summary())
+}
+
+// WriteSummaryText : capacity 검토에 첨부할 수 있는 text 형식으로 요약 출력
+func WriteSummaryText(w io.Writer, sm *status.Summary) {
+	pct := func(v float64) string {
+		return fmt.Sprintf("%.1f%%", v*100)
+	}
+	bps := func(v status.BpsSample) string {
+		if v.Time.IsZero() {
+			return humanize.Bytes(uint64(v.Bps))
+		}
+		return fmt.Sprintf("%v(%v)", humanize.Bytes(uint64(v.Bps)), TimeToStr(v.Time))
+	}
+	r := sm.RejectReasons
+	fmt.Fprintf(w, "summary: %v ~ %v\n", TimeToStr(sm.Start), TimeToStr(sm.End))
+	fmt.Fprintf(w, "sessions(%v) rejected(%v) overflow(%v) over-capacity(session-limit:%v bps-limit:%v vod-down:%v) no-file(%v)\n",
+		sm.SessionCount, sm.RejectedSessionCount, sm.OverflowSessionCount, r["session-limit"], r["bps-limit"], r["vod-down"],
+		sm.MissSessionCount)
+	fmt.Fprintf(w, "hit(%v/%v: %v) byte-hit(%v/%v: %v)\n",
+		sm.HitChunkCount, sm.ChunkCount, pct(sm.HitRatio),
+		humanize.IBytes(uint64(sm.HitBytes)), humanize.IBytes(uint64(sm.Bytes)), pct(sm.ByteHitRatio))
+	o := sm.Origin
+	fmt.Fprintf(w, "origin(limit:%v over-limit:%v) p50:%v p95:%v p99:%v max:%v\n",
+		humanize.Bytes(uint64(o.LimitBps)), o.OverLimitDuration, bps(o.P50), bps(o.P95), bps(o.P99), bps(o.Max))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "vod\tsession p50\tp95\tp99\tmax\tbps p50\tp95\tp99\tmax\t")
+	for _, v := range sm.VODs {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n", v.VODKey,
+			pct(v.SessionP50), pct(v.SessionP95), pct(v.SessionP99), pct(v.SessionMax),
+			pct(v.BpsP50), pct(v.BpsP95), pct(v.BpsP99), pct(v.BpsMax))
+	}
+	tw.Flush()
+
+	periods := func(name string, l []status.PeriodSummary) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(tw, "%v\tsessions\trejected\tchunks\thit\tbyte-hit\tpeak-origin\t\n", name)
+		for _, p := range l {
+			if p.SessionCount == 0 && p.ChunkCount == 0 {
+				continue
+			}
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n", p.Period, p.SessionCount, p.RejectedSessionCount,
+				p.ChunkCount, pct(p.HitRatio), pct(p.ByteHitRatio), bps(p.PeakOriginBps))
+		}
+		tw.Flush()
+	}
+	periods("hour", sm.Hours)
+	periods("day", sm.Days)
+}
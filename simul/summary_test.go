@@ -0,0 +1,98 @@
+package simul
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/status"
+)
+
+func TestSimulator_Summary(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 20000000, LimitSession: 3, LimitBps: 20000000},
+			data.VODConfig{VodID: "vod2", StorageSize: 20000000, LimitSession: 3, LimitBps: 20000000},
+		},
+		OriginLimitBps: 3000000,
+	}
+	ss := randomSessions(300)
+	dir, err := ioutil.TempDir("", "summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(cfg data.Config, opt Options) *Simulator {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		si := NewSimulator(cfg, opt, l, NewMemEventReader(ss), nil, nil, nil)
+		si.Run()
+		return si
+	}
+	fpath := filepath.Join(dir, "summary.json")
+	si := run(cfg, Options{StatusWritePeriod: time.Hour, SummaryFile: fpath})
+	sm := si.Summary()
+	st := si.Status()
+
+	hit, miss := cacheHitMiss(st)
+	if sm.HitChunkCount != hit || sm.ChunkCount != hit+miss || sm.HitBytes > sm.Bytes || sm.ByteHitRatio <= 0 {
+		t.Errorf("invalid hit summary %+v, status(%v/%v)", sm, hit, hit+miss)
+	}
+	rejected := st.Reject.SessionLimitCount + st.Reject.BpsLimitCount
+	if sm.SessionCount != int64(len(ss)) || sm.RejectedSessionCount != rejected || rejected == 0 {
+		t.Errorf("invalid session summary %v/%v, rejected %v", sm.SessionCount, sm.RejectedSessionCount, rejected)
+	}
+	overflowCfg := cfg
+	overflowCfg.Overflow = true
+	overflow := run(overflowCfg, Options{StatusWritePeriod: time.Hour}).Summary()
+	r := overflow.RejectReasons
+	if overflow.RejectedSessionCount != 0 || overflow.OverflowSessionCount == 0 ||
+		r["session-limit"]+r["bps-limit"]+r["vod-down"] != overflow.RejectedSessionCount+overflow.OverflowSessionCount {
+		t.Errorf("overflow sessions must be reported separately, %v/%v %v",
+			overflow.RejectedSessionCount, overflow.OverflowSessionCount, r)
+	}
+	if sm.Origin.Max.Bps != st.Origin.PeakBps || sm.Origin.P50.Bps > sm.Origin.P99.Bps || sm.Origin.P99.Bps > sm.Origin.Max.Bps {
+		t.Errorf("invalid origin summary %+v, peak %v", sm.Origin, st.Origin.PeakBps)
+	}
+	if len(sm.VODs) != 2 || sm.VODs[0].SessionMax != 1 || sm.VODs[0].SessionP50 > sm.VODs[0].SessionP95 {
+		t.Errorf("invalid vod summary %+v", sm.VODs)
+	}
+	if len(sm.Hours) != 24 || len(sm.Days) != 1 || sm.Days[0].Period != "2017-01-01" {
+		t.Errorf("invalid periods %v %v", len(sm.Hours), sm.Days)
+	}
+	var hours status.PeriodSummary
+	for _, v := range sm.Hours {
+		hours.SessionCount += v.SessionCount
+		hours.ChunkCount += v.ChunkCount
+		hours.HitBytes += v.HitBytes
+	}
+	if hours.SessionCount != sm.SessionCount || hours.ChunkCount != sm.ChunkCount || hours.HitBytes != sm.HitBytes {
+		t.Errorf("sum of hours must be total, %+v", hours)
+	}
+
+	b, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written status.Summary
+	if err := json.Unmarshal(b, &written); err != nil {
+		t.Fatal(err)
+	}
+	if written.ChunkCount != sm.ChunkCount || !reflect.DeepEqual(written.RejectReasons, sm.RejectReasons) {
+		t.Errorf("invalid summary file %+v", written)
+	}
+
+	warmUp := run(cfg, Options{StatusWritePeriod: time.Hour, WarmUp: time.Hour}).Summary()
+	if !warmUp.Start.Equal(ss[0].Started.Add(time.Hour)) || warmUp.ChunkCount >= sm.ChunkCount || warmUp.SessionCount >= sm.SessionCount {
+		t.Errorf("warm-up must be excluded from summary, %v %v/%v", warmUp.Start, warmUp.ChunkCount, sm.ChunkCount)
+	}
+}
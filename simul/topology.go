@@ -0,0 +1,112 @@
+package simul
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/lb"
+	"github.com/castisdev/cdn-simul/lb/vod"
+)
+
+// SetTopology : VOD 추가/삭제/변경 일정 설정, LoadBalancer 가 lb.TopologyHandler 를 구현해야 함
+func (s *Simulator) SetTopology(events []*data.TopologyEvent) error {
+	if _, ok := s.lb.(lb.TopologyHandler); !ok {
+		return fmt.Errorf("loadbalancer does not support topology change")
+	}
+	sorted := append([]*data.TopologyEvent{}, events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	vods := make(map[string]struct{})
+	for _, v := range s.cfg.VODs {
+		vods[v.VodID] = struct{}{}
+	}
+	for _, v := range sorted {
+		_, exists := vods[v.VOD.VodID]
+		switch v.Action {
+		case data.TopologyAdd:
+			if exists {
+				return fmt.Errorf("already exists vod %v", v.VOD.VodID)
+			}
+			vods[v.VOD.VodID] = struct{}{}
+		case data.TopologyRemove:
+			if !exists {
+				return fmt.Errorf("not exists vod %v", v.VOD.VodID)
+			}
+			delete(vods, v.VOD.VodID)
+			if len(vods) == 0 {
+				return fmt.Errorf("can not remove last vod %v", v.VOD.VodID)
+			}
+		case data.TopologyChange:
+			if !exists {
+				return fmt.Errorf("not exists vod %v", v.VOD.VodID)
+			}
+		default:
+			return fmt.Errorf("invalid topology action %v", v.Action)
+		}
+	}
+	s.topology = sorted
+	s.topologyIdx = 0
+	return nil
+}
+
+// applyScheduleUntil : ti 까지의 장애, topology event 를 시간 순으로 처리, 처리한 event 가 있으면 true
+// 같은 시간이면 장애 event 먼저 처리
+func (s *Simulator) applyScheduleUntil(ti time.Time) bool {
+	applied := false
+	for {
+		var f *faultEvent
+		var tp *data.TopologyEvent
+		if s.faultIdx < len(s.faults) && !s.faults[s.faultIdx].time.After(ti) {
+			f = s.faults[s.faultIdx]
+		}
+		if s.topologyIdx < len(s.topology) && !s.topology[s.topologyIdx].Time.After(ti) {
+			tp = s.topology[s.topologyIdx]
+		}
+		if f == nil && tp == nil {
+			return applied
+		}
+		applied = true
+		if tp == nil || (f != nil && !f.time.After(tp.Time)) {
+			s.applyFault(f)
+			s.faultIdx++
+		} else {
+			s.applyTopology(tp)
+			s.topologyIdx++
+		}
+	}
+}
+
+func (s *Simulator) applyTopology(e *data.TopologyEvent) {
+	th := s.lb.(lb.TopologyHandler)
+	key := vod.Key(e.VOD.VodID)
+	var err error
+	sessions, migrated := 0, 0
+	switch e.Action {
+	case data.TopologyAdd:
+		err = th.AddVOD(e.VOD)
+	case data.TopologyRemove:
+		if err = th.DownVOD(key); err != nil {
+			break
+		}
+		sessions, migrated = s.evacuate(e.Time, key)
+		err = th.RemoveVOD(key)
+	case data.TopologyChange:
+		err = th.ChangeVOD(e.VOD)
+	}
+	if err != nil {
+		log.Fatalf("failed to %v vod, %v", e.Action, err)
+	}
+	s.cfg = th.Config()
+
+	str := fmt.Sprintf("%s vod %v: %v", TimeToStr(e.Time), e.Action, e.VOD.VodID)
+	if e.Action == data.TopologyRemove {
+		str += fmt.Sprintf(" sessions(%v) migrated(%v) terminated(%v)", sessions, migrated, sessions-migrated)
+	}
+	if tp := s.lb.Status(e.Time).Topology; tp != nil {
+		str += fmt.Sprintf(" remapped-files(%v/%v)", tp.RemappedFileCount, tp.TrackedFileCount)
+	}
+	fmt.Println(str)
+}
@@ -0,0 +1,98 @@
+package simul
+
+import (
+	"testing"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+)
+
+func TestSimulator_Topology(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{
+			data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000},
+		},
+	}
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-A",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:00:10.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-B",
+			Started:   StrToTime("2017-01-01 00:00:20.000"),
+			Ended:     StrToTime("2017-01-01 00:00:25.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+		},
+		&glblog.SessionInfo{
+			SID:       "sess-C",
+			Started:   StrToTime("2017-01-01 00:00:21.000"),
+			Ended:     StrToTime("2017-01-01 00:00:25.000"),
+			Filename:  "b.mpg",
+			Bandwidth: 1000000,
+		},
+	}
+	// vod2 추가 후 vod1 삭제, sess-A 는 vod1 삭제 시 vod2 로 이전(migrate) 또는 종료
+	// vod2 의 session limit 을 1 로 변경하여 sess-C 는 거부
+	events := []*data.TopologyEvent{
+		&data.TopologyEvent{Time: StrToTime("2017-01-01 00:00:05.000"), Action: data.TopologyRemove, VOD: data.VODConfig{VodID: "vod1"}},
+		&data.TopologyEvent{Time: StrToTime("2017-01-01 00:00:02.000"), Action: data.TopologyAdd,
+			VOD: data.VODConfig{VodID: "vod2", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000}},
+		&data.TopologyEvent{Time: StrToTime("2017-01-01 00:00:15.000"), Action: data.TopologyChange, VOD: data.VODConfig{VodID: "vod2", LimitSession: 1}},
+	}
+
+	for _, migrate := range []bool{false, true} {
+		l, err := lb.New(cfg, &lb.SameHashingWeight{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		si := NewSimulator(cfg, Options{FaultMigrate: migrate}, l, NewTestEventReader(ss), &StdStatusWriter{}, nil, nil)
+		if err := si.SetTopology(events); err != nil {
+			t.Fatal(err)
+		}
+		si.Run()
+
+		if len(si.cfg.VODs) != 1 || si.cfg.VODs[0].VodID != "vod2" || si.cfg.VODs[0].LimitSession != 1 {
+			t.Errorf("migrate(%v) cfg %+v", migrate, si.cfg.VODs)
+		}
+		st := l.Status(StrToTime("2017-01-01 00:00:30.000"))
+		if _, ok := st.Vods["vod1"]; ok {
+			t.Errorf("migrate(%v) vod1 must be removed", migrate)
+		}
+		if st.Reject.SessionLimitCount != 1 {
+			t.Errorf("migrate(%v) reject status %+v", migrate, *st.Reject)
+		}
+		tp := st.Topology
+		if tp.ChangeCount != 3 || tp.TotalRemappedCount != 1 || tp.TrackedFileCount != 1 {
+			t.Errorf("migrate(%v) topology status %+v", migrate, *tp)
+		}
+		// 마지막 변경 이후 sess-B 의 chunk 1개
+		if tp.HitSinceChange+tp.MissSinceChange != 1 {
+			t.Errorf("migrate(%v) chunks after topology change must be counted, %+v", migrate, *tp)
+		}
+		// 이전된 sess-A 의 chunk 가 vod2 에 cache 되어 sess-B 는 hit
+		c := st.Caches["vod2"]
+		if migrate && (c.CacheMissCount != 1 || c.CacheHitCount != 1 || tp.HitSinceChange != 1) {
+			t.Errorf("migrated session and sess-B must be served by vod2, %+v", *c)
+		}
+		if !migrate && (c.CacheMissCount != 1 || c.CacheHitCount != 0 || tp.MissSinceChange != 1) {
+			t.Errorf("terminated session must not be served by vod2, %+v", *c)
+		}
+	}
+
+	l, _ := lb.New(cfg, &lb.SameHashingWeight{})
+	si := NewSimulator(cfg, Options{}, l, NewTestEventReader(ss), nil, nil, nil)
+	if err := si.SetTopology(events[:1]); err == nil {
+		t.Errorf("removing last vod must be failed")
+	}
+	l, _ = lb.NewLegacyLB(cfg, nil)
+	si = NewSimulator(cfg, Options{}, l, NewTestEventReader(ss), nil, nil, nil)
+	if err := si.SetTopology(events); err == nil {
+		t.Errorf("legacy loadbalancer must not support topology change")
+	}
+}
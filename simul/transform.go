@@ -0,0 +1,242 @@
+package simul
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/castisdev/cdn-simul/glblog"
+)
+
+func copySession(ev *glblog.SessionInfo) *glblog.SessionInfo {
+	c := *ev
+	if len(ev.Tricks) > 0 {
+		c.Tricks = append([]glblog.Trick{}, ev.Tricks...)
+	}
+	return &c
+}
+
+type sessionHeap []*glblog.SessionInfo
+
+func (h sessionHeap) Len() int { return len(h) }
+func (h sessionHeap) Less(i, j int) bool {
+	if h[i].Started.Equal(h[j].Started) {
+		return h[i].SID < h[j].SID
+	}
+	return h[i].Started.Before(h[j].Started)
+}
+func (h sessionHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *sessionHeap) Push(x interface{}) {
+	*h = append(*h, x.(*glblog.SessionInfo))
+}
+
+func (h *sessionHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// ShiftReader : session 의 시작, 종료, trick-play 시각을 d 만큼 이동
+type ShiftReader struct {
+	r EventReader
+	d time.Duration
+}
+
+// NewShiftReader :
+func NewShiftReader(r EventReader, d time.Duration) *ShiftReader {
+	return &ShiftReader{r: r, d: d}
+}
+
+// ReadEvent :
+func (s *ShiftReader) ReadEvent() *glblog.SessionInfo {
+	ev := s.r.ReadEvent()
+	if ev == nil {
+		return nil
+	}
+	return shiftSession(ev, s.d)
+}
+
+func shiftSession(ev *glblog.SessionInfo, d time.Duration) *glblog.SessionInfo {
+	c := copySession(ev)
+	c.Started = c.Started.Add(d)
+	c.Ended = c.Ended.Add(d)
+	for i := range c.Tricks {
+		c.Tricks[i].Time = c.Tricks[i].Time.Add(d)
+	}
+	return c
+}
+
+// TimeRangeReader : start 이전에 시작된 session 은 무시, end 이후에 시작된 session 부터 읽지 않음
+// zero time 이면 해당 bound 없음, session 의 종료 시각은 바꾸지 않음
+type TimeRangeReader struct {
+	r     EventReader
+	start time.Time
+	end   time.Time
+	eof   bool
+}
+
+// NewTimeRangeReader :
+func NewTimeRangeReader(r EventReader, start, end time.Time) *TimeRangeReader {
+	return &TimeRangeReader{r: r, start: start, end: end}
+}
+
+// ReadEvent :
+func (s *TimeRangeReader) ReadEvent() *glblog.SessionInfo {
+	for !s.eof {
+		ev := s.r.ReadEvent()
+		if ev == nil || (!s.end.IsZero() && !ev.Started.Before(s.end)) {
+			s.eof = true
+			break
+		}
+		if !s.start.IsZero() && ev.Started.Before(s.start) {
+			continue
+		}
+		return ev
+	}
+	return nil
+}
+
+// SampleReader : session 을 ratio 확률로 남김 (thinning)
+type SampleReader struct {
+	r     EventReader
+	ratio float64
+	rand  *rand.Rand
+}
+
+// NewSampleReader : ratio 는 (0, 1]
+func NewSampleReader(r EventReader, ratio float64, seed int64) (*SampleReader, error) {
+	if ratio <= 0 || ratio > 1 {
+		return nil, fmt.Errorf("invalid sample ratio %v", ratio)
+	}
+	return &SampleReader{r: r, ratio: ratio, rand: rand.New(rand.NewSource(seed))}, nil
+}
+
+// ReadEvent :
+func (s *SampleReader) ReadEvent() *glblog.SessionInfo {
+	for {
+		ev := s.r.ReadEvent()
+		if ev == nil {
+			return nil
+		}
+		if s.rand.Float64() < s.ratio {
+			return ev
+		}
+	}
+}
+
+// ScaleReader : session 을 평균 factor 배로 복제, 복제본은 SID 에 -c<n> 을 붙이고
+// 시작 시각을 [0, jitter) 만큼 늦춤 (재생 시간, 위치는 같음)
+// factor 의 소수 부분은 확률로 적용, (ex) 1.3 이면 30% 의 session 만 1개 복제
+type ScaleReader struct {
+	r       EventReader
+	factor  float64
+	jitter  time.Duration
+	rand    *rand.Rand
+	pending *sessionHeap
+	lastT   time.Time
+	eof     bool
+}
+
+// NewScaleReader : factor 는 1 이상
+func NewScaleReader(r EventReader, factor float64, jitter time.Duration, seed int64) (*ScaleReader, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("invalid scale factor %v, use sample for thinning", factor)
+	}
+	if jitter < 0 {
+		return nil, fmt.Errorf("invalid jitter %v", jitter)
+	}
+	h := &sessionHeap{}
+	heap.Init(h)
+	return &ScaleReader{r: r, factor: factor, jitter: jitter, rand: rand.New(rand.NewSource(seed)), pending: h}, nil
+}
+
+// ReadEvent :
+func (s *ScaleReader) ReadEvent() *glblog.SessionInfo {
+	// 이후에 읽는 session 과 복제본은 lastT 이후에 시작하므로 lastT 전에 시작하는 session 부터 내보냄
+	for !s.eof && (s.pending.Len() == 0 || !(*s.pending)[0].Started.Before(s.lastT)) {
+		ev := s.r.ReadEvent()
+		if ev == nil {
+			s.eof = true
+			break
+		}
+		s.lastT = ev.Started
+		heap.Push(s.pending, ev)
+		n := int(s.factor) - 1
+		if s.rand.Float64() < s.factor-float64(int(s.factor)) {
+			n++
+		}
+		for i := 1; i <= n; i++ {
+			var d time.Duration
+			if s.jitter > 0 {
+				d = time.Duration(s.rand.Int63n(int64(s.jitter))).Truncate(time.Millisecond)
+			}
+			c := shiftSession(ev, d)
+			c.SID = fmt.Sprintf("%s-c%d", ev.SID, i)
+			heap.Push(s.pending, c)
+		}
+	}
+	if s.pending.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(s.pending).(*glblog.SessionInfo)
+}
+
+// MergeReader : 여러 reader 의 session 을 시작 시각 순으로 합침, 각 reader 는 시작 시각 순이어야 함
+type MergeReader struct {
+	readers []EventReader
+	heads   []*glblog.SessionInfo
+	started bool
+}
+
+// NewMergeReader :
+func NewMergeReader(readers ...EventReader) *MergeReader {
+	return &MergeReader{readers: readers, heads: make([]*glblog.SessionInfo, len(readers))}
+}
+
+// ReadEvent :
+func (s *MergeReader) ReadEvent() *glblog.SessionInfo {
+	if !s.started {
+		s.started = true
+		for i, r := range s.readers {
+			s.heads[i] = r.ReadEvent()
+		}
+	}
+	min := -1
+	for i, v := range s.heads {
+		if v != nil && (min == -1 || v.Started.Before(s.heads[min].Started)) {
+			min = i
+		}
+	}
+	if min == -1 {
+		return nil
+	}
+	ev := s.heads[min]
+	s.heads[min] = s.readers[min].ReadEvent()
+	return ev
+}
+
+// PrefixReader : SID 앞에 prefix 를 붙임, 여러 trace 를 합칠 때 SID 충돌 방지
+type PrefixReader struct {
+	r      EventReader
+	prefix string
+}
+
+// NewPrefixReader :
+func NewPrefixReader(r EventReader, prefix string) *PrefixReader {
+	return &PrefixReader{r: r, prefix: prefix}
+}
+
+// ReadEvent :
+func (s *PrefixReader) ReadEvent() *glblog.SessionInfo {
+	ev := s.r.ReadEvent()
+	if ev == nil {
+		return nil
+	}
+	c := copySession(ev)
+	c.SID = s.prefix + c.SID
+	return c
+}
@@ -0,0 +1,152 @@
+package simul
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func testTrace(prefix string, startSec []int) []*glblog.SessionInfo {
+	base := StrToTime("2017-01-01 00:00:00.000")
+	var ss []*glblog.SessionInfo
+	for i, v := range startSec {
+		started := base.Add(time.Duration(v) * time.Second)
+		ss = append(ss, &glblog.SessionInfo{
+			SID:       prefix + string(rune('a'+i)),
+			Started:   started,
+			Ended:     started.Add(10 * time.Second),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+			Tricks:    []glblog.Trick{glblog.Trick{Time: started.Add(time.Second), Type: glblog.TrickPause, Offset: -1}},
+		})
+	}
+	return ss
+}
+
+func readAll(t *testing.T, r EventReader) []*glblog.SessionInfo {
+	var ret []*glblog.SessionInfo
+	for {
+		ev := r.ReadEvent()
+		if ev == nil {
+			return ret
+		}
+		if len(ret) > 0 && ev.Started.Before(ret[len(ret)-1].Started) {
+			t.Fatalf("not ordered by start time, %v < %v", ev.Started, ret[len(ret)-1].Started)
+		}
+		ret = append(ret, ev)
+	}
+}
+
+func TestShiftReader(t *testing.T) {
+	ss := testTrace("s", []int{0, 5})
+	got := readAll(t, NewShiftReader(NewTestEventReader(ss), time.Hour))
+	if len(got) != 2 {
+		t.Fatalf("invalid count %v", len(got))
+	}
+	if !got[1].Started.Equal(ss[1].Started.Add(time.Hour)) || !got[1].Ended.Equal(ss[1].Ended.Add(time.Hour)) ||
+		!got[1].Tricks[0].Time.Equal(ss[1].Tricks[0].Time.Add(time.Hour)) {
+		t.Errorf("invalid shifted session %v %v", got[1], got[1].Tricks)
+	}
+	// 원본은 바뀌지 않음
+	if !ss[1].Tricks[0].Time.Equal(ss[1].Started.Add(time.Second)) {
+		t.Errorf("source session was modified, %v", ss[1].Tricks)
+	}
+}
+
+func TestTimeRangeReader(t *testing.T) {
+	ss := testTrace("s", []int{0, 5, 10, 15})
+	got := readAll(t, NewTimeRangeReader(NewTestEventReader(ss), ss[1].Started, ss[3].Started))
+	if len(got) != 2 || got[0].SID != "sb" || got[1].SID != "sc" {
+		t.Errorf("invalid sessions %v", got)
+	}
+	if got := readAll(t, NewTimeRangeReader(NewTestEventReader(ss), time.Time{}, time.Time{})); len(got) != 4 {
+		t.Errorf("invalid count without bound %v", len(got))
+	}
+}
+
+func TestSampleReader(t *testing.T) {
+	var starts []int
+	for i := 0; i < 1000; i++ {
+		starts = append(starts, i)
+	}
+	ss := testTrace("s", starts)
+	r, err := NewSampleReader(NewTestEventReader(ss), 0.3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(readAll(t, r)); n < 250 || n > 350 {
+		t.Errorf("invalid sampled count %v", n)
+	}
+	if _, err := NewSampleReader(NewTestEventReader(ss), 0, 1); err == nil {
+		t.Errorf("ratio 0 must be failed")
+	}
+}
+
+func TestScaleReader(t *testing.T) {
+	var starts []int
+	for i := 0; i < 1000; i++ {
+		starts = append(starts, i)
+	}
+	ss := testTrace("s", starts)
+	r, err := NewScaleReader(NewTestEventReader(ss), 2.5, 30*time.Second, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readAll(t, r)
+	if len(got) < 2400 || len(got) > 2600 {
+		t.Errorf("invalid scaled count %v", len(got))
+	}
+	sids := make(map[string]struct{})
+	for _, v := range got {
+		if _, ok := sids[v.SID]; ok {
+			t.Fatalf("duplicated sid %v", v.SID)
+		}
+		sids[v.SID] = struct{}{}
+		if v.Ended.Sub(v.Started) != 10*time.Second {
+			t.Errorf("duration of cloned session must be same, %v", v)
+		}
+	}
+	if _, ok := sids["sa-c1"]; !ok {
+		t.Errorf("not exists clone of first session")
+	}
+	if _, err := NewScaleReader(NewTestEventReader(ss), 0.5, 0, 1); err == nil {
+		t.Errorf("factor < 1 must be failed")
+	}
+}
+
+func TestMergeReader(t *testing.T) {
+	a := NewPrefixReader(NewTestEventReader(testTrace("s", []int{0, 10, 20})), "0-")
+	b := NewPrefixReader(NewTestEventReader(testTrace("s", []int{5, 15})), "1-")
+	got := readAll(t, NewMergeReader(a, b, NewTestEventReader(nil)))
+	var sids []string
+	for _, v := range got {
+		sids = append(sids, v.SID)
+	}
+	if strings.Join(sids, ",") != "0-sa,1-sa,0-sb,1-sb,0-sc" {
+		t.Errorf("invalid merged sessions %v", sids)
+	}
+}
+
+func TestWriteDBEvents(t *testing.T) {
+	path := "test.transform.db"
+	defer os.RemoveAll(path)
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// 시작 시각이 같은 session 이 SID 로 구분되어 모두 기록됨
+	ss := testTrace("s", []int{10, 0, 0})
+	n, err := WriteDBEvents(db, NewTestEventReader(ss))
+	if err != nil || n != 3 {
+		t.Fatalf("%v %v", n, err)
+	}
+	got := readAll(t, NewDBEventReader(db))
+	if len(got) != 3 || got[0].SID != "sb" || got[2].SID != "sa" || len(got[2].Tricks) != 1 {
+		t.Errorf("invalid sessions from db %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package simul
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/glblog"
+)
+
+// trickEvent : session 의 trick-play event, offset 은 session 시작 시 chunk index 로 변환
+type trickEvent struct {
+	time  time.Time
+	typ   glblog.TrickType
+	index int // -1 이면 재생 위치 변경 없음
+}
+
+// newTrickEvents : offsetIndex 는 byte offset 을 chunk index 로 변환
+func newTrickEvents(tricks []glblog.Trick, offsetIndex func(offset int64) int) []trickEvent {
+	if len(tricks) == 0 {
+		return nil
+	}
+	events := make([]trickEvent, 0, len(tricks))
+	for _, v := range tricks {
+		e := trickEvent{time: v.Time, typ: v.Type, index: -1}
+		if v.Offset >= 0 {
+			e.index = offsetIndex(v.Offset)
+		}
+		events = append(events, e)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].time.Before(events[j].time) })
+	return events
+}
+
+// offsetIndex : session bps 로 offset 만큼 재생한 위치의 chunk index
+func (s *Simulator) offsetIndex(filename string, offset int64, bw int) int {
+	size := s.chunkSizer.Size(filename)
+	if ladder := s.abrLadder(filename); ladder != nil {
+		_, _, du := abrChunk(ladder, size, 0)
+		return int(float64(8*offset) / float64(bw) / du.Seconds())
+	}
+	return int(offset / size)
+}
+
+// applyTricks : t 까지의 trick-play event 를 적용, seek, play 는 다음 chunk index 를 바꾸고
+// pause, ff, rw 는 다음 play, seek 까지 chunk 요청을 멈춤
+// chunk 요청 중에 발생한 event 는 chunk 가 끝난 뒤 적용
+func (e *endEvent) applyTricks(t time.Time) {
+	for len(e.tricks) > 0 && !e.tricks[0].time.After(t) {
+		v := e.tricks[0]
+		e.tricks = e.tricks[1:]
+		switch v.typ {
+		case glblog.TrickPause, glblog.TrickFF, glblog.TrickRW:
+			e.paused = true
+		default:
+			e.paused = false
+		}
+		if v.index >= 0 {
+			e.index = v.index
+		}
+	}
+}
+
+// resumeTime : 멈춘 session 의 다음 trick-play event 시각, session 종료 전에 없으면 false
+func (e *endEvent) resumeTime() (time.Time, bool) {
+	if len(e.tricks) == 0 {
+		return time.Time{}, false
+	}
+	t := e.tricks[0].time
+	if t.Before(e.sessionEndTime.Add(-time.Millisecond)) {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// pauseChunk : 다음 chunk 요청 전에 trick-play event 적용, chunk 요청이 멈췄으면
+// 다음 trick-play event 시각에 다시 확인하도록 chunkResume event 로 바꾸고 true
+func (e *endEvent) pauseChunk(events *eventHeap) bool {
+	e.applyTricks(e.time)
+	if !e.paused {
+		e.endType = chunkEnd
+		return false
+	}
+	if t, ok := e.resumeTime(); ok {
+		e.endType = chunkResume
+		e.time = t
+		heap.Push(events, e)
+	}
+	return true
+}
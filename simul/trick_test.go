@@ -0,0 +1,91 @@
+package simul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/glblog"
+	"github.com/castisdev/cdn-simul/lb"
+)
+
+func TestSimulator_Tricks(t *testing.T) {
+	cfg := data.Config{
+		VODs: []data.VODConfig{data.VODConfig{VodID: "vod1", StorageSize: 1000000000, LimitSession: 10, LimitBps: 1000000000}},
+	}
+	// chunk 재생 시간 16초
+	ss := []*glblog.SessionInfo{
+		&glblog.SessionInfo{
+			SID:       "sess-1",
+			Started:   StrToTime("2017-01-01 00:00:00.000"),
+			Ended:     StrToTime("2017-01-01 00:01:40.000"),
+			Filename:  "a.mpg",
+			Bandwidth: 1000000,
+			Tricks: []glblog.Trick{
+				glblog.Trick{Time: StrToTime("2017-01-01 00:00:10.000"), Type: glblog.TrickPause, Offset: -1},
+				glblog.Trick{Time: StrToTime("2017-01-01 00:00:40.000"), Type: glblog.TrickPlay, Offset: -1},
+				glblog.Trick{Time: StrToTime("2017-01-01 00:00:50.000"), Type: glblog.TrickSeek, Offset: 20000000},
+			},
+		},
+		// 빨리 감기 후 재생 재개 없이 종료
+		&glblog.SessionInfo{
+			SID:       "sess-2",
+			Started:   StrToTime("2017-01-01 00:00:01.000"),
+			Ended:     StrToTime("2017-01-01 00:01:41.000"),
+			Filename:  "b.mpg",
+			Bandwidth: 1000000,
+			Tricks: []glblog.Trick{
+				glblog.Trick{Time: StrToTime("2017-01-01 00:00:05.000"), Type: glblog.TrickFF, Offset: 0},
+			},
+		},
+	}
+
+	r := NewChunkRefReader(NewTestEventReader(ss), testChunkSizer(t), time.Time{}, 0)
+	var refs []*ChunkRef
+	for {
+		ref := r.ReadChunkRef()
+		if ref == nil {
+			break
+		}
+		if ref.SessionID == "sess-1" {
+			refs = append(refs, ref)
+		} else if ref.Index != 0 {
+			t.Errorf("chunk request of fast-forwarding session, %v", ref)
+		}
+	}
+	// 10초에 멈췄으므로 두번째 chunk 는 재개된 40초에, 50초의 seek 는 다음 chunk(56초) 부터 적용
+	exp := []struct {
+		t     string
+		index int64
+	}{
+		{"2017-01-01 00:00:00.000", 0},
+		{"2017-01-01 00:00:40.000", 1},
+		{"2017-01-01 00:00:56.000", 10},
+		{"2017-01-01 00:01:12.000", 11},
+		{"2017-01-01 00:01:28.000", 12},
+	}
+	if len(refs) != len(exp) {
+		t.Fatalf("invalid chunk count %v != %v", len(refs), len(exp))
+	}
+	for i, v := range exp {
+		if refs[i].Index != v.index || !refs[i].Time.Equal(StrToTime(v.t)) {
+			t.Errorf("%v: invalid chunk %+v, expected %v %v", i, *refs[i], v.t, v.index)
+		}
+	}
+
+	l, err := lb.New(cfg, &lb.SameHashingWeight{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := NewSimulator(cfg, Options{StatusWritePeriod: time.Hour}, l, NewTestEventReader(ss), nil, nil, nil)
+	si.Run()
+	c := l.(*lb.LB).Caches["vod1"]
+	if c.MissCount != int64(len(exp)+1) || c.HitCount != 0 {
+		t.Errorf("invalid cache hit(%v) miss(%v)", c.HitCount, c.MissCount)
+	}
+	for _, v := range l.GetVODs() {
+		if v.CurSessionCount != 0 {
+			t.Errorf("all sessions must be ended, %+v", *v)
+		}
+	}
+}
@@ -12,12 +12,74 @@ type Status struct {
 	Origin       *OriginStatus
 	Vods         map[vod.Key]*VODStatus
 	Caches       map[vod.Key]*CacheStatus
+	Parents      map[string]*ParentStatus
+	Reject       *RejectStatus
+	Replication  *ReplicationStatus
+	Topology     *TopologyStatus
+	ABR          *ABRStatus
 	AllCacheFull bool
 }
 
 // OriginStatus :
 type OriginStatus struct {
-	Bps int64
+	Bps               int64
+	LimitBps          int64         // 0 이면 제한 없음
+	PeakBps           int64         // origin 최대 요구량
+	OverLimitDuration time.Duration // 요구량이 limit 을 넘은 시간의 합
+	DelayedChunkCount int64         // limit 초과로 전송이 늦어진 chunk 수
+	TotalDelay        time.Duration // limit 초과로 늘어난 chunk 전송 시간의 합
+	MissSessionCount  int64         // filebase 에서 file 이 없어 거부하지 않고 origin 에서 처리한 session 수
+}
+
+// RejectStatus : VOD 가 처리하지 못한 session 의 reason 별 누적 수
+type RejectStatus struct {
+	SessionLimitCount    int64
+	BpsLimitCount        int64
+	VODDownCount         int64 // 선택 가능한 VOD 가 모두 장애
+	OverflowSessionCount int64 // overflow 로 origin 에서 직접 처리 중인 session 수
+	OverflowBps          int64
+	OverflowTotalCount   int64 // overflow 로 처리한 누적 session 수, reason 별 수에 포함됨
+}
+
+// RefusedCount : reason 별 수 중 overflow 로 처리하지 않고 실제로 거부한 session 수
+func (r *RejectStatus) RefusedCount() int64 {
+	return r.SessionLimitCount + r.BpsLimitCount + r.VODDownCount - r.OverflowTotalCount
+}
+
+// ReplicationStatus : replication selector 가 인기 file 을 여러 VOD 에 배치하여 추가로 사용하는 storage
+type ReplicationStatus struct {
+	ReplicatedFileCount int64 // replica 수가 1 보다 큰 file 수
+	ExtraReplicaCount   int64 // 1개를 제외한 추가 replica 수
+	OverheadSize        int64 // 추가 replica 의 file size 합
+}
+
+// TopologyStatus : 실행 중 VOD 추가/삭제/변경에 따른 file remap 과 이후 cache miss
+type TopologyStatus struct {
+	ChangeCount        int64
+	RemappedFileCount  int64 // 마지막 변경으로 owner VOD 가 바뀐 file 수
+	TotalRemappedCount int64 // 모든 변경으로 owner VOD 가 바뀐 file 수의 합
+	HitSinceChange     int64 // 마지막 변경 이후 cache hit 수
+	MissSinceChange    int64 // 마지막 변경 이후 cache miss 수, 변경 직후 miss storm 측정에 사용
+	TrackedFileCount   int64 // remap 측정 대상 file 수
+}
+
+// ABRStatus : abr session 의 rendition 전환과 rendition 별 chunk 수
+type ABRStatus struct {
+	SwitchCount     int64
+	RenditionChunks []int64 // rendition(ladder 의 index) 별 누적 chunk 수
+}
+
+// QoEStatus : 종료된 session 의 시작 지연, rebuffering 통계
+type QoEStatus struct {
+	SessionCount           int64
+	StartupP50             time.Duration
+	StartupP90             time.Duration
+	StartupP99             time.Duration
+	RebufferedSessionCount int64 // rebuffering 이 1번 이상 발생한 session 수
+	RebufferCount          int64
+	StallP90               time.Duration // session 별 rebuffering 시간 합의 percentile
+	StallP99               time.Duration
+	TotalStall             time.Duration
 }
 
 // VODStatus :
@@ -27,6 +89,7 @@ type VODStatus struct {
 	CurBps            int64
 	TotalSessionCount int64
 	HitSessionCount   int64
+	Down              bool
 }
 
 // CacheStatus :
@@ -36,4 +99,16 @@ type CacheStatus struct {
 	CacheHitCount  int64
 	OriginBps      int64
 	CurSize        int64
+	AdmitCount     int64 // admission 을 통과하여 cache 에 기록된 miss chunk 수
+	RejectCount    int64 // admission 에 의해 cache 에 기록되지 않은 miss chunk 수
+}
+
+// ParentStatus :
+type ParentStatus struct {
+	ParentID       string
+	CacheMissCount int64
+	CacheHitCount  int64
+	InBps          int64 // 하위 VOD 들의 cache miss 로 parent 에 요청되는 bps (VOD-parent link)
+	OriginBps      int64 // parent 의 cache miss 로 origin 에 요청되는 bps (parent-origin link)
+	CurSize        int64
 }
@@ -0,0 +1,70 @@
+package status
+
+import (
+	"time"
+)
+
+// Summary : 실행이 끝날 때의 결과 요약, warm-up 기간은 제외
+// hit 은 VOD cache hit(origin 을 사용하지 않은 chunk) 기준
+type Summary struct {
+	Start                time.Time        `json:"start"`
+	End                  time.Time        `json:"end"`
+	SessionCount         int64            `json:"sessionCount"`
+	RejectedSessionCount int64            `json:"rejectedSessionCount"`
+	RejectReasons        map[string]int64 `json:"rejectReasons"`        // VOD 용량 초과 원인(session-limit, bps-limit, vod-down)별 session 수, rejected + overflow
+	OverflowSessionCount int64            `json:"overflowSessionCount"` // 용량 초과로 origin 에서 직접 처리한 session, 거부가 아님
+	MissSessionCount     int64            `json:"missSessionCount"`     // filebase 에서 file 이 없어 origin 에서 처리한 session, 거부가 아님
+	ChunkCount           int64            `json:"chunkCount"`
+	HitChunkCount        int64            `json:"hitChunkCount"`
+	Bytes                int64            `json:"bytes"`
+	HitBytes             int64            `json:"hitBytes"`
+	HitRatio             float64          `json:"hitRatio"`
+	ByteHitRatio         float64          `json:"byteHitRatio"`
+	Origin               OriginSummary    `json:"origin"`
+	VODs                 []VODSummary     `json:"vods"`
+	Hours                []PeriodSummary  `json:"hours"` // 시간대(0~23시)별, 모든 날짜 합산
+	Days                 []PeriodSummary  `json:"days"`
+}
+
+// BpsSample : 시각별 bps
+type BpsSample struct {
+	Bps  int64     `json:"bps"`
+	Time time.Time `json:"time"`
+}
+
+// OriginSummary : origin 요구량 percentile 은 일정 주기로 sample 한 값 기준, Max 는 모든 chunk 시작 시점 기준
+type OriginSummary struct {
+	LimitBps          int64         `json:"limitBps"`
+	P50               BpsSample     `json:"p50"`
+	P95               BpsSample     `json:"p95"`
+	P99               BpsSample     `json:"p99"`
+	Max               BpsSample     `json:"max"`
+	OverLimitDuration time.Duration `json:"overLimitDuration"`
+}
+
+// VODSummary : session 수, bps 사용률(0~1) percentile, 일정 주기로 sample 한 값 기준
+type VODSummary struct {
+	VODKey     string  `json:"vod"`
+	SessionP50 float64 `json:"sessionP50"`
+	SessionP95 float64 `json:"sessionP95"`
+	SessionP99 float64 `json:"sessionP99"`
+	SessionMax float64 `json:"sessionMax"`
+	BpsP50     float64 `json:"bpsP50"`
+	BpsP95     float64 `json:"bpsP95"`
+	BpsP99     float64 `json:"bpsP99"`
+	BpsMax     float64 `json:"bpsMax"`
+}
+
+// PeriodSummary : 시간대, 날짜별 결과, Period 는 시간대이면 "00"~"23", 날짜이면 "2006-01-02"
+type PeriodSummary struct {
+	Period               string    `json:"period"`
+	SessionCount         int64     `json:"sessionCount"`
+	RejectedSessionCount int64     `json:"rejectedSessionCount"`
+	ChunkCount           int64     `json:"chunkCount"`
+	HitChunkCount        int64     `json:"hitChunkCount"`
+	Bytes                int64     `json:"bytes"`
+	HitBytes             int64     `json:"hitBytes"`
+	HitRatio             float64   `json:"hitRatio"`
+	ByteHitRatio         float64   `json:"byteHitRatio"`
+	PeakOriginBps        BpsSample `json:"peakOriginBps"`
+}
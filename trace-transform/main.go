@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/simul"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func main() {
+	dbFiles := flag.String("db", "session.db", "comma separated event dbs, merged in start time order")
+	outFile := flag.String("out", "transformed.db", "event db to write, removed if exists")
+	sidPrefix := flag.Bool("sid-prefix", true, "if true and multiple dbs, prefix session ids with db index (0-, 1-, ...)")
+	start := flag.String("start", "", "sessions started before this time are dropped, (ex)2017-01-01 00:00:00.000")
+	end := flag.String("end", "", "sessions started at or after this time are dropped, (ex)2017-01-02 00:00:00.000")
+	sample := flag.Float64("sample", 1, "ratio of sessions to keep, (0, 1]")
+	scale := flag.Float64("scale", 1, "load scale factor (>= 1), sessions are cloned with new session ids")
+	jitter := flag.Duration("jitter", time.Minute, "max start delay of cloned sessions (scale)")
+	shift := flag.Duration("shift", 0, "time shift of all sessions, (ex)24h, -1h")
+	seed := flag.Int64("seed", 1, "random seed of sample and scale")
+	flag.Parse()
+
+	var readers []simul.EventReader
+	var meta *data.LogMeta
+	paths := strings.Split(*dbFiles, ",")
+	for i, v := range paths {
+		v = strings.TrimSpace(v)
+		if v == *outFile {
+			log.Fatalf("out db must be different from input db, %v", v)
+		}
+		m, err := data.LoadLogMeta(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if m != nil {
+			if meta != nil && meta.ChunkSize != m.ChunkSize {
+				log.Fatalf("chunk size of %v(%v) != %v", v, m.ChunkSize, meta.ChunkSize)
+			}
+			meta = m
+		}
+		db, err := leveldb.OpenFile(v, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		var r simul.EventReader = simul.NewDBEventReader(db)
+		if *sidPrefix && len(paths) > 1 {
+			r = simul.NewPrefixReader(r, fmt.Sprintf("%d-", i))
+		}
+		readers = append(readers, r)
+	}
+
+	var r simul.EventReader = simul.NewMergeReader(readers...)
+	if *start != "" || *end != "" {
+		var startT, endT time.Time
+		if *start != "" {
+			startT = simul.StrToTime(*start)
+		}
+		if *end != "" {
+			endT = simul.StrToTime(*end)
+		}
+		r = simul.NewTimeRangeReader(r, startT, endT)
+	}
+	if *sample != 1 {
+		sr, err := simul.NewSampleReader(r, *sample, *seed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r = sr
+	}
+	if *scale != 1 {
+		sr, err := simul.NewScaleReader(r, *scale, *jitter, *seed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r = sr
+	}
+	if *shift != 0 {
+		r = simul.NewShiftReader(r, *shift)
+	}
+
+	if err := os.RemoveAll(*outFile); err != nil {
+		log.Fatal(err)
+	}
+	out, err := leveldb.OpenFile(*outFile, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+	if meta != nil {
+		if err := data.WriteLogMeta(*outFile, *meta); err != nil {
+			log.Fatal(err)
+		}
+	}
+	count, err := simul.WriteDBEvents(out, r)
+	if err != nil {
+		log.Fatalf("failed to write sessions, %v", err)
+	}
+	log.Printf("%v sessions written to %v\n", count, *outFile)
+}
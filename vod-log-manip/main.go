@@ -78,9 +78,16 @@ func main() {
 			if err != nil {
 				log.Fatal(err)
 			}
-			if len(logs) > 1 {
-				sort.Sort(vodlog.Sorter(logs))
-				for _, l := range logs {
+			// resetup 은 usage event 로만 판단
+			usages := []vodlog.EventLog{}
+			for _, l := range logs {
+				if l.IsTrick() == false {
+					usages = append(usages, l)
+				}
+			}
+			if len(usages) > 1 {
+				sort.Sort(vodlog.Sorter(usages))
+				for _, l := range usages {
 					fmt.Fprintln(of, l)
 				}
 				fmt.Fprintln(of)
@@ -152,10 +159,14 @@ func doOneFile(fpath string, db, sdb *leveldb.DB) {
 			continue
 		}
 		if minor != vodlog.Usage {
+			// trick-play event 는 session 별 기록에만 추가
+			if e, ok := parseTrick(line, strs, minor); ok {
+				appendSessionLog(sdb, e)
+			}
 			continue
 		}
 
-		var e vodlog.EventLog
+		e := vodlog.EventLog{Type: vodlog.Usage}
 
 		tm, err := strconv.Atoi(strs[2])
 		if err != nil {
@@ -222,34 +233,7 @@ func doOneFile(fpath string, db, sdb *leveldb.DB) {
 			batch.Put([]byte(e.EventTime.Format(vodlog.Layout)+e.VodIP+e.SID+strconv.Itoa(int(e.StartOffset))), buf.Bytes())
 		}
 
-		data, err := sdb.Get([]byte(e.SID), nil)
-		if err != nil && err != leveldb.ErrNotFound {
-			log.Fatal(err)
-		}
-		logs := []vodlog.EventLog{}
-		if data != nil {
-			reader := bytes.NewReader(data)
-			dec := gob.NewDecoder(reader)
-			err := dec.Decode(&logs)
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-		logs = append(logs, e)
-		sort.Sort(vodlog.Sorter(logs))
-
-		{
-			var buf bytes.Buffer
-			enc := gob.NewEncoder(&buf)
-			err = enc.Encode(logs)
-			if err != nil {
-				log.Fatal(err)
-			}
-			err := sdb.Put([]byte(e.SID), buf.Bytes(), nil)
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
+		appendSessionLog(sdb, e)
 	}
 
 	if err := s.Err(); err != nil {
@@ -263,3 +247,76 @@ func doOneFile(fpath string, db, sdb *leveldb.DB) {
 
 	log.Println("done with", fpath)
 }
+
+// parseTrick : FF, RW, Pause, Play, Seek event, offset 이 없으면 StartOffset 은 -1
+func parseTrick(line string, strs []string, minor int64) (vodlog.EventLog, bool) {
+	e := vodlog.EventLog{Type: minor, StartOffset: -1}
+	if e.IsTrick() == false {
+		return e, false
+	}
+
+	tm, err := strconv.Atoi(strs[2])
+	if err != nil {
+		log.Fatal(err)
+	}
+	e.EventTime = time.Unix(int64(tm), 0)
+
+	sepFunc := func(c rune) bool {
+		return c == '[' || c == ']' || c == ',' || c == ' '
+	}
+
+	idx := strings.Index(line, "SessionID")
+	if idx == -1 {
+		return e, false
+	}
+	strs3 := strings.FieldsFunc(line[idx:], sepFunc)
+	if len(strs3) < 2 {
+		return e, false
+	}
+	e.SID = strs3[1]
+
+	for _, name := range []string{"startoffset", "offset"} {
+		idx = strings.Index(line, name)
+		if idx == -1 {
+			continue
+		}
+		strs3 = strings.FieldsFunc(line[idx:], sepFunc)
+		if len(strs3) > 1 {
+			if offset, err := strconv.ParseInt(strs3[1], 10, 64); err == nil {
+				e.StartOffset = offset
+			}
+		}
+		break
+	}
+	return e, true
+}
+
+// appendSessionLog : session 별 event 목록(시간순)에 추가
+func appendSessionLog(sdb *leveldb.DB, e vodlog.EventLog) {
+	data, err := sdb.Get([]byte(e.SID), nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		log.Fatal(err)
+	}
+	logs := []vodlog.EventLog{}
+	if data != nil {
+		reader := bytes.NewReader(data)
+		dec := gob.NewDecoder(reader)
+		err := dec.Decode(&logs)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	logs = append(logs, e)
+	sort.Sort(vodlog.Sorter(logs))
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err = enc.Encode(logs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = sdb.Put([]byte(e.SID), buf.Bytes(), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -52,8 +52,9 @@ const (
 	Usage = 0x0200
 )
 
-// EventLog :
+// EventLog : Type 은 SessionUsage 의 minor type, 0 이면 Usage (Type 이 없던 이전 db 호환)
 type EventLog struct {
+	Type        int64
 	EventTime   time.Time
 	SID         string
 	Filename    string
@@ -65,6 +66,15 @@ type EventLog struct {
 	ClientIP    string
 }
 
+// IsTrick : FF, RW, Pause, Play, Seek event 이면 true
+func (e EventLog) IsTrick() bool {
+	switch e.Type {
+	case FF, RW, Pause, Play, Seek:
+		return true
+	}
+	return false
+}
+
 // Layout :
 var Layout = "2006-01-02 15:04:05"
 
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/castisdev/cdn-simul/data"
+	"github.com/castisdev/cdn-simul/simul"
+	"github.com/castisdev/cdn-simul/workload"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+var layout = "2006-01-02 15:04:05.000"
+
+func main() {
+	dbFile := flag.String("db", "session.db", "event db to write, removed if exists")
+	fiFile := flag.String("file-info", "fileinfo.csv", "csv file to write id,name,bps,size,register-time of generated titles. if empty, not written")
+	chunkSize := flag.Int64("chunk-size", data.DefaultChunkSize, "chunk size (byte) of simulation, recorded in <db>.meta.json, simulator warns if it differs")
+	seed := flag.Int64("seed", 1, "random seed, same seed and parameters generate same workload")
+	start := flag.String("start", "2017-01-01 00:00:00.000", "start time of first session")
+	duration := flag.Duration("duration", 24*time.Hour, "workload duration")
+	sessions := flag.Float64("sessions-per-day", 100000, "average session count per day")
+	diurnal := flag.String("diurnal", "", "24 comma separated relative arrival rates of each hour (default evening peak curve)")
+	catalog := flag.Int("catalog", 10000, "number of titles")
+	zipfS := flag.Float64("zipf-s", 0.8, "zipf exponent, popularity of rank k is proportional to (k+q)^-s")
+	zipfQ := flag.Float64("zipf-q", 0, "zipf-mandelbrot shift q, if 0, zipf")
+	bitrates := flag.String("bitrates", "2000000:0.3,6000000:0.5,15000000:0.2", "bitrate mix of titles, comma separated bps:weight")
+	titleMin := flag.Duration("title-min", 20*time.Minute, "min duration of title")
+	titleMax := flag.Duration("title-max", 120*time.Minute, "max duration of title")
+	sessionMedian := flag.Duration("session-median", 20*time.Minute, "median watch duration of session (lognormal)")
+	sessionSigma := flag.Float64("session-sigma", 1.0, "sigma of log watch duration (lognormal)")
+	releasePeriod := flag.Duration("release-period", 24*time.Hour, "new release injection period")
+	releaseCount := flag.Int("release-count", 0, "number of new titles per release period. if 0, no new release")
+	releaseBoost := flag.Float64("release-boost", 1.0, "popularity of new title relative to the most popular catalog title")
+	releaseHalfLife := flag.Duration("release-half-life", 72*time.Hour, "half-life of new title popularity")
+	flag.Parse()
+
+	loc, _ := time.LoadLocation("Local")
+	startT, err := time.ParseInLocation(layout, *start, loc)
+	if err != nil {
+		log.Fatalf("invalid start time, %v", err)
+	}
+	m := workload.Model{
+		Seed:            *seed,
+		Start:           startT,
+		Duration:        *duration,
+		SessionsPerDay:  *sessions,
+		Catalog:         *catalog,
+		ZipfS:           *zipfS,
+		ZipfQ:           *zipfQ,
+		TitleMin:        *titleMin,
+		TitleMax:        *titleMax,
+		SessionMedian:   *sessionMedian,
+		SessionSigma:    *sessionSigma,
+		ReleasePeriod:   *releasePeriod,
+		ReleaseCount:    *releaseCount,
+		ReleaseBoost:    *releaseBoost,
+		ReleaseHalfLife: *releaseHalfLife,
+	}
+	if *diurnal != "" {
+		for _, v := range strings.Split(*diurnal, ",") {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				log.Fatalf("invalid diurnal %v, %v", *diurnal, err)
+			}
+			m.Diurnal = append(m.Diurnal, f)
+		}
+	}
+	for _, v := range strings.Split(*bitrates, ",") {
+		strs := strings.Split(strings.TrimSpace(v), ":")
+		if len(strs) != 2 {
+			log.Fatalf("invalid bitrates %v", *bitrates)
+		}
+		bps, err := strconv.ParseInt(strs[0], 10, 64)
+		if err != nil {
+			log.Fatalf("invalid bitrates %v, %v", *bitrates, err)
+		}
+		w, err := strconv.ParseFloat(strs[1], 64)
+		if err != nil {
+			log.Fatalf("invalid bitrates %v, %v", *bitrates, err)
+		}
+		m.Bitrates = append(m.Bitrates, workload.BitrateClass{Bps: bps, Weight: w})
+	}
+
+	g, err := workload.NewGenerator(m)
+	if err != nil {
+		log.Fatalf("failed to create generator, %v", err)
+	}
+
+	if err := os.RemoveAll(*dbFile); err != nil {
+		log.Fatal(err)
+	}
+	db, err := leveldb.OpenFile(*dbFile, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	if err := data.WriteLogMeta(*dbFile, data.LogMeta{ChunkSize: *chunkSize}); err != nil {
+		log.Fatal(err)
+	}
+
+	count, err := simul.WriteDBEvents(db, g)
+	if err != nil {
+		log.Fatalf("failed to write sessions, %v", err)
+	}
+	log.Printf("%v sessions written to %v\n", count, *dbFile)
+
+	if *fiFile != "" {
+		writeFileInfos(*fiFile, g.Titles())
+	}
+}
+
+// writeFileInfos : data.NewFileInfos 형식 [id, filename, bitrate, size, register-time]
+func writeFileInfos(fpath string, titles []*workload.Title) {
+	f, err := os.Create(fpath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	for i, v := range titles {
+		fmt.Fprintf(f, "%d,%s,%d,%d,%s\n", i+1, v.Name, v.Bps, v.Size, v.RegisterT.Format("2006-01-02T15:04:05"))
+	}
+	log.Printf("%v titles written to %v\n", len(titles), fpath)
+}
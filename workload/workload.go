@@ -0,0 +1,255 @@
+package workload
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/castisdev/cdn-simul/glblog"
+)
+
+// DefaultDiurnal : 시간대(0~23시)별 상대 session 도착률, 새벽에 가장 낮고 저녁에 가장 높음
+var DefaultDiurnal = []float64{
+	0.6, 0.4, 0.25, 0.15, 0.1, 0.1, 0.15, 0.3, 0.5, 0.6, 0.7, 0.8,
+	0.9, 0.9, 0.9, 0.95, 1.0, 1.1, 1.3, 1.6, 1.9, 2.1, 1.8, 1.1,
+}
+
+// BitrateClass : title 의 bitrate 와 해당 bitrate title 의 비율
+type BitrateClass struct {
+	Bps    int64
+	Weight float64
+}
+
+// Model : 생성할 workload 의 parameter
+type Model struct {
+	Seed           int64
+	Start          time.Time
+	Duration       time.Duration
+	SessionsPerDay float64   // 평균 하루 session 수
+	Diurnal        []float64 // 시간대별 상대 도착률, 24개, 평균이 1 이 되도록 정규화
+	// popularity, 순위 k(1부터) 의 title 은 (k+ZipfQ)^(-ZipfS) 에 비례 (ZipfQ 가 0 이면 Zipf, 아니면 Zipf-Mandelbrot)
+	Catalog  int
+	ZipfS    float64
+	ZipfQ    float64
+	Bitrates []BitrateClass
+	// title 재생 시간은 [TitleMin, TitleMax] 에서 균등 분포, file size 는 bitrate * 재생 시간
+	TitleMin time.Duration
+	TitleMax time.Duration
+	// session 시청 시간은 중앙값 SessionMedian, log 표준편차 SessionSigma 인 lognormal, title 재생 시간 이하
+	SessionMedian time.Duration
+	SessionSigma  float64
+	// ReleasePeriod 마다 ReleaseCount 개의 신규 title 추가, 신규 title 의 인기는 1위 title 의 ReleaseBoost 배에서
+	// ReleaseHalfLife 마다 절반으로 감소
+	ReleasePeriod   time.Duration
+	ReleaseCount    int
+	ReleaseBoost    float64
+	ReleaseHalfLife time.Duration
+}
+
+// Title : 생성된 file
+type Title struct {
+	Name      string
+	Bps       int64
+	Size      int64
+	Duration  time.Duration
+	RegisterT time.Time
+}
+
+type release struct {
+	title *Title
+	t     time.Time
+}
+
+// Generator : Model 로 session 을 시작 시각 순으로 생성, simul.EventReader 로 사용 가능
+type Generator struct {
+	m          Model
+	r          *rand.Rand
+	titles     []*Title
+	cdf        []float64 // catalog title 누적 인기
+	topWeight  float64
+	releases   []*release
+	nextRelT   time.Time
+	end        time.Time
+	maxRate    float64 // 초당 최대 도착률
+	t          time.Time
+	seq        int
+	releaseSeq int
+}
+
+// NewGenerator :
+func NewGenerator(m Model) (*Generator, error) {
+	if m.Duration <= 0 || m.SessionsPerDay <= 0 || m.Catalog <= 0 {
+		return nil, fmt.Errorf("invalid duration(%v), sessions-per-day(%v) or catalog(%v)", m.Duration, m.SessionsPerDay, m.Catalog)
+	}
+	if m.ZipfS <= 0 || m.ZipfQ < 0 {
+		return nil, fmt.Errorf("invalid zipf s(%v) q(%v)", m.ZipfS, m.ZipfQ)
+	}
+	if len(m.Diurnal) == 0 {
+		m.Diurnal = DefaultDiurnal
+	}
+	if len(m.Diurnal) != 24 {
+		return nil, fmt.Errorf("diurnal curve must have 24 values, %v", len(m.Diurnal))
+	}
+	var sum, max float64
+	for _, v := range m.Diurnal {
+		if v < 0 {
+			return nil, fmt.Errorf("invalid diurnal value %v", v)
+		}
+		sum += v
+		if v > max {
+			max = v
+		}
+	}
+	if sum == 0 {
+		return nil, fmt.Errorf("diurnal curve is all zero")
+	}
+	if len(m.Bitrates) == 0 {
+		return nil, fmt.Errorf("empty bitrate mix")
+	}
+	for _, v := range m.Bitrates {
+		if v.Bps <= 0 || v.Weight < 0 {
+			return nil, fmt.Errorf("invalid bitrate class %+v", v)
+		}
+	}
+	if m.TitleMin <= 0 || m.TitleMax < m.TitleMin {
+		return nil, fmt.Errorf("invalid title duration [%v, %v]", m.TitleMin, m.TitleMax)
+	}
+	if m.SessionMedian <= 0 || m.SessionSigma < 0 {
+		return nil, fmt.Errorf("invalid session median(%v) sigma(%v)", m.SessionMedian, m.SessionSigma)
+	}
+	if m.ReleaseCount > 0 && (m.ReleasePeriod <= 0 || m.ReleaseBoost <= 0 || m.ReleaseHalfLife <= 0) {
+		return nil, fmt.Errorf("invalid release period(%v) boost(%v) half-life(%v)", m.ReleasePeriod, m.ReleaseBoost, m.ReleaseHalfLife)
+	}
+	diurnal := make([]float64, len(m.Diurnal))
+	for i, v := range m.Diurnal {
+		diurnal[i] = v * 24 / sum
+	}
+	m.Diurnal = diurnal
+
+	g := &Generator{
+		m:       m,
+		r:       rand.New(rand.NewSource(m.Seed)),
+		end:     m.Start.Add(m.Duration),
+		maxRate: m.SessionsPerDay / 86400 * max * 24 / sum,
+		t:       m.Start,
+	}
+	var total float64
+	for k := 1; k <= m.Catalog; k++ {
+		total += math.Pow(float64(k)+m.ZipfQ, -m.ZipfS)
+		g.cdf = append(g.cdf, total)
+		g.titles = append(g.titles, g.newTitle(fmt.Sprintf("title%07d.mpg", k), m.Start))
+	}
+	g.topWeight = g.cdf[0]
+	if m.ReleaseCount > 0 {
+		g.nextRelT = m.Start.Add(m.ReleasePeriod)
+	}
+	return g, nil
+}
+
+func (g *Generator) newTitle(name string, t time.Time) *Title {
+	var sum float64
+	for _, v := range g.m.Bitrates {
+		sum += v.Weight
+	}
+	bps := g.m.Bitrates[len(g.m.Bitrates)-1].Bps
+	u := g.r.Float64() * sum
+	for _, v := range g.m.Bitrates {
+		if u < v.Weight {
+			bps = v.Bps
+			break
+		}
+		u -= v.Weight
+	}
+	du := g.m.TitleMin + time.Duration(g.r.Int63n(int64(g.m.TitleMax-g.m.TitleMin)+1))
+	du = du.Truncate(time.Second)
+	return &Title{
+		Name:      name,
+		Bps:       bps,
+		Size:      int64(float64(bps) / 8 * du.Seconds()),
+		Duration:  du,
+		RegisterT: t,
+	}
+}
+
+// rate : t 의 초당 session 도착률
+func (g *Generator) rate(t time.Time) float64 {
+	return g.m.SessionsPerDay / 86400 * g.m.Diurnal[t.Hour()]
+}
+
+// addReleases : t 까지 추가된 신규 title
+func (g *Generator) addReleases(t time.Time) {
+	for g.m.ReleaseCount > 0 && !g.nextRelT.After(t) {
+		for i := 0; i < g.m.ReleaseCount; i++ {
+			g.releaseSeq++
+			title := g.newTitle(fmt.Sprintf("release%07d.mpg", g.releaseSeq), g.nextRelT)
+			g.titles = append(g.titles, title)
+			g.releases = append(g.releases, &release{title: title, t: g.nextRelT})
+		}
+		g.nextRelT = g.nextRelT.Add(g.m.ReleasePeriod)
+	}
+}
+
+// pick : 인기에 비례하여 title 선택
+func (g *Generator) pick(t time.Time) *Title {
+	total := g.cdf[len(g.cdf)-1]
+	weights := make([]float64, len(g.releases))
+	for i, v := range g.releases {
+		halfLives := float64(t.Sub(v.t)) / float64(g.m.ReleaseHalfLife)
+		weights[i] = g.m.ReleaseBoost * g.topWeight * math.Pow(0.5, halfLives)
+		total += weights[i]
+	}
+	u := g.r.Float64() * total
+	for i, w := range weights {
+		if u < w {
+			return g.releases[i].title
+		}
+		u -= w
+	}
+	i := sort.SearchFloat64s(g.cdf, u)
+	if i >= len(g.cdf) {
+		i = len(g.cdf) - 1
+	}
+	return g.titles[i]
+}
+
+// ReadEvent : 다음 session, Duration 이 지나면 nil
+func (g *Generator) ReadEvent() *glblog.SessionInfo {
+	// 최대 도착률의 poisson 과정에서 rate/maxRate 확률로 채택 (thinning)
+	for {
+		gap := g.r.ExpFloat64() / g.maxRate
+		g.t = g.t.Add(time.Duration(gap * float64(time.Second)))
+		if !g.t.Before(g.end) {
+			return nil
+		}
+		if g.r.Float64()*g.maxRate < g.rate(g.t) {
+			break
+		}
+	}
+	g.addReleases(g.t)
+	title := g.pick(g.t)
+
+	watch := time.Duration(float64(g.m.SessionMedian) * math.Exp(g.m.SessionSigma*g.r.NormFloat64()))
+	if watch > title.Duration {
+		watch = title.Duration
+	}
+	if watch < time.Second {
+		watch = time.Second
+	}
+	g.seq++
+	started := g.t.Truncate(time.Millisecond)
+	return &glblog.SessionInfo{
+		SID:       fmt.Sprintf("gen-%010d", g.seq),
+		Started:   started,
+		Ended:     started.Add(watch.Truncate(time.Millisecond)),
+		Filename:  title.Name,
+		Bandwidth: int(title.Bps),
+		Filesize:  title.Size,
+	}
+}
+
+// Titles : 지금까지 생성된 catalog, 신규 title
+func (g *Generator) Titles() []*Title {
+	return g.titles
+}
@@ -0,0 +1,148 @@
+package workload
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testModel() Model {
+	return Model{
+		Seed:           7,
+		Start:          time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local),
+		Duration:       24 * time.Hour,
+		SessionsPerDay: 20000,
+		Catalog:        1000,
+		ZipfS:          0.8,
+		Bitrates:       []BitrateClass{BitrateClass{Bps: 2000000, Weight: 1}, BitrateClass{Bps: 6000000, Weight: 1}},
+		TitleMin:       20 * time.Minute,
+		TitleMax:       60 * time.Minute,
+		SessionMedian:  10 * time.Minute,
+		SessionSigma:   1.0,
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	g, err := NewGenerator(testModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make(map[string]*Title)
+	for _, v := range g.Titles() {
+		titles[v.Name] = v
+	}
+
+	var prev time.Time
+	count := 0
+	hits := make(map[string]int)
+	hours := make([]int, 24)
+	for {
+		si := g.ReadEvent()
+		if si == nil {
+			break
+		}
+		count++
+		if si.Started.Before(prev) {
+			t.Fatalf("not ordered by start time, %v < %v", si.Started, prev)
+		}
+		prev = si.Started
+		title := titles[si.Filename]
+		if title == nil {
+			t.Fatalf("not exists title %v", si.Filename)
+		}
+		if !si.Ended.After(si.Started) || si.Ended.Sub(si.Started) > title.Duration {
+			t.Errorf("invalid session duration %v", si)
+		}
+		if int64(si.Bandwidth) != title.Bps || si.Filesize != title.Size {
+			t.Errorf("invalid bandwidth or size %v, %+v", si, *title)
+		}
+		hits[si.Filename]++
+		hours[si.Started.Hour()]++
+	}
+	if count < 18000 || count > 22000 {
+		t.Errorf("invalid session count %v", count)
+	}
+	if hits["title0000001.mpg"] <= hits["title0000100.mpg"] {
+		t.Errorf("rank 1 hit(%v) <= rank 100 hit(%v)", hits["title0000001.mpg"], hits["title0000100.mpg"])
+	}
+	if hours[21] <= 5*hours[4] {
+		t.Errorf("peak hour(%v) must be much more than dawn(%v)", hours[21], hours[4])
+	}
+}
+
+func TestGenerator_Seed(t *testing.T) {
+	m := testModel()
+	a, _ := NewGenerator(m)
+	b, _ := NewGenerator(m)
+	m.Seed++
+	c, _ := NewGenerator(m)
+	same := true
+	for i := 0; i < 100; i++ {
+		sa, sb, sc := a.ReadEvent(), b.ReadEvent(), c.ReadEvent()
+		if sa.String() != sb.String() {
+			t.Fatalf("same seed must generate same workload, %v != %v", sa, sb)
+		}
+		if sa.String() != sc.String() {
+			same = false
+		}
+	}
+	if same {
+		t.Errorf("different seed generated same workload")
+	}
+}
+
+func TestGenerator_Release(t *testing.T) {
+	m := testModel()
+	m.ReleasePeriod = 6 * time.Hour
+	m.ReleaseCount = 2
+	m.ReleaseBoost = 5
+	m.ReleaseHalfLife = 3 * time.Hour
+	g, err := NewGenerator(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relStart := m.Start.Add(m.ReleasePeriod)
+	hits := make(map[string]int)
+	for {
+		si := g.ReadEvent()
+		if si == nil {
+			break
+		}
+		if strings.HasPrefix(si.Filename, "release") {
+			if si.Started.Before(relStart) {
+				t.Errorf("release requested before released, %v", si)
+			}
+			hits[si.Filename]++
+		} else {
+			hits["catalog"]++
+		}
+	}
+	// 6, 12, 18시에 2개씩
+	if len(hits) != 7 {
+		t.Errorf("invalid release count %v", hits)
+	}
+	// 신규 title 은 catalog 1위보다 인기
+	if hits["release0000001.mpg"] == 0 || hits["release0000001.mpg"] < hits["title0000001.mpg"] {
+		t.Errorf("new release must be popular, %v", hits)
+	}
+	if len(g.Titles()) != m.Catalog+6 {
+		t.Errorf("invalid title count %v", len(g.Titles()))
+	}
+}
+
+func TestNewGenerator_Invalid(t *testing.T) {
+	for i, f := range []func(m *Model){
+		func(m *Model) { m.Catalog = 0 },
+		func(m *Model) { m.ZipfS = 0 },
+		func(m *Model) { m.Diurnal = []float64{1, 2} },
+		func(m *Model) { m.Bitrates = nil },
+		func(m *Model) { m.TitleMax = m.TitleMin - time.Second },
+		func(m *Model) { m.ReleaseCount = 1 },
+	} {
+		m := testModel()
+		f(&m)
+		if _, err := NewGenerator(m); err == nil {
+			t.Errorf("%v: must be failed", i)
+		}
+	}
+}